@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
+
+	"swipeup-be/pkg/utils"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +18,12 @@ type Config struct {
 	DBName     string
 	ServerPort string
 	JWTSecret  string
+
+	// Kata yang ditolak pada catatan pesanan, dipisah koma
+	BlockedWords []string
+
+	// Zona waktu sekolah untuk jadwal menu dan laporan harian
+	Timezone string
 }
 
 func Load() *Config {
@@ -24,7 +33,7 @@ func Load() *Config {
 		log.Fatal("Error loading .env file")
 	}
 
-	return &Config{
+	cfg := &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgres"),
@@ -32,7 +41,20 @@ func Load() *Config {
 		DBName:     getEnv("DB_NAME", "swipeup_db"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+
+		BlockedWords: getEnvList("BLOCKED_WORDS"),
+		Timezone:     getEnv("TIMEZONE", utils.DefaultTimezone),
+	}
+
+	// Apply the blocked word list used when sanitizing order notes
+	utils.SetBlockedWords(cfg.BlockedWords)
+
+	// Apply the school timezone used by menu schedules
+	if err := utils.SetSchoolTimezone(cfg.Timezone); err != nil {
+		log.Printf("Invalid TIMEZONE %q, using %s: %v", cfg.Timezone, utils.DefaultTimezone, err)
 	}
+
+	return cfg
 }
 
 func getEnv(key, defaultValue string) string {
@@ -40,4 +62,15 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvList reads a comma-separated environment variable into a list
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
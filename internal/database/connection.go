@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"time"
 
 	"swipeup-be/internal/config"
 
@@ -10,7 +11,10 @@ import (
 )
 
 func Connect(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+	// Timestamps are stored in UTC: the columns have no timezone, so the session and every
+	// time written by the app must agree on one. Reports convert to the school's timezone.
+	// Rows written before the switch are converted by migration 0029.
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=UTC",
 		cfg.DBHost,
 		cfg.DBPort,
 		cfg.DBUser,
@@ -18,7 +22,9 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		cfg.DBName,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"swipeup-be/internal/models"
 	"swipeup-be/internal/services"
 
@@ -28,7 +29,19 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 	}
 
 	if err := h.service.AddToCart(&cart); err != nil {
-		InternalErrorResponse(c, "Failed to add item to cart", err)
+		if errors.Is(err, services.ErrInvalidOption) {
+			BadRequestResponse(c, "Invalid menu options", err)
+		} else if errors.Is(err, services.ErrInvalidNote) {
+			BadRequestResponse(c, "Invalid note", err)
+		} else if errors.Is(err, services.ErrInvalidCartItem) {
+			BadRequestResponse(c, "Invalid cart item", err)
+		} else if errors.Is(err, services.ErrMenuUnavailable) {
+			BadRequestResponse(c, "Bundle is not available", err)
+		} else if err.Error() == "record not found" {
+			NotFoundResponse(c, "Bundle not found")
+		} else {
+			InternalErrorResponse(c, "Failed to add item to cart", err)
+		}
 		return
 	}
 
@@ -71,7 +84,7 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 	SuccessResponse(c, "Cart retrieved successfully", response)
 }
 
-// UpdateCartItem updates cart item quantity
+// UpdateCartItem updates cart item quantity and, when given, its note
 func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	cartID, err := GetIDParam(c)
 	if err != nil {
@@ -80,15 +93,22 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	}
 
 	var req struct {
-		Qty int `json:"qty" binding:"required,min=1"`
+		Qty     int     `json:"qty" binding:"required,min=1"`
+		Catatan *string `json:"catatan"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	if err := h.service.UpdateCartItem(cartID, req.Qty); err != nil {
-		InternalErrorResponse(c, "Failed to update cart item", err)
+	if err := h.service.UpdateCartItem(cartID, req.Qty, req.Catatan); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Cart item not found")
+		} else if errors.Is(err, services.ErrInvalidNote) {
+			BadRequestResponse(c, "Invalid note", err)
+		} else {
+			InternalErrorResponse(c, "Failed to update cart item", err)
+		}
 		return
 	}
 
@@ -142,7 +162,11 @@ func (h *CartHandler) CheckoutCart(c *gin.Context) {
 	// Get cart details
 	details, err := h.service.CheckoutCart(req.SiswaID, req.StanID)
 	if err != nil {
-		InternalErrorResponse(c, "Failed to checkout cart", err)
+		if errors.Is(err, services.ErrMenuUnavailable) {
+			BadRequestResponse(c, "Menu is not available", err)
+		} else {
+			InternalErrorResponse(c, "Failed to checkout cart", err)
+		}
 		return
 	}
 
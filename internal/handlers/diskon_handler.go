@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"swipeup-be/internal/models"
 	"swipeup-be/internal/services"
@@ -44,7 +45,7 @@ func (h *DiskonHandler) checkDiskonPermission(c *gin.Context, diskon *models.Dis
 
 		// Admin can only modify diskon for their own stan or global (but global should be superadmin only)
 		// For stan and menu diskon, check if id_stan matches
-		if diskon.TipeDiskon == models.DiskonStan || diskon.TipeDiskon == models.DiskonMenu {
+		if diskon.TipeDiskon == models.DiskonStan || diskon.TipeDiskon == models.DiskonMenu || diskon.TipeDiskon == models.DiskonKategori {
 			return diskon.IDStan != nil && *diskon.IDStan == stan.ID
 		}
 	}
@@ -53,13 +54,16 @@ func (h *DiskonHandler) checkDiskonPermission(c *gin.Context, diskon *models.Dis
 }
 
 type CreateDiskonRequest struct {
-	NamaDiskon       string  `json:"nama_diskon" binding:"required"`
-	PersentaseDiskon float64 `json:"persentase_diskon" binding:"required,min=0,max=100"`
-	TanggalAwal      string  `json:"tanggal_awal" binding:"required"`
-	TanggalAkhir     string  `json:"tanggal_akhir" binding:"required"`
-	TipeDiskon       string  `json:"tipe_diskon" binding:"required,oneof=global stan menu"`
-	IDStan           *uint   `json:"id_stan,omitempty"`
-	IDMenu           []uint  `json:"id_menu,omitempty"`
+	NamaDiskon       string   `json:"nama_diskon" binding:"required"`
+	PersentaseDiskon float64  `json:"persentase_diskon" binding:"required,min=0,max=100"`
+	TanggalAwal      string   `json:"tanggal_awal" binding:"required"`
+	TanggalAkhir     string   `json:"tanggal_akhir" binding:"required"`
+	TipeDiskon       string   `json:"tipe_diskon" binding:"required,oneof=global stan menu kategori"`
+	IDStan           *uint    `json:"id_stan,omitempty"`
+	IDMenu           []uint   `json:"id_menu,omitempty"`
+	TargetJenis      *string  `json:"target_jenis,omitempty" binding:"omitempty,oneof=makanan minuman"`
+	TargetTag        string   `json:"target_tag,omitempty" binding:"excludesall=0x2C"`
+	AnggaranSubsidi  *float64 `json:"anggaran_subsidi,omitempty" binding:"omitempty,gt=0"`
 }
 
 func (h *DiskonHandler) Create(c *gin.Context) {
@@ -78,7 +82,7 @@ func (h *DiskonHandler) Create(c *gin.Context) {
 			ErrorResponse(c, 403, "Admin stan cannot create global diskon", nil)
 			return
 		}
-		if req.TipeDiskon == "stan" || req.TipeDiskon == "menu" {
+		if req.TipeDiskon == "stan" || req.TipeDiskon == "menu" || req.TipeDiskon == "kategori" {
 			if req.IDStan == nil {
 				BadRequestResponse(c, "id_stan is required", nil)
 				return
@@ -106,6 +110,18 @@ func (h *DiskonHandler) Create(c *gin.Context) {
 			return
 		}
 	}
+	if req.TipeDiskon == "kategori" && req.IDStan != nil {
+		// Category discount may be canteen-wide (no id_stan) or limited to one stan
+		if _, err := h.stanService.FindByID(*req.IDStan); err != nil {
+			NotFoundResponse(c, "Stan not found")
+			return
+		}
+	}
+	targetTag := models.NormalizeTags(req.TargetTag)
+	if req.TipeDiskon == "kategori" && req.TargetJenis == nil && targetTag == "" {
+		BadRequestResponse(c, "target_jenis or target_tag is required for kategori discount", nil)
+		return
+	}
 	if req.TipeDiskon == "menu" && len(req.IDMenu) == 0 {
 		BadRequestResponse(c, "id_menu is required for menu discount", nil)
 		return
@@ -142,10 +158,18 @@ func (h *DiskonHandler) Create(c *gin.Context) {
 	diskon := models.Diskon{
 		NamaDiskon:       req.NamaDiskon,
 		PersentaseDiskon: req.PersentaseDiskon,
-		TanggalAwal:      tanggalAwal,
-		TanggalAkhir:     tanggalAkhir,
+		TanggalAwal:      tanggalAwal.UTC(),
+		TanggalAkhir:     tanggalAkhir.UTC(),
 		TipeDiskon:       models.TipeDiskon(req.TipeDiskon),
 		IDStan:           req.IDStan,
+		AnggaranSubsidi:  req.AnggaranSubsidi,
+	}
+	if req.TipeDiskon == "kategori" {
+		diskon.TargetTag = targetTag
+		if req.TargetJenis != nil {
+			jenis := models.JenisMenu(*req.TargetJenis)
+			diskon.TargetJenis = &jenis
+		}
 	}
 
 	if err := h.service.Create(&diskon); err != nil {
@@ -240,10 +264,20 @@ func (h *DiskonHandler) Update(c *gin.Context) {
 		updates["persentase_diskon"] = persentase
 	}
 	if tanggalAwal, ok := updateData["tanggal_awal"].(string); ok {
-		updates["tanggal_awal"] = tanggalAwal
+		t, err := time.Parse(time.RFC3339, tanggalAwal)
+		if err != nil {
+			BadRequestResponse(c, "Invalid tanggal_awal format", err)
+			return
+		}
+		updates["tanggal_awal"] = t.UTC()
 	}
 	if tanggalAkhir, ok := updateData["tanggal_akhir"].(string); ok {
-		updates["tanggal_akhir"] = tanggalAkhir
+		t, err := time.Parse(time.RFC3339, tanggalAkhir)
+		if err != nil {
+			BadRequestResponse(c, "Invalid tanggal_akhir format", err)
+			return
+		}
+		updates["tanggal_akhir"] = t.UTC()
 	}
 	if tipeDiskon, ok := updateData["tipe_diskon"].(string); ok {
 		updates["tipe_diskon"] = tipeDiskon
@@ -252,6 +286,18 @@ func (h *DiskonHandler) Update(c *gin.Context) {
 		stanID := uint(idStan)
 		updates["id_stan"] = &stanID
 	}
+	if targetJenis, ok := updateData["target_jenis"].(string); ok {
+		updates["target_jenis"] = targetJenis
+	}
+	if targetTag, ok := updateData["target_tag"].(string); ok {
+		updates["target_tag"] = targetTag
+	}
+	if anggaran, ok := updateData["anggaran_subsidi"].(float64); ok {
+		updates["anggaran_subsidi"] = anggaran
+	}
+	if isActive, ok := updateData["is_active"].(bool); ok {
+		updates["is_active"] = isActive
+	}
 
 	if len(updates) == 0 {
 		BadRequestResponse(c, "No valid fields to update", nil)
@@ -259,7 +305,11 @@ func (h *DiskonHandler) Update(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateFields(id, updates); err != nil {
-		InternalErrorResponse(c, "Failed to update diskon", err)
+		if errors.Is(err, services.ErrInvalidDiskonTarget) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to update diskon", err)
+		}
 		return
 	}
 
@@ -1,22 +1,84 @@
 package handlers
 
 import (
+	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// parseDateRange parses start_date and end_date from query params
+// parseDateRange parses start_date and end_date from query params, in UTC like the stored times
 func parseDateRange(c *gin.Context) (startDate, endDate time.Time) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 
 	if startDateStr != "" {
 		startDate, _ = time.Parse(time.RFC3339, startDateStr)
+		startDate = startDate.UTC()
 	}
 	if endDateStr != "" {
 		endDate, _ = time.Parse(time.RFC3339, endDateStr)
+		endDate = endDate.UTC()
 	}
 
 	return startDate, endDate
 }
+
+// parseExportFormat reads the format query param of a report endpoint. An empty format or
+// json means the usual JSON response; ok is false when a bad request response was sent.
+func parseExportFormat(c *gin.Context) (format string, ok bool) {
+	switch format = c.Query("format"); format {
+	case "", "json":
+		return "", true
+	case utils.FormatCSV, utils.FormatXLSX, utils.FormatPDF:
+		return format, true
+	}
+	BadRequestResponse(c, "Invalid format, use json, csv, xlsx or pdf", nil)
+	return "", false
+}
+
+// startReportDownload sets the headers of a report file download
+func startReportDownload(c *gin.Context, name, format string) {
+	filename := name + "_" + time.Now().Format("20060102") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Content-Type", utils.ReportContentTypes[format])
+}
+
+// abortReportDownload ends a download that failed. When nothing was sent yet the download
+// headers are dropped and a JSON error is sent instead. Once part of the file was sent the
+// connection is closed without finishing the response, so the client sees a failed download
+// rather than a short file that looks complete.
+func abortReportDownload(c *gin.Context, err error) {
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		InternalErrorResponse(c, "Failed to export report", err)
+		return
+	}
+	c.Error(err)
+	c.Abort()
+	if conn, _, hijackErr := c.Writer.Hijack(); hijackErr == nil {
+		conn.Close()
+	}
+}
+
+// sendReport sends a report as a file download in the given format
+func sendReport(c *gin.Context, name, format string, table *utils.ReportTable) {
+	startReportDownload(c, name, format)
+	if err := utils.WriteReport(c.Writer, format, table); err != nil {
+		abortReportDownload(c, err)
+	}
+}
+
+// stockReason returns the requested stock change reason, defaulting to restock when
+// stock is added and correction otherwise
+func stockReason(reason string, delta int) models.StockReason {
+	if reason != "" {
+		return models.StockReason(reason)
+	}
+	if delta > 0 {
+		return models.StockRestock
+	}
+	return models.StockCorrection
+}
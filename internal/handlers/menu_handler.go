@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"strconv"
+	"strings"
 	"swipeup-be/internal/models"
 	"swipeup-be/internal/services"
 	"swipeup-be/pkg/utils"
@@ -62,6 +65,25 @@ func (h *MenuHandler) Create(c *gin.Context) {
 
 	menu.IDStan = stan.ID
 
+	alergen, err := models.NormalizeAlergen(menu.Alergen)
+	if err != nil {
+		BadRequestResponse(c, "Invalid alergen", err)
+		return
+	}
+	menu.Alergen = alergen
+	if menu.LevelPedas < 0 || menu.LevelPedas > models.MaxLevelPedas {
+		BadRequestResponse(c, "level_pedas must be between 0 and 5", nil)
+		return
+	}
+	if menu.StokMinimum < 0 {
+		BadRequestResponse(c, "stok_minimum must not be negative", nil)
+		return
+	}
+	if menu.StokPar < 0 {
+		BadRequestResponse(c, "stok_par must not be negative", nil)
+		return
+	}
+
 	// Handle base64 image if provided
 	if menu.Foto != "" && utils.IsBase64Image(menu.Foto) {
 		imagePath, err := utils.SaveBase64Image(menu.Foto)
@@ -71,6 +93,7 @@ func (h *MenuHandler) Create(c *gin.Context) {
 		}
 		menu.Foto = imagePath
 	}
+	menu.Tags = models.NormalizeTags(menu.Tags)
 
 	if err := h.service.Create(&menu); err != nil {
 		InternalErrorResponse(c, "Failed to create menu", err)
@@ -162,14 +185,61 @@ func (h *MenuHandler) Update(c *gin.Context) {
 	if stock, ok := updateData["stock"].(float64); ok {
 		updates["stock"] = int(stock)
 	}
+	if tags, ok := updateData["tags"].(string); ok {
+		updates["tags"] = models.NormalizeTags(tags)
+	}
+	if alergen, ok := updateData["alergen"].(string); ok {
+		normalized, err := models.NormalizeAlergen(alergen)
+		if err != nil {
+			BadRequestResponse(c, "Invalid alergen", err)
+			return
+		}
+		updates["alergen"] = normalized
+	}
+	if isVegetarian, ok := updateData["is_vegetarian"].(bool); ok {
+		updates["is_vegetarian"] = isVegetarian
+	}
+	if isHalal, ok := updateData["is_halal"].(bool); ok {
+		updates["is_halal"] = isHalal
+	}
+	if levelPedas, ok := updateData["level_pedas"].(float64); ok {
+		if levelPedas < 0 || levelPedas > models.MaxLevelPedas {
+			BadRequestResponse(c, "level_pedas must be between 0 and 5", nil)
+			return
+		}
+		updates["level_pedas"] = int(levelPedas)
+	}
+	if stokMinimum, ok := updateData["stok_minimum"].(float64); ok {
+		if stokMinimum < 0 {
+			BadRequestResponse(c, "stok_minimum must not be negative", nil)
+			return
+		}
+		updates["stok_minimum"] = int(stokMinimum)
+	}
+	if stokPar, ok := updateData["stok_par"].(float64); ok {
+		if stokPar < 0 {
+			BadRequestResponse(c, "stok_par must not be negative", nil)
+			return
+		}
+		updates["stok_par"] = int(stokPar)
+	}
 
 	if len(updates) == 0 {
 		BadRequestResponse(c, "No valid fields to update", nil)
 		return
 	}
 
-	if err := h.service.UpdateFields(id, updates); err != nil {
-		InternalErrorResponse(c, "Failed to update menu", err)
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	if err := h.service.UpdateFieldsBy(id, updates, userID); err != nil {
+		if errors.Is(err, services.ErrInvalidStockChange) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to update menu", err)
+		}
 		return
 	}
 
@@ -237,8 +307,80 @@ func (h *MenuHandler) SearchByName(c *gin.Context) {
 	SuccessResponse(c, "Menus retrieved successfully", menus)
 }
 
+// Search searches menus with typo tolerance, filters and sorting
+// Query params: q, stan_id, jenis, min_harga, max_harga, available, has_diskon,
+// vegetarian, halal, tanpa_alergen, max_level_pedas, sort (relevance|price_asc|price_desc|popular), page, limit
+func (h *MenuHandler) Search(c *gin.Context) {
+	page, limit, offset := ParsePaginationParams(c)
+
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan_id parameter", err)
+		return
+	}
+
+	diet, err := parseDietFilter(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid dietary filter", err)
+		return
+	}
+
+	params := services.MenuSearchParams{
+		Query:         strings.TrimSpace(c.Query("q")),
+		StanID:        stanID,
+		Jenis:         models.JenisMenu(c.Query("jenis")),
+		AvailableOnly: c.Query("available") == "true",
+		HasDiskon:     c.Query("has_diskon") == "true",
+		Diet:          diet,
+		Sort:          c.DefaultQuery("sort", services.SortRelevance),
+		Limit:         limit,
+		Offset:        offset,
+	}
+
+	if params.Jenis != "" && params.Jenis != models.JenisMakanan && params.Jenis != models.JenisMinuman {
+		BadRequestResponse(c, "Invalid jenis parameter", nil)
+		return
+	}
+	switch params.Sort {
+	case services.SortRelevance, services.SortPriceAsc, services.SortPriceDesc, services.SortPopular:
+	default:
+		BadRequestResponse(c, "Invalid sort parameter", nil)
+		return
+	}
+	if v := c.Query("min_harga"); v != "" {
+		minHarga, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			BadRequestResponse(c, "Invalid min_harga parameter", err)
+			return
+		}
+		params.MinHarga = &minHarga
+	}
+	if v := c.Query("max_harga"); v != "" {
+		maxHarga, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			BadRequestResponse(c, "Invalid max_harga parameter", err)
+			return
+		}
+		params.MaxHarga = &maxHarga
+	}
+
+	menus, total, err := h.service.Search(params)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to search menus", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Menus retrieved successfully", menus, page, limit, int(total))
+}
+
 // UpdateStock updates the stock of a menu item (inventory management)
 func (h *MenuHandler) UpdateStock(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
 	id, err := GetIDParam(c)
 	if err != nil {
 		BadRequestResponse(c, "Invalid ID", err)
@@ -246,15 +388,28 @@ func (h *MenuHandler) UpdateStock(c *gin.Context) {
 	}
 
 	var req struct {
-		Stock int `json:"stock" binding:"required,min=0"`
+		Stock      *int   `json:"stock" binding:"required,min=0"`
+		Reason     string `json:"reason"`
+		Keterangan string `json:"keterangan"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	if err := h.service.UpdateStock(id, req.Stock); err != nil {
-		InternalErrorResponse(c, "Failed to update stock", err)
+	reason := stockReason(req.Reason, 0)
+	if reason != models.StockCorrection && reason != models.StockRestock && reason != models.StockWaste {
+		BadRequestResponse(c, "reason must be restock, waste or correction", nil)
+		return
+	}
+
+	change := services.StockChange{Reason: reason, IDUser: userID, Keterangan: utils.SanitizeString(req.Keterangan)}
+	if err := h.service.UpdateStock(id, *req.Stock, change); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found")
+		} else {
+			InternalErrorResponse(c, "Failed to update stock", err)
+		}
 		return
 	}
 
@@ -264,6 +419,12 @@ func (h *MenuHandler) UpdateStock(c *gin.Context) {
 
 // AdjustStock adjusts stock by a delta value (positive to add, negative to reduce)
 func (h *MenuHandler) AdjustStock(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
 	id, err := GetIDParam(c)
 	if err != nil {
 		BadRequestResponse(c, "Invalid ID", err)
@@ -271,15 +432,28 @@ func (h *MenuHandler) AdjustStock(c *gin.Context) {
 	}
 
 	var req struct {
-		Delta int `json:"delta" binding:"required"`
+		Delta      int    `json:"delta" binding:"required"`
+		Reason     string `json:"reason"`
+		Keterangan string `json:"keterangan"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	if err := h.service.AdjustStock(id, req.Delta); err != nil {
-		InternalErrorResponse(c, "Failed to adjust stock", err)
+	reason := stockReason(req.Reason, req.Delta)
+	if err := services.ValidateManualChange(reason, req.Delta); err != nil {
+		BadRequestResponse(c, "Invalid stock change", err)
+		return
+	}
+
+	change := services.StockChange{Reason: reason, IDUser: userID, Keterangan: utils.SanitizeString(req.Keterangan)}
+	if err := h.service.AdjustStock(id, req.Delta, change); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found")
+		} else {
+			InternalErrorResponse(c, "Failed to adjust stock", err)
+		}
 		return
 	}
 
@@ -295,11 +469,83 @@ func (h *MenuHandler) GetAvailableByStanID(c *gin.Context) {
 		return
 	}
 
+	diet, err := parseDietFilter(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid dietary filter", err)
+		return
+	}
+
 	menus, err := h.service.GetAvailableMenuByStanID(stanID)
 	if err != nil {
 		InternalErrorResponse(c, "Failed to get available menus", err)
 		return
 	}
 
-	SuccessResponse(c, "Available menus retrieved successfully", menus)
+	SuccessResponse(c, "Available menus retrieved successfully", services.FilterMenus(menus, diet))
+}
+
+// GetAvailableBundlesByStanID gets the bundles (paket) of a stan that can be ordered now
+func (h *MenuHandler) GetAvailableBundlesByStanID(c *gin.Context) {
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil || stanID == 0 {
+		BadRequestResponse(c, "Invalid stan_id parameter", err)
+		return
+	}
+
+	diet, err := parseDietFilter(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid dietary filter", err)
+		return
+	}
+
+	bundles, err := h.service.GetAvailableBundlesByStanID(stanID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get available bundles", err)
+		return
+	}
+
+	SuccessResponse(c, "Available bundles retrieved successfully", services.FilterBundles(bundles, diet))
+}
+
+// GetTodayByStanID gets the menus a stan offers today, with their time windows
+func (h *MenuHandler) GetTodayByStanID(c *gin.Context) {
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil || stanID == 0 {
+		BadRequestResponse(c, "Invalid stan_id parameter", err)
+		return
+	}
+
+	menus, err := h.service.GetTodayMenuByStanID(stanID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get today's menus", err)
+		return
+	}
+
+	SuccessResponse(c, "Today's menus retrieved successfully", menus)
+}
+
+// parseDietFilter reads vegetarian, halal, tanpa_alergen (comma separated) and max_level_pedas query params
+func parseDietFilter(c *gin.Context) (services.DietFilter, error) {
+	filter := services.DietFilter{
+		Vegetarian: c.Query("vegetarian") == "true",
+		Halal:      c.Query("halal") == "true",
+	}
+
+	alergen, err := models.NormalizeAlergen(c.Query("tanpa_alergen"))
+	if err != nil {
+		return filter, err
+	}
+	if alergen != "" {
+		filter.ExcludeAlergen = strings.Split(alergen, ",")
+	}
+
+	if v := c.Query("max_level_pedas"); v != "" {
+		level, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.MaxLevelPedas = &level
+	}
+
+	return filter, nil
 }
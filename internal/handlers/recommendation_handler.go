@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+	"swipeup-be/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRecommendations caps the limit query param of the recommendation endpoint
+const maxRecommendations = 50
+
+type RecommendationHandler struct {
+	service        *services.RecommendationService
+	studentService *services.StudentService
+}
+
+func NewRecommendationHandler(
+	service *services.RecommendationService,
+	studentService *services.StudentService,
+) *RecommendationHandler {
+	return &RecommendationHandler{
+		service:        service,
+		studentService: studentService,
+	}
+}
+
+// GetForStudent returns menu recommendations for the authenticated student.
+// Query params: stan_id (optional), limit (default 10)
+func (h *RecommendationHandler) GetForStudent(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	// Get siswa ID from user
+	siswa, err := h.studentService.GetSiswaByUserID(userID)
+	if err != nil {
+		NotFoundResponse(c, "Siswa profile not found")
+		return
+	}
+
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan_id", err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxRecommendations {
+		BadRequestResponse(c, "limit must be between 1 and 50", nil)
+		return
+	}
+
+	recommendations, err := h.service.GetForSiswa(siswa.ID, stanID, limit)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get recommendations", err)
+		return
+	}
+
+	SuccessResponse(c, "Recommendations retrieved successfully", recommendations)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"swipeup-be/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewHandler serves the public review listings of stans and menus
+type ReviewHandler struct {
+	service *services.ReviewService
+}
+
+func NewReviewHandler(service *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{service: service}
+}
+
+// GetByStanID retrieves the visible reviews of a stan
+func (h *ReviewHandler) GetByStanID(c *gin.Context) {
+	stanID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan ID", err)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	reviews, total, err := h.service.GetByStanID(stanID, false, limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get reviews", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Reviews retrieved successfully", reviews, page, limit, int(total))
+}
+
+// GetByMenuID retrieves the visible reviews that rated a menu
+func (h *ReviewHandler) GetByMenuID(c *gin.Context) {
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	reviews, total, err := h.service.GetByMenuID(menuID, limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get reviews", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Reviews retrieved successfully", reviews, page, limit, int(total))
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"swipeup-be/internal/models"
 	"swipeup-be/internal/services"
 	"swipeup-be/pkg/utils"
@@ -67,6 +69,18 @@ func (h *StanAdminHandler) UpdateStanProfile(c *gin.Context) {
 	if telp, ok := updateData["telp"].(string); ok {
 		updates["telp"] = telp
 	}
+	if jamResetStok, ok := updateData["jam_reset_stok"].(string); ok {
+		// Empty turns the automatic daily stock reset off
+		if jamResetStok != "" {
+			jam, err := time.Parse("15:04", jamResetStok)
+			if err != nil {
+				BadRequestResponse(c, "jam_reset_stok must be HH:MM", err)
+				return
+			}
+			jamResetStok = jam.Format("15:04")
+		}
+		updates["jam_reset_stok"] = jamResetStok
+	}
 	if foto, ok := updateData["foto"].(string); ok {
 		// Handle base64 image
 		if utils.IsBase64Image(foto) {
@@ -157,6 +171,25 @@ func (h *StanAdminHandler) CreateMenu(c *gin.Context) {
 		return
 	}
 
+	alergen, err := models.NormalizeAlergen(menu.Alergen)
+	if err != nil {
+		BadRequestResponse(c, "Invalid alergen", err)
+		return
+	}
+	menu.Alergen = alergen
+	if menu.LevelPedas < 0 || menu.LevelPedas > models.MaxLevelPedas {
+		BadRequestResponse(c, "level_pedas must be between 0 and 5", nil)
+		return
+	}
+	if menu.StokMinimum < 0 {
+		BadRequestResponse(c, "stok_minimum must not be negative", nil)
+		return
+	}
+	if menu.StokPar < 0 {
+		BadRequestResponse(c, "stok_par must not be negative", nil)
+		return
+	}
+
 	// Handle base64 image if provided
 	if menu.Foto != "" && utils.IsBase64Image(menu.Foto) {
 		imagePath, err := utils.SaveBase64Image(menu.Foto)
@@ -166,6 +199,7 @@ func (h *StanAdminHandler) CreateMenu(c *gin.Context) {
 		}
 		menu.Foto = imagePath
 	}
+	menu.Tags = models.NormalizeTags(menu.Tags)
 
 	if err := h.stanAdminService.CreateMenu(userID, &menu); err != nil {
 		InternalErrorResponse(c, "Failed to create menu", err)
@@ -231,6 +265,44 @@ func (h *StanAdminHandler) UpdateMenu(c *gin.Context) {
 	if stock, ok := updateData["stock"].(float64); ok {
 		updates["stock"] = int(stock)
 	}
+	if tags, ok := updateData["tags"].(string); ok {
+		updates["tags"] = models.NormalizeTags(tags)
+	}
+	if alergen, ok := updateData["alergen"].(string); ok {
+		normalized, err := models.NormalizeAlergen(alergen)
+		if err != nil {
+			BadRequestResponse(c, "Invalid alergen", err)
+			return
+		}
+		updates["alergen"] = normalized
+	}
+	if isVegetarian, ok := updateData["is_vegetarian"].(bool); ok {
+		updates["is_vegetarian"] = isVegetarian
+	}
+	if isHalal, ok := updateData["is_halal"].(bool); ok {
+		updates["is_halal"] = isHalal
+	}
+	if levelPedas, ok := updateData["level_pedas"].(float64); ok {
+		if levelPedas < 0 || levelPedas > models.MaxLevelPedas {
+			BadRequestResponse(c, "level_pedas must be between 0 and 5", nil)
+			return
+		}
+		updates["level_pedas"] = int(levelPedas)
+	}
+	if stokMinimum, ok := updateData["stok_minimum"].(float64); ok {
+		if stokMinimum < 0 {
+			BadRequestResponse(c, "stok_minimum must not be negative", nil)
+			return
+		}
+		updates["stok_minimum"] = int(stokMinimum)
+	}
+	if stokPar, ok := updateData["stok_par"].(float64); ok {
+		if stokPar < 0 {
+			BadRequestResponse(c, "stok_par must not be negative", nil)
+			return
+		}
+		updates["stok_par"] = int(stokPar)
+	}
 
 	if len(updates) == 0 {
 		BadRequestResponse(c, "No valid fields to update", nil)
@@ -240,6 +312,8 @@ func (h *StanAdminHandler) UpdateMenu(c *gin.Context) {
 	if err := h.stanAdminService.UpdateMenu(userID, menuID, updates); err != nil {
 		if err.Error() == "record not found" {
 			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidStockChange) {
+			BadRequestResponse(c, err.Error(), nil)
 		} else {
 			InternalErrorResponse(c, "Failed to update menu", err)
 		}
@@ -306,33 +380,41 @@ func (h *StanAdminHandler) GetMenus(c *gin.Context) {
 	SuccessResponse(c, "Menus retrieved successfully", menus)
 }
 
-// UpdateStock updates the stock of a menu item
-func (h *StanAdminHandler) UpdateStock(c *gin.Context) {
+// GetMenuOptionGroups retrieves option groups of a menu owned by the stan
+func (h *StanAdminHandler) GetMenuOptionGroups(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
 	menuID, err := GetIDParam(c)
 	if err != nil {
 		BadRequestResponse(c, "Invalid menu ID", err)
 		return
 	}
 
-	var req struct {
-		Stock int `json:"stock" binding:"required,min=0"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "Invalid request body", err)
+	groups, err := h.stanAdminService.GetMenuOptionGroups(userID, menuID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to get option groups", err)
+		}
 		return
 	}
 
-	if err := h.menuService.UpdateStock(menuID, req.Stock); err != nil {
-		InternalErrorResponse(c, "Failed to update stock", err)
+	SuccessResponse(c, "Option groups retrieved successfully", groups)
+}
+
+// CreateMenuOptionGroup creates an option group (e.g. ukuran, level pedas, topping) for a menu
+func (h *StanAdminHandler) CreateMenuOptionGroup(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	menu, _ := h.menuService.FindByID(menuID, "Stan")
-	SuccessResponse(c, "Stock updated successfully", menu)
-}
-
-// AdjustStock adjusts stock by a delta value
-func (h *StanAdminHandler) AdjustStock(c *gin.Context) {
 	menuID, err := GetIDParam(c)
 	if err != nil {
 		BadRequestResponse(c, "Invalid menu ID", err)
@@ -340,148 +422,234 @@ func (h *StanAdminHandler) AdjustStock(c *gin.Context) {
 	}
 
 	var req struct {
-		Delta int `json:"delta" binding:"required"`
+		NamaGroup  string `json:"nama_group" binding:"required"`
+		IsRequired bool   `json:"is_required"`
+		MinPilihan int    `json:"min_pilihan" binding:"min=0"`
+		MaxPilihan int    `json:"max_pilihan" binding:"min=0"`
+		Urutan     int    `json:"urutan"`
+		Options    []struct {
+			NamaOpsi      string  `json:"nama_opsi" binding:"required"`
+			HargaTambahan float64 `json:"harga_tambahan" binding:"min=0"`
+			Stock         *int    `json:"stock" binding:"omitempty,min=0"`
+		} `json:"options" binding:"dive"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	if err := h.menuService.AdjustStock(menuID, req.Delta); err != nil {
-		InternalErrorResponse(c, "Failed to adjust stock", err)
+	if req.MaxPilihan == 0 {
+		req.MaxPilihan = 1
+	}
+	if req.MinPilihan > req.MaxPilihan {
+		BadRequestResponse(c, "min_pilihan cannot be greater than max_pilihan", nil)
 		return
 	}
 
-	menu, _ := h.menuService.FindByID(menuID, "Stan")
-	SuccessResponse(c, "Stock adjusted successfully", menu)
+	group := models.MenuOptionGroup{
+		IDMenu:     menuID,
+		NamaGroup:  req.NamaGroup,
+		IsRequired: req.IsRequired,
+		MinPilihan: req.MinPilihan,
+		MaxPilihan: req.MaxPilihan,
+		Urutan:     req.Urutan,
+	}
+	for _, opt := range req.Options {
+		group.Options = append(group.Options, models.MenuOption{
+			NamaOpsi:      opt.NamaOpsi,
+			HargaTambahan: opt.HargaTambahan,
+			Stock:         opt.Stock,
+			IsAvailable:   true,
+		})
+	}
+
+	if err := h.stanAdminService.CreateMenuOptionGroup(userID, &group); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to create option group", err)
+		}
+		return
+	}
+
+	CreatedResponse(c, "Option group created successfully", group)
 }
 
-// CreateStanDiscount creates a new stan-level discount
-func (h *StanAdminHandler) CreateStanDiscount(c *gin.Context) {
+// UpdateMenuOptionGroup updates an option group
+func (h *StanAdminHandler) UpdateMenuOptionGroup(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	var req struct {
-		NamaDiskon       string  `json:"nama_diskon" binding:"required"`
-		PersentaseDiskon float64 `json:"persentase_diskon" binding:"required,min=0,max=100"`
-		TanggalAwal      string  `json:"tanggal_awal" binding:"required"`
-		TanggalAkhir     string  `json:"tanggal_akhir" binding:"required"`
+	groupID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid option group ID", err)
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
+
+	// Bind request body to a map to only update provided fields
+	var updateData map[string]interface{}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	// Parse dates
-	tanggalAwal, err := time.Parse(time.RFC3339, req.TanggalAwal)
-	if err != nil {
-		BadRequestResponse(c, "Invalid tanggal_awal format", err)
-		return
+	// Build updates map with only allowed fields
+	updates := make(map[string]interface{})
+	if namaGroup, ok := updateData["nama_group"].(string); ok {
+		updates["nama_group"] = namaGroup
 	}
-	tanggalAkhir, err := time.Parse(time.RFC3339, req.TanggalAkhir)
-	if err != nil {
-		BadRequestResponse(c, "Invalid tanggal_akhir format", err)
-		return
+	if isRequired, ok := updateData["is_required"].(bool); ok {
+		updates["is_required"] = isRequired
+	}
+	if minPilihan, ok := updateData["min_pilihan"].(float64); ok {
+		updates["min_pilihan"] = int(minPilihan)
+	}
+	if maxPilihan, ok := updateData["max_pilihan"].(float64); ok {
+		updates["max_pilihan"] = int(maxPilihan)
+	}
+	if urutan, ok := updateData["urutan"].(float64); ok {
+		updates["urutan"] = int(urutan)
 	}
 
-	diskon := models.Diskon{
-		NamaDiskon:       req.NamaDiskon,
-		PersentaseDiskon: req.PersentaseDiskon,
-		TanggalAwal:      tanggalAwal,
-		TanggalAkhir:     tanggalAkhir,
+	if len(updates) == 0 {
+		BadRequestResponse(c, "No valid fields to update", nil)
+		return
 	}
 
-	if err := h.stanAdminService.CreateStanDiscount(userID, &diskon); err != nil {
-		InternalErrorResponse(c, "Failed to create discount", err)
+	if err := h.stanAdminService.UpdateMenuOptionGroup(userID, groupID, updates); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Option group not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidOption) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to update option group", err)
+		}
 		return
 	}
 
-	CreatedResponse(c, "Discount created successfully", diskon)
+	SuccessResponse(c, "Option group updated successfully", nil)
 }
 
-// CreateMenuDiscount creates a new menu-level discount
-func (h *StanAdminHandler) CreateMenuDiscount(c *gin.Context) {
+// DeleteMenuOptionGroup deletes an option group and its options
+func (h *StanAdminHandler) DeleteMenuOptionGroup(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	var req struct {
-		NamaDiskon       string  `json:"nama_diskon" binding:"required"`
-		PersentaseDiskon float64 `json:"persentase_diskon" binding:"required,min=0,max=100"`
-		TanggalAwal      string  `json:"tanggal_awal" binding:"required"`
-		TanggalAkhir     string  `json:"tanggal_akhir" binding:"required"`
-		MenuIDs          []uint  `json:"menu_ids" binding:"required,min=1"`
+	groupID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid option group ID", err)
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "Invalid request body", err)
+
+	if err := h.stanAdminService.DeleteMenuOptionGroup(userID, groupID); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Option group not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to delete option group", err)
+		}
 		return
 	}
 
-	// Parse dates
-	tanggalAwal, err := time.Parse(time.RFC3339, req.TanggalAwal)
-	if err != nil {
-		BadRequestResponse(c, "Invalid tanggal_awal format", err)
+	SuccessResponse(c, "Option group deleted successfully", nil)
+}
+
+// CreateMenuOption adds an option to an option group
+func (h *StanAdminHandler) CreateMenuOption(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
-	tanggalAkhir, err := time.Parse(time.RFC3339, req.TanggalAkhir)
+
+	groupID, err := GetIDParam(c)
 	if err != nil {
-		BadRequestResponse(c, "Invalid tanggal_akhir format", err)
+		BadRequestResponse(c, "Invalid option group ID", err)
 		return
 	}
 
-	diskon := models.Diskon{
-		NamaDiskon:       req.NamaDiskon,
-		PersentaseDiskon: req.PersentaseDiskon,
-		TanggalAwal:      tanggalAwal,
-		TanggalAkhir:     tanggalAkhir,
+	var req struct {
+		NamaOpsi      string  `json:"nama_opsi" binding:"required"`
+		HargaTambahan float64 `json:"harga_tambahan" binding:"min=0"`
+		Stock         *int    `json:"stock" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
 	}
 
-	if err := h.stanAdminService.CreateMenuDiscount(userID, &diskon, req.MenuIDs); err != nil {
-		InternalErrorResponse(c, "Failed to create discount", err)
+	option := models.MenuOption{
+		IDGroup:       groupID,
+		NamaOpsi:      req.NamaOpsi,
+		HargaTambahan: req.HargaTambahan,
+		Stock:         req.Stock,
+		IsAvailable:   true,
+	}
+
+	if err := h.stanAdminService.CreateMenuOption(userID, &option); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Option group not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to create option", err)
+		}
 		return
 	}
 
-	CreatedResponse(c, "Discount created successfully", diskon)
+	CreatedResponse(c, "Option created successfully", option)
 }
 
-// UpdateDiscount updates a discount
-func (h *StanAdminHandler) UpdateDiscount(c *gin.Context) {
+// UpdateMenuOption updates an option
+func (h *StanAdminHandler) UpdateMenuOption(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	diskonID, err := GetIDParam(c)
+	optionID, err := GetIDParam(c)
 	if err != nil {
-		BadRequestResponse(c, "Invalid discount ID", err)
+		BadRequestResponse(c, "Invalid option ID", err)
 		return
 	}
 
+	// Bind request body to a map to only update provided fields
 	var updateData map[string]interface{}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	// Build updates map
+	// Build updates map with only allowed fields
 	updates := make(map[string]interface{})
-	if namaDiskon, ok := updateData["nama_diskon"].(string); ok {
-		updates["nama_diskon"] = namaDiskon
-	}
-	if persentase, ok := updateData["persentase_diskon"].(float64); ok {
-		updates["persentase_diskon"] = persentase
-	}
-	if tanggalAwal, ok := updateData["tanggal_awal"].(string); ok {
-		updates["tanggal_awal"] = tanggalAwal
+	if namaOpsi, ok := updateData["nama_opsi"].(string); ok {
+		updates["nama_opsi"] = namaOpsi
 	}
-	if tanggalAkhir, ok := updateData["tanggal_akhir"].(string); ok {
-		updates["tanggal_akhir"] = tanggalAkhir
+	if hargaTambahan, ok := updateData["harga_tambahan"].(float64); ok {
+		if hargaTambahan < 0 {
+			BadRequestResponse(c, "harga_tambahan cannot be negative", nil)
+			return
+		}
+		updates["harga_tambahan"] = hargaTambahan
+	}
+	if isAvailable, ok := updateData["is_available"].(bool); ok {
+		updates["is_available"] = isAvailable
+	}
+	if stock, exists := updateData["stock"]; exists {
+		// null stops tracking stock for the option
+		if stock == nil {
+			updates["stock"] = nil
+		} else if stockValue, ok := stock.(float64); ok {
+			if stockValue < 0 {
+				BadRequestResponse(c, "stock cannot be negative", nil)
+				return
+			}
+			updates["stock"] = int(stockValue)
+		}
 	}
 
 	if len(updates) == 0 {
@@ -489,172 +657,1979 @@ func (h *StanAdminHandler) UpdateDiscount(c *gin.Context) {
 		return
 	}
 
-	if err := h.stanAdminService.UpdateDiscount(userID, diskonID, updates); err != nil {
+	if err := h.stanAdminService.UpdateMenuOption(userID, optionID, updates); err != nil {
 		if err.Error() == "record not found" {
-			NotFoundResponse(c, "Discount not found or you don't have permission")
+			NotFoundResponse(c, "Option not found or you don't have permission")
 		} else {
-			InternalErrorResponse(c, "Failed to update discount", err)
+			InternalErrorResponse(c, "Failed to update option", err)
 		}
 		return
 	}
 
-	SuccessResponse(c, "Discount updated successfully", nil)
+	SuccessResponse(c, "Option updated successfully", nil)
 }
 
-// DeleteDiscount deletes a discount
-func (h *StanAdminHandler) DeleteDiscount(c *gin.Context) {
+// DeleteMenuOption deletes an option
+func (h *StanAdminHandler) DeleteMenuOption(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	diskonID, err := GetIDParam(c)
+	optionID, err := GetIDParam(c)
 	if err != nil {
-		BadRequestResponse(c, "Invalid discount ID", err)
+		BadRequestResponse(c, "Invalid option ID", err)
 		return
 	}
 
-	if err := h.stanAdminService.DeleteDiscount(userID, diskonID); err != nil {
+	if err := h.stanAdminService.DeleteMenuOption(userID, optionID); err != nil {
 		if err.Error() == "record not found" {
-			NotFoundResponse(c, "Discount not found or you don't have permission")
+			NotFoundResponse(c, "Option not found or you don't have permission")
 		} else {
-			InternalErrorResponse(c, "Failed to delete discount", err)
+			InternalErrorResponse(c, "Failed to delete option", err)
 		}
 		return
 	}
 
-	SuccessResponse(c, "Discount deleted successfully", nil)
+	SuccessResponse(c, "Option deleted successfully", nil)
 }
 
-// GetDiscounts retrieves all discounts for the stan
-func (h *StanAdminHandler) GetDiscounts(c *gin.Context) {
+// maxImportFileSize limits uploaded menu import files
+const maxImportFileSize = 10 << 20
+
+// ImportMenus imports menus from an uploaded CSV or XLSX file (form field "file").
+// With dry_run=true the rows are only validated and the planned changes are returned.
+func (h *StanAdminHandler) ImportMenus(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	diskon, err := h.stanAdminService.GetDiscountsByStan(userID)
+	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		InternalErrorResponse(c, "Failed to get discounts", err)
+		BadRequestResponse(c, "File is required", err)
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		BadRequestResponse(c, "File is too large (max 10MB)", nil)
 		return
 	}
 
-	SuccessResponse(c, "Discounts retrieved successfully", diskon)
-}
+	format := utils.SpreadsheetFormat(fileHeader.Filename)
+	if format == "" {
+		BadRequestResponse(c, "Unsupported file type, use .csv or .xlsx", nil)
+		return
+	}
 
-// GetActiveDiscounts retrieves active discounts for the stan
-func (h *StanAdminHandler) GetActiveDiscounts(c *gin.Context) {
-	userID, exists := GetUserIDFromContext(c)
-	if !exists {
-		BadRequestResponse(c, "User not authenticated", nil)
+	file, err := fileHeader.Open()
+	if err != nil {
+		BadRequestResponse(c, "Failed to read file", err)
 		return
 	}
+	defer file.Close()
 
-	diskon, err := h.stanAdminService.GetActiveDiscountsByStan(userID)
+	records, err := utils.ReadSpreadsheet(file, format)
 	if err != nil {
-		InternalErrorResponse(c, "Failed to get active discounts", err)
+		BadRequestResponse(c, "Failed to parse file", err)
 		return
 	}
 
-	SuccessResponse(c, "Active discounts retrieved successfully", diskon)
+	dryRun := c.Query("dry_run") == "true"
+	result, err := h.stanAdminService.ImportMenus(userID, records, dryRun)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to import menus", err)
+		return
+	}
+
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, Response{
+			Success: false,
+			Message: "Import file has invalid rows",
+			Data:    result,
+		})
+		return
+	}
+
+	if dryRun {
+		SuccessResponse(c, "Import validated successfully", result)
+		return
+	}
+	SuccessResponse(c, "Menus imported successfully", result)
 }
 
-// GetTransactions retrieves all transactions for the stan
-func (h *StanAdminHandler) GetTransactions(c *gin.Context) {
+// ExportMenus exports the stan's menus as CSV or XLSX (format query param, default csv)
+func (h *StanAdminHandler) ExportMenus(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	transaksi, err := h.stanAdminService.GetTransactionsByStan(userID)
+	format := c.DefaultQuery("format", utils.FormatCSV)
+	if format != utils.FormatCSV && format != utils.FormatXLSX {
+		BadRequestResponse(c, "Invalid format, use csv or xlsx", nil)
+		return
+	}
+
+	records, err := h.stanAdminService.ExportMenus(userID)
 	if err != nil {
-		InternalErrorResponse(c, "Failed to get transactions", err)
+		InternalErrorResponse(c, "Failed to export menus", err)
 		return
 	}
 
-	SuccessResponse(c, "Transactions retrieved successfully", transaksi)
+	filename := "menu_" + time.Now().Format("20060102") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == utils.FormatXLSX {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		err = utils.WriteXLSX(c.Writer, "Menu", records)
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		err = utils.WriteCSV(c.Writer, records)
+	}
+	if err != nil {
+		c.Error(err)
+	}
 }
 
-// GetTransactionsByDateRange retrieves transactions for the stan within a date range
-func (h *StanAdminHandler) GetTransactionsByDateRange(c *gin.Context) {
+// GetReviews retrieves the stan's reviews, including ones hidden by the superadmin
+func (h *StanAdminHandler) GetReviews(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	startDate, endDate := parseDateRange(c)
-
-	transaksi, err := h.stanAdminService.GetTransactionsByStanAndDateRange(userID, startDate, endDate)
+	page, limit, offset := ParsePaginationParams(c)
+	reviews, total, err := h.stanAdminService.GetReviews(userID, limit, offset)
 	if err != nil {
-		InternalErrorResponse(c, "Failed to get transactions", err)
+		InternalErrorResponse(c, "Failed to get reviews", err)
 		return
 	}
 
-	SuccessResponse(c, "Transactions retrieved successfully", transaksi)
+	PaginatedSuccessResponse(c, "Reviews retrieved successfully", reviews, page, limit, int(total))
 }
 
-// UpdateTransactionStatus updates the status of a transaction
-func (h *StanAdminHandler) UpdateTransactionStatus(c *gin.Context) {
+// ReplyReview sets the stan's reply to a review
+func (h *StanAdminHandler) ReplyReview(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	transaksiID, err := GetIDParam(c)
+	reviewID, err := GetIDParam(c)
 	if err != nil {
-		BadRequestResponse(c, "Invalid transaction ID", err)
+		BadRequestResponse(c, "Invalid review ID", err)
 		return
 	}
 
 	var req struct {
-		Status models.StatusTransaksi `json:"status" binding:"required"`
+		Balasan string `json:"balasan" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	if err := h.stanAdminService.UpdateTransactionStatus(userID, transaksiID, req.Status); err != nil {
+	if err := h.stanAdminService.ReplyReview(userID, reviewID, req.Balasan); err != nil {
 		if err.Error() == "record not found" {
-			NotFoundResponse(c, "Transaction not found or you don't have permission")
+			NotFoundResponse(c, "Review not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidReview) {
+			BadRequestResponse(c, "Invalid reply", err)
 		} else {
-			InternalErrorResponse(c, "Failed to update transaction status", err)
+			InternalErrorResponse(c, "Failed to reply to review", err)
 		}
 		return
 	}
 
-	SuccessResponse(c, "Transaction status updated successfully", nil)
+	SuccessResponse(c, "Review replied successfully", nil)
 }
 
-// GetRevenue retrieves revenue for the stan
-func (h *StanAdminHandler) GetRevenue(c *gin.Context) {
+// GetMenuPriceHistory retrieves the price change history of a menu
+func (h *StanAdminHandler) GetMenuPriceHistory(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
 	if !exists {
 		BadRequestResponse(c, "User not authenticated", nil)
 		return
 	}
 
-	startDate, endDate := parseDateRange(c)
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
 
-	totalRevenue, totalOrders, err := h.stanAdminService.GetStanRevenue(userID, startDate, endDate)
+	history, err := h.stanAdminService.GetMenuPriceHistory(userID, menuID)
 	if err != nil {
-		InternalErrorResponse(c, "Failed to get revenue", err)
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to get menu price history", err)
+		}
 		return
 	}
 
-	response := gin.H{
-		"total_revenue": totalRevenue,
-		"total_orders":  totalOrders,
+	SuccessResponse(c, "Menu price history retrieved successfully", history)
+}
+
+// GetScheduledPriceChanges retrieves the scheduled price changes of a menu
+func (h *StanAdminHandler) GetScheduledPriceChanges(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	changes, err := h.stanAdminService.GetScheduledPriceChanges(userID, menuID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to get scheduled price changes", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Scheduled price changes retrieved successfully", changes)
+}
+
+// SchedulePriceChange schedules a new price for a menu that takes effect at berlaku_mulai
+func (h *StanAdminHandler) SchedulePriceChange(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	var req struct {
+		HargaBaru    *float64 `json:"harga_baru" binding:"required,gt=0"`
+		BerlakuMulai string   `json:"berlaku_mulai" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	berlakuMulai, err := time.Parse(time.RFC3339, req.BerlakuMulai)
+	if err != nil {
+		BadRequestResponse(c, "Invalid berlaku_mulai format", err)
+		return
+	}
+
+	change := models.MenuPriceChange{
+		IDMenu:       menuID,
+		HargaBaru:    *req.HargaBaru,
+		BerlakuMulai: berlakuMulai.UTC(),
+	}
+	if err := h.stanAdminService.SchedulePriceChange(userID, &change); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidPriceChange) {
+			BadRequestResponse(c, "Invalid price change", err)
+		} else {
+			InternalErrorResponse(c, "Failed to schedule price change", err)
+		}
+		return
+	}
+
+	CreatedResponse(c, "Price change scheduled successfully", change)
+}
+
+// CancelPriceChange cancels a pending scheduled price change
+func (h *StanAdminHandler) CancelPriceChange(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	changeID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid price change ID", err)
+		return
+	}
+
+	if err := h.stanAdminService.CancelPriceChange(userID, changeID); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Price change not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidPriceChange) {
+			BadRequestResponse(c, "Price change can no longer be cancelled", err)
+		} else {
+			InternalErrorResponse(c, "Failed to cancel price change", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Price change cancelled successfully", nil)
+}
+
+// GetMenuSchedules retrieves the availability schedules of a menu
+func (h *StanAdminHandler) GetMenuSchedules(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	schedules, err := h.stanAdminService.GetMenuSchedules(userID, menuID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to get menu schedules", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Menu schedules retrieved successfully", schedules)
+}
+
+// SetMenuSchedules replaces the availability schedules of a menu.
+// An empty list makes the menu available at all times again.
+func (h *StanAdminHandler) SetMenuSchedules(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	var req struct {
+		Schedules []struct {
+			Hari           string `json:"hari"`
+			JamMulai       string `json:"jam_mulai"`
+			JamSelesai     string `json:"jam_selesai"`
+			TanggalMulai   string `json:"tanggal_mulai"`
+			TanggalSelesai string `json:"tanggal_selesai"`
+		} `json:"schedules"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	schedules := make([]models.MenuSchedule, 0, len(req.Schedules))
+	for _, item := range req.Schedules {
+		hari, err := models.NormalizeHari(item.Hari)
+		if err != nil {
+			BadRequestResponse(c, "Invalid hari", err)
+			return
+		}
+		jamMulai, err := models.NormalizeJam(item.JamMulai)
+		if err != nil {
+			BadRequestResponse(c, "Invalid jam_mulai", err)
+			return
+		}
+		jamSelesai, err := models.NormalizeJam(item.JamSelesai)
+		if err != nil {
+			BadRequestResponse(c, "Invalid jam_selesai", err)
+			return
+		}
+		// Windows past midnight are not supported; they are set as two schedules
+		if jamMulai != "" && jamSelesai != "" && jamMulai >= jamSelesai {
+			BadRequestResponse(c, "jam_mulai must be before jam_selesai; split windows past midnight into two schedules", nil)
+			return
+		}
+
+		schedule := models.MenuSchedule{
+			Hari:       hari,
+			JamMulai:   jamMulai,
+			JamSelesai: jamSelesai,
+		}
+		if item.TanggalMulai != "" {
+			tanggalMulai, err := time.Parse(time.RFC3339, item.TanggalMulai)
+			if err != nil {
+				BadRequestResponse(c, "Invalid tanggal_mulai format", err)
+				return
+			}
+			tanggalMulai = tanggalMulai.UTC()
+			schedule.TanggalMulai = &tanggalMulai
+		}
+		if item.TanggalSelesai != "" {
+			tanggalSelesai, err := time.Parse(time.RFC3339, item.TanggalSelesai)
+			if err != nil {
+				BadRequestResponse(c, "Invalid tanggal_selesai format", err)
+				return
+			}
+			tanggalSelesai = tanggalSelesai.UTC()
+			schedule.TanggalSelesai = &tanggalSelesai
+		}
+		if schedule.TanggalMulai != nil && schedule.TanggalSelesai != nil && schedule.TanggalSelesai.Before(*schedule.TanggalMulai) {
+			BadRequestResponse(c, "tanggal_selesai must be after tanggal_mulai", nil)
+			return
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := h.stanAdminService.SetMenuSchedules(userID, menuID, schedules); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to update menu schedules", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Menu schedules updated successfully", schedules)
+}
+
+// bundleItemRequest is a component menu of a bundle in create/update requests
+type bundleItemRequest struct {
+	IDMenu uint `json:"id_menu" binding:"required"`
+	Qty    int  `json:"qty" binding:"required,min=1"`
+}
+
+func toBundleItems(reqItems []bundleItemRequest) []models.MenuBundleItem {
+	items := make([]models.MenuBundleItem, 0, len(reqItems))
+	for _, item := range reqItems {
+		items = append(items, models.MenuBundleItem{IDMenu: item.IDMenu, Qty: item.Qty})
+	}
+	return items
+}
+
+// GetBundles retrieves all bundles (paket) for the stan
+func (h *StanAdminHandler) GetBundles(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	bundles, err := h.stanAdminService.GetBundlesByStan(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get bundles", err)
+		return
+	}
+
+	SuccessResponse(c, "Bundles retrieved successfully", bundles)
+}
+
+// CreateBundle creates a bundle (paket) from the stan's menus
+func (h *StanAdminHandler) CreateBundle(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		NamaBundle string              `json:"nama_bundle" binding:"required"`
+		Harga      float64             `json:"harga" binding:"required,min=0"`
+		Foto       string              `json:"foto"`
+		Deskripsi  string              `json:"deskripsi"`
+		Items      []bundleItemRequest `json:"items" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	bundle := models.MenuBundle{
+		NamaBundle:  req.NamaBundle,
+		Harga:       req.Harga,
+		Foto:        req.Foto,
+		Deskripsi:   req.Deskripsi,
+		IsAvailable: true,
+		Items:       toBundleItems(req.Items),
+	}
+
+	// Handle base64 image if provided
+	if bundle.Foto != "" && utils.IsBase64Image(bundle.Foto) {
+		imagePath, err := utils.SaveBase64Image(bundle.Foto)
+		if err != nil {
+			BadRequestResponse(c, "Failed to process image", err)
+			return
+		}
+		bundle.Foto = imagePath
+	}
+
+	if err := h.stanAdminService.CreateBundle(userID, &bundle); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to create bundle", err)
+		}
+		return
+	}
+
+	CreatedResponse(c, "Bundle created successfully", bundle)
+}
+
+// UpdateBundle updates a bundle and optionally replaces its items
+func (h *StanAdminHandler) UpdateBundle(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	bundleID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid bundle ID", err)
+		return
+	}
+
+	var req struct {
+		NamaBundle  *string             `json:"nama_bundle"`
+		Harga       *float64            `json:"harga" binding:"omitempty,min=0"`
+		Foto        *string             `json:"foto"`
+		Deskripsi   *string             `json:"deskripsi"`
+		IsAvailable *bool               `json:"is_available"`
+		Items       []bundleItemRequest `json:"items" binding:"omitempty,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	// Build updates map with only provided fields
+	updates := make(map[string]interface{})
+	if req.NamaBundle != nil {
+		updates["nama_bundle"] = *req.NamaBundle
+	}
+	if req.Harga != nil {
+		updates["harga"] = *req.Harga
+	}
+	if req.Deskripsi != nil {
+		updates["deskripsi"] = *req.Deskripsi
+	}
+	if req.IsAvailable != nil {
+		updates["is_available"] = *req.IsAvailable
+	}
+	if req.Foto != nil {
+		if utils.IsBase64Image(*req.Foto) {
+			imagePath, err := utils.SaveBase64Image(*req.Foto)
+			if err != nil {
+				BadRequestResponse(c, "Failed to process image", err)
+				return
+			}
+			updates["foto"] = imagePath
+		} else {
+			updates["foto"] = *req.Foto
+		}
+	}
+
+	var items []models.MenuBundleItem
+	if req.Items != nil {
+		items = toBundleItems(req.Items)
+	}
+
+	if len(updates) == 0 && items == nil {
+		BadRequestResponse(c, "No valid fields to update", nil)
+		return
+	}
+
+	if err := h.stanAdminService.UpdateBundle(userID, bundleID, updates, items); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Bundle not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to update bundle", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Bundle updated successfully", nil)
+}
+
+// DeleteBundle deletes a bundle
+func (h *StanAdminHandler) DeleteBundle(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	bundleID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid bundle ID", err)
+		return
+	}
+
+	if err := h.stanAdminService.DeleteBundle(userID, bundleID); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Bundle not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to delete bundle", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Bundle deleted successfully", nil)
+}
+
+// UpdateStock sets the stock of a menu item.
+// reason is restock, waste or correction (default correction); keterangan is optional.
+// A waste reduction is recorded as a waste entry with alasan "lainnya".
+func (h *StanAdminHandler) UpdateStock(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	var req struct {
+		Stock      *int   `json:"stock" binding:"required,min=0"`
+		Reason     string `json:"reason"`
+		Keterangan string `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	reason := stockReason(req.Reason, 0)
+	if err := h.stanAdminService.UpdateStock(userID, menuID, *req.Stock, reason, req.Keterangan); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidStockChange) || errors.Is(err, services.ErrInvalidWaste) {
+			BadRequestResponse(c, "Invalid stock change", err)
+		} else {
+			InternalErrorResponse(c, "Failed to update stock", err)
+		}
+		return
+	}
+
+	menu, _ := h.menuService.FindByID(menuID, "Stan")
+	SuccessResponse(c, "Stock updated successfully", menu)
+}
+
+// AdjustStock adjusts stock by a delta value.
+// reason is restock, waste or correction (default restock for positive deltas, otherwise correction).
+// A waste reduction is recorded as a waste entry with alasan "lainnya".
+func (h *StanAdminHandler) AdjustStock(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	var req struct {
+		Delta      int    `json:"delta" binding:"required"`
+		Reason     string `json:"reason"`
+		Keterangan string `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	reason := stockReason(req.Reason, req.Delta)
+	if err := h.stanAdminService.AdjustStock(userID, menuID, req.Delta, reason, req.Keterangan); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidStockChange) || errors.Is(err, services.ErrInvalidWaste) {
+			BadRequestResponse(c, "Invalid stock change", err)
+		} else {
+			InternalErrorResponse(c, "Failed to adjust stock", err)
+		}
+		return
+	}
+
+	menu, _ := h.menuService.FindByID(menuID, "Stan")
+	SuccessResponse(c, "Stock adjusted successfully", menu)
+}
+
+// GetStockHistory retrieves the stock ledger of a menu, newest first
+func (h *StanAdminHandler) GetStockHistory(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	movements, total, err := h.stanAdminService.GetStockHistory(userID, menuID, limit, offset)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to get stock history", err)
+		}
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Stock history retrieved successfully", movements, page, limit, int(total))
+}
+
+// GetStockDrift lists menus whose stock no longer matches their stock ledger
+func (h *StanAdminHandler) GetStockDrift(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	drifts, err := h.stanAdminService.GetStockDrift(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to check stock drift", err)
+		return
+	}
+
+	SuccessResponse(c, "Stock drift retrieved successfully", drifts)
+}
+
+// RebuildStock resets a menu's stock to the value rebuilt from its stock ledger
+func (h *StanAdminHandler) RebuildStock(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	drift, err := h.stanAdminService.RebuildStock(userID, menuID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to rebuild stock", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Stock rebuilt successfully", drift)
+}
+
+// CreateStanDiscount creates a new stan-level discount
+func (h *StanAdminHandler) CreateStanDiscount(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		NamaDiskon       string  `json:"nama_diskon" binding:"required"`
+		PersentaseDiskon float64 `json:"persentase_diskon" binding:"required,min=0,max=100"`
+		TanggalAwal      string  `json:"tanggal_awal" binding:"required"`
+		TanggalAkhir     string  `json:"tanggal_akhir" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	// Parse dates
+	tanggalAwal, err := time.Parse(time.RFC3339, req.TanggalAwal)
+	if err != nil {
+		BadRequestResponse(c, "Invalid tanggal_awal format", err)
+		return
+	}
+	tanggalAkhir, err := time.Parse(time.RFC3339, req.TanggalAkhir)
+	if err != nil {
+		BadRequestResponse(c, "Invalid tanggal_akhir format", err)
+		return
+	}
+
+	diskon := models.Diskon{
+		NamaDiskon:       req.NamaDiskon,
+		PersentaseDiskon: req.PersentaseDiskon,
+		TanggalAwal:      tanggalAwal.UTC(),
+		TanggalAkhir:     tanggalAkhir.UTC(),
+	}
+
+	if err := h.stanAdminService.CreateStanDiscount(userID, &diskon); err != nil {
+		InternalErrorResponse(c, "Failed to create discount", err)
+		return
+	}
+
+	CreatedResponse(c, "Discount created successfully", diskon)
+}
+
+// CreateMenuDiscount creates a new menu-level discount
+func (h *StanAdminHandler) CreateMenuDiscount(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		NamaDiskon       string  `json:"nama_diskon" binding:"required"`
+		PersentaseDiskon float64 `json:"persentase_diskon" binding:"required,min=0,max=100"`
+		TanggalAwal      string  `json:"tanggal_awal" binding:"required"`
+		TanggalAkhir     string  `json:"tanggal_akhir" binding:"required"`
+		MenuIDs          []uint  `json:"menu_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	// Parse dates
+	tanggalAwal, err := time.Parse(time.RFC3339, req.TanggalAwal)
+	if err != nil {
+		BadRequestResponse(c, "Invalid tanggal_awal format", err)
+		return
+	}
+	tanggalAkhir, err := time.Parse(time.RFC3339, req.TanggalAkhir)
+	if err != nil {
+		BadRequestResponse(c, "Invalid tanggal_akhir format", err)
+		return
+	}
+
+	diskon := models.Diskon{
+		NamaDiskon:       req.NamaDiskon,
+		PersentaseDiskon: req.PersentaseDiskon,
+		TanggalAwal:      tanggalAwal.UTC(),
+		TanggalAkhir:     tanggalAkhir.UTC(),
+	}
+
+	if err := h.stanAdminService.CreateMenuDiscount(userID, &diskon, req.MenuIDs); err != nil {
+		InternalErrorResponse(c, "Failed to create discount", err)
+		return
+	}
+
+	CreatedResponse(c, "Discount created successfully", diskon)
+}
+
+// CreateCategoryDiscount creates a discount for all menus matching a jenis and/or tag
+func (h *StanAdminHandler) CreateCategoryDiscount(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		NamaDiskon       string  `json:"nama_diskon" binding:"required"`
+		PersentaseDiskon float64 `json:"persentase_diskon" binding:"required,min=0,max=100"`
+		TanggalAwal      string  `json:"tanggal_awal" binding:"required"`
+		TanggalAkhir     string  `json:"tanggal_akhir" binding:"required"`
+		TargetJenis      *string `json:"target_jenis" binding:"omitempty,oneof=makanan minuman"`
+		TargetTag        string  `json:"target_tag" binding:"excludesall=0x2C"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	targetTag := models.NormalizeTags(req.TargetTag)
+	if req.TargetJenis == nil && targetTag == "" {
+		BadRequestResponse(c, "target_jenis or target_tag is required", nil)
+		return
+	}
+
+	// Parse dates
+	tanggalAwal, err := time.Parse(time.RFC3339, req.TanggalAwal)
+	if err != nil {
+		BadRequestResponse(c, "Invalid tanggal_awal format", err)
+		return
+	}
+	tanggalAkhir, err := time.Parse(time.RFC3339, req.TanggalAkhir)
+	if err != nil {
+		BadRequestResponse(c, "Invalid tanggal_akhir format", err)
+		return
+	}
+
+	diskon := models.Diskon{
+		NamaDiskon:       req.NamaDiskon,
+		PersentaseDiskon: req.PersentaseDiskon,
+		TanggalAwal:      tanggalAwal.UTC(),
+		TanggalAkhir:     tanggalAkhir.UTC(),
+		TargetTag:        targetTag,
+	}
+	if req.TargetJenis != nil {
+		jenis := models.JenisMenu(*req.TargetJenis)
+		diskon.TargetJenis = &jenis
+	}
+
+	if err := h.stanAdminService.CreateCategoryDiscount(userID, &diskon); err != nil {
+		InternalErrorResponse(c, "Failed to create discount", err)
+		return
+	}
+
+	CreatedResponse(c, "Discount created successfully", diskon)
+}
+
+// UpdateDiscount updates a discount
+func (h *StanAdminHandler) UpdateDiscount(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	diskonID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid discount ID", err)
+		return
+	}
+
+	var updateData map[string]interface{}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	// Build updates map
+	updates := make(map[string]interface{})
+	if namaDiskon, ok := updateData["nama_diskon"].(string); ok {
+		updates["nama_diskon"] = namaDiskon
+	}
+	if persentase, ok := updateData["persentase_diskon"].(float64); ok {
+		updates["persentase_diskon"] = persentase
+	}
+	if tanggalAwal, ok := updateData["tanggal_awal"].(string); ok {
+		t, err := time.Parse(time.RFC3339, tanggalAwal)
+		if err != nil {
+			BadRequestResponse(c, "Invalid tanggal_awal format", err)
+			return
+		}
+		updates["tanggal_awal"] = t.UTC()
+	}
+	if tanggalAkhir, ok := updateData["tanggal_akhir"].(string); ok {
+		t, err := time.Parse(time.RFC3339, tanggalAkhir)
+		if err != nil {
+			BadRequestResponse(c, "Invalid tanggal_akhir format", err)
+			return
+		}
+		updates["tanggal_akhir"] = t.UTC()
+	}
+	if targetJenis, ok := updateData["target_jenis"].(string); ok {
+		updates["target_jenis"] = targetJenis
+	}
+	if targetTag, ok := updateData["target_tag"].(string); ok {
+		updates["target_tag"] = targetTag
+	}
+	if anggaran, ok := updateData["anggaran_subsidi"].(float64); ok {
+		updates["anggaran_subsidi"] = anggaran
+	}
+	if isActive, ok := updateData["is_active"].(bool); ok {
+		updates["is_active"] = isActive
+	}
+
+	if len(updates) == 0 {
+		BadRequestResponse(c, "No valid fields to update", nil)
+		return
+	}
+
+	if err := h.stanAdminService.UpdateDiscount(userID, diskonID, updates); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Discount not found or you don't have permission")
+		} else if errors.Is(err, services.ErrInvalidDiskonTarget) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to update discount", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Discount updated successfully", nil)
+}
+
+// DeleteDiscount deletes a discount
+func (h *StanAdminHandler) DeleteDiscount(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	diskonID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid discount ID", err)
+		return
+	}
+
+	if err := h.stanAdminService.DeleteDiscount(userID, diskonID); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Discount not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to delete discount", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Discount deleted successfully", nil)
+}
+
+// GetDiscounts retrieves all discounts for the stan
+func (h *StanAdminHandler) GetDiscounts(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	diskon, err := h.stanAdminService.GetDiscountsByStan(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get discounts", err)
+		return
+	}
+
+	SuccessResponse(c, "Discounts retrieved successfully", diskon)
+}
+
+// GetActiveDiscounts retrieves active discounts for the stan
+func (h *StanAdminHandler) GetActiveDiscounts(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	diskon, err := h.stanAdminService.GetActiveDiscountsByStan(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get active discounts", err)
+		return
+	}
+
+	SuccessResponse(c, "Active discounts retrieved successfully", diskon)
+}
+
+// GetTransactions retrieves all transactions for the stan.
+// With format csv, xlsx or pdf the list is streamed as a file instead.
+func (h *StanAdminHandler) GetTransactions(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+	if format != "" {
+		h.exportTransactions(c, userID, format, time.Time{}, time.Time{})
+		return
+	}
+
+	transaksi, err := h.stanAdminService.GetTransactionsByStan(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get transactions", err)
+		return
+	}
+
+	SuccessResponse(c, "Transactions retrieved successfully", transaksi)
+}
+
+// GetKitchenQueue retrieves open orders with their options and notes, oldest first
+func (h *StanAdminHandler) GetKitchenQueue(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	queue, err := h.stanAdminService.GetKitchenQueue(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get kitchen queue", err)
+		return
+	}
+
+	SuccessResponse(c, "Kitchen queue retrieved successfully", queue)
+}
+
+// GetTransactionsByDateRange retrieves transactions for the stan within a date range.
+// With format csv, xlsx or pdf the list is streamed as a file instead.
+func (h *StanAdminHandler) GetTransactionsByDateRange(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	if format != "" {
+		h.exportTransactions(c, userID, format, startDate, endDate)
+		return
+	}
+
+	transaksi, err := h.stanAdminService.GetTransactionsByStanAndDateRange(userID, startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get transactions", err)
+		return
+	}
+
+	SuccessResponse(c, "Transactions retrieved successfully", transaksi)
+}
+
+// exportTransactions streams the stan's transactions in the period as a file
+func (h *StanAdminHandler) exportTransactions(c *gin.Context, userID uint, format string, startDate, endDate time.Time) {
+	// The stan is looked up before the download starts so a missing stan is still a JSON error
+	if _, err := h.stanAdminService.GetStanByUserID(userID); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Stan not found")
+		} else {
+			InternalErrorResponse(c, "Failed to export transactions", err)
+		}
+		return
+	}
+	startReportDownload(c, "transaksi", format)
+	writer, err := utils.NewReportWriter(c.Writer, format, services.TransactionReportHeader(startDate, endDate))
+	if err == nil {
+		err = h.stanAdminService.ExportTransactions(userID, startDate, endDate, writer)
+	}
+	if err != nil {
+		abortReportDownload(c, err)
+	}
+}
+
+// UpdateTransactionStatus updates the status of a transaction
+func (h *StanAdminHandler) UpdateTransactionStatus(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	transaksiID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid transaction ID", err)
+		return
+	}
+
+	var req struct {
+		Status models.StatusTransaksi `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	if err := h.stanAdminService.UpdateTransactionStatus(userID, transaksiID, req.Status); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Transaction not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to update transaction status", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Transaction status updated successfully", nil)
+}
+
+// GetRevenue retrieves revenue for the stan (format query param: json, csv, xlsx or pdf)
+func (h *StanAdminHandler) GetRevenue(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+
+	totalRevenue, totalOrders, err := h.stanAdminService.GetStanRevenue(userID, startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get revenue", err)
+		return
+	}
+
+	if format != "" {
+		stan, err := h.stanAdminService.GetStanByUserID(userID)
+		if err != nil {
+			InternalErrorResponse(c, "Failed to get revenue", err)
+			return
+		}
+		revenue := services.StanRevenue{StanID: stan.ID, NamaStan: stan.NamaStan, TotalRevenue: totalRevenue, TotalOrders: totalOrders}
+		sendReport(c, "pendapatan", format, services.StanRevenueTable([]services.StanRevenue{revenue}, startDate, endDate))
+		return
+	}
+
+	response := gin.H{
+		"total_revenue": totalRevenue,
+		"total_orders":  totalOrders,
 		"start_date":   startDate,
 		"end_date":     endDate,
 	}
 
-	SuccessResponse(c, "Revenue retrieved successfully", response)
+	SuccessResponse(c, "Revenue retrieved successfully", response)
+}
+
+
+
+// GetLowStock retrieves the stan's menus at or below their minimum stock, and those sold out
+func (h *StanAdminHandler) GetLowStock(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	lowStock, soldOut, err := h.stanAdminService.GetLowStock(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get low stock menus", err)
+		return
+	}
+
+	SuccessResponse(c, "Low stock menus retrieved successfully", gin.H{
+		"low_stock": lowStock,
+		"sold_out":  soldOut,
+	})
+}
+
+// GetNotifications retrieves the stan admin's notifications. Use unread=true for unread only.
+func (h *StanAdminHandler) GetNotifications(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	unreadOnly := c.Query("unread") == "true"
+	notifications, total, err := h.stanAdminService.GetNotifications(userID, unreadOnly, limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get notifications", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Notifications retrieved successfully", notifications, page, limit, int(total))
+}
+
+// MarkNotificationRead marks one of the stan admin's notifications as read
+func (h *StanAdminHandler) MarkNotificationRead(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	id, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid notification ID", err)
+		return
+	}
+
+	if err := h.stanAdminService.MarkNotificationRead(userID, id); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Notification not found or you don't have permission")
+			return
+		}
+		InternalErrorResponse(c, "Failed to mark notification as read", err)
+		return
+	}
+
+	SuccessResponse(c, "Notification marked as read", nil)
+}
+
+// MarkAllNotificationsRead marks all of the stan admin's notifications as read
+func (h *StanAdminHandler) MarkAllNotificationsRead(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.stanAdminService.MarkAllNotificationsRead(userID); err != nil {
+		InternalErrorResponse(c, "Failed to mark notifications as read", err)
+		return
+	}
+
+	SuccessResponse(c, "All notifications marked as read", nil)
+}
+
+// GetStockResets retrieves the stan's daily stock resets and skipped days
+func (h *StanAdminHandler) GetStockResets(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	resets, total, err := h.stanAdminService.GetStockResets(userID, limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get stock resets", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Stock resets retrieved successfully", resets, page, limit, int(total))
+}
+
+// TriggerStockReset resets the stan's menus to their par levels for today
+func (h *StanAdminHandler) TriggerStockReset(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	reset, err := h.stanAdminService.TriggerStockReset(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStockReset) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to reset stock", err)
+		return
+	}
+
+	SuccessResponse(c, "Stock reset to par levels successfully", reset)
+}
+
+// SkipStockReset skips the automatic stock reset on a day (query tanggal as YYYY-MM-DD, today when empty)
+func (h *StanAdminHandler) SkipStockReset(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	reset, err := h.stanAdminService.SkipStockReset(userID, c.Query("tanggal"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStockReset) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to skip stock reset", err)
+		return
+	}
+
+	SuccessResponse(c, "Stock reset skipped successfully", reset)
+}
+
+// CancelStockResetSkip re-enables the automatic stock reset on a skipped day (query tanggal, today when empty)
+func (h *StanAdminHandler) CancelStockResetSkip(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.stanAdminService.CancelStockResetSkip(userID, c.Query("tanggal")); err != nil {
+		if errors.Is(err, services.ErrInvalidStockReset) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to cancel stock reset skip", err)
+		return
+	}
+
+	SuccessResponse(c, "Stock reset skip cancelled successfully", nil)
+}
+
+// GetIngredients retrieves the stan's ingredients
+func (h *StanAdminHandler) GetIngredients(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	ingredients, err := h.stanAdminService.GetIngredients(userID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get ingredients", err)
+		return
+	}
+
+	SuccessResponse(c, "Ingredients retrieved successfully", ingredients)
+}
+
+// CreateIngredient adds an ingredient; its stock starts at zero and grows through purchases
+func (h *StanAdminHandler) CreateIngredient(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		Nama   string `json:"nama" binding:"required"`
+		Satuan string `json:"satuan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	ingredient := models.Ingredient{Nama: req.Nama, Satuan: req.Satuan}
+	if err := h.stanAdminService.CreateIngredient(userID, &ingredient); err != nil {
+		if errors.Is(err, services.ErrInvalidIngredient) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to create ingredient", err)
+		return
+	}
+
+	CreatedResponse(c, "Ingredient created successfully", ingredient)
+}
+
+// UpdateIngredient renames an ingredient or changes its unit
+func (h *StanAdminHandler) UpdateIngredient(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	ingredientID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid ingredient ID", err)
+		return
+	}
+
+	var req struct {
+		Nama   string `json:"nama" binding:"required"`
+		Satuan string `json:"satuan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	ingredient, err := h.stanAdminService.UpdateIngredient(userID, ingredientID, req.Nama, req.Satuan)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Ingredient not found or you don't have permission")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidIngredient) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to update ingredient", err)
+		return
+	}
+
+	SuccessResponse(c, "Ingredient updated successfully", ingredient)
+}
+
+// DeleteIngredient deletes an ingredient that no recipe uses
+func (h *StanAdminHandler) DeleteIngredient(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	ingredientID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid ingredient ID", err)
+		return
+	}
+
+	if err := h.stanAdminService.DeleteIngredient(userID, ingredientID); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Ingredient not found or you don't have permission")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidIngredient) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to delete ingredient", err)
+		return
+	}
+
+	SuccessResponse(c, "Ingredient deleted successfully", nil)
+}
+
+// PurchaseIngredient records a purchase (restock) of an ingredient
+func (h *StanAdminHandler) PurchaseIngredient(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	ingredientID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid ingredient ID", err)
+		return
+	}
+
+	var req struct {
+		Qty        float64 `json:"qty" binding:"required,gt=0"`
+		HargaTotal float64 `json:"harga_total" binding:"min=0"`
+		Supplier   string  `json:"supplier" binding:"max=100"`
+		Keterangan string  `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	purchase := models.IngredientPurchase{
+		IDIngredient: ingredientID,
+		Qty:          req.Qty,
+		HargaTotal:   req.HargaTotal,
+		Supplier:     req.Supplier,
+		Keterangan:   req.Keterangan,
+	}
+	if err := h.stanAdminService.PurchaseIngredient(userID, &purchase); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Ingredient not found or you don't have permission")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidIngredient) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to record ingredient purchase", err)
+		return
+	}
+
+	CreatedResponse(c, "Ingredient purchase recorded successfully", purchase)
+}
+
+// GetIngredientPurchases retrieves the stan's ingredient purchases (query id_ingredient to filter)
+func (h *StanAdminHandler) GetIngredientPurchases(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	ingredientID, err := GetQueryParamUint(c, "id_ingredient")
+	if err != nil {
+		BadRequestResponse(c, "Invalid id_ingredient", err)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	purchases, total, err := h.stanAdminService.GetIngredientPurchases(userID, ingredientID, limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get ingredient purchases", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Ingredient purchases retrieved successfully", purchases, page, limit, int(total))
+}
+
+// GetMenuRecipe retrieves the recipe of a menu
+func (h *StanAdminHandler) GetMenuRecipe(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	recipe, err := h.stanAdminService.GetMenuRecipe(userID, menuID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to get menu recipe", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Menu recipe retrieved successfully", recipe)
+}
+
+// SetMenuRecipe replaces the recipe of a menu. With a recipe the menu's stock follows
+// its ingredient stock; an empty list returns stock to manual management.
+func (h *StanAdminHandler) SetMenuRecipe(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	var req struct {
+		Items []struct {
+			IDIngredient uint    `json:"id_ingredient" binding:"required"`
+			Qty          float64 `json:"qty" binding:"required,gt=0"`
+		} `json:"items" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	items := make([]models.RecipeItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, models.RecipeItem{IDIngredient: item.IDIngredient, Qty: item.Qty})
+	}
+
+	recipe, err := h.stanAdminService.SetMenuRecipe(userID, menuID, items)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found or you don't have permission")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidIngredient) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to update menu recipe", err)
+		return
+	}
+
+	SuccessResponse(c, "Menu recipe updated successfully", recipe)
+}
+
+// SubmitStockOpname records a physical count of every menu and ingredient of the stan
+// and returns the variance against system stock
+func (h *StanAdminHandler) SubmitStockOpname(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		Counts     []services.OpnameCount `json:"counts" binding:"required"`
+		Keterangan string                 `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	opname, err := h.stanAdminService.SubmitStockOpname(userID, req.Counts, req.Keterangan)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStockOpname) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to submit stock opname", err)
+		return
+	}
+
+	CreatedResponse(c, "Stock opname submitted successfully", opname)
+}
+
+// GetStockOpnames retrieves the stan's stock opnames (query status to filter)
+func (h *StanAdminHandler) GetStockOpnames(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	opnames, total, err := h.stanAdminService.GetStockOpnames(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get stock opnames", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Stock opnames retrieved successfully", opnames, page, limit, int(total))
+}
+
+// GetStockOpname retrieves a stock opname with its variance report
+func (h *StanAdminHandler) GetStockOpname(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	opnameID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid opname ID", err)
+		return
+	}
+
+	opname, err := h.stanAdminService.GetStockOpname(userID, opnameID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Stock opname not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to get stock opname", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Stock opname retrieved successfully", opname)
+}
+
+// ApproveStockOpname applies the variances of a pending stock opname as stock corrections
+func (h *StanAdminHandler) ApproveStockOpname(c *gin.Context) {
+	h.reviewStockOpname(c, true)
+}
+
+// RejectStockOpname closes a pending stock opname without changing stock
+func (h *StanAdminHandler) RejectStockOpname(c *gin.Context) {
+	h.reviewStockOpname(c, false)
+}
+
+// reviewStockOpname approves or rejects a pending stock opname of the stan
+func (h *StanAdminHandler) reviewStockOpname(c *gin.Context, approve bool) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	opnameID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid opname ID", err)
+		return
+	}
+
+	var opname *models.StockOpname
+	if approve {
+		opname, err = h.stanAdminService.ApproveStockOpname(userID, opnameID)
+	} else {
+		opname, err = h.stanAdminService.RejectStockOpname(userID, opnameID)
+	}
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Stock opname not found or you don't have permission")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidStockOpname) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to review stock opname", err)
+		return
+	}
+
+	if approve {
+		SuccessResponse(c, "Stock opname approved successfully", opname)
+		return
+	}
+	SuccessResponse(c, "Stock opname rejected successfully", opname)
+}
+
+// RecordWaste records food or ingredients thrown away with a reason code
+func (h *StanAdminHandler) RecordWaste(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		IDMenu       *uint              `json:"id_menu"`
+		IDIngredient *uint              `json:"id_ingredient"`
+		Qty          float64            `json:"qty" binding:"required,gt=0"`
+		Alasan       models.WasteReason `json:"alasan" binding:"required"`
+		Keterangan   string             `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	entry, err := h.stanAdminService.RecordWaste(userID, &models.WasteEntry{
+		IDMenu:       req.IDMenu,
+		IDIngredient: req.IDIngredient,
+		Qty:          req.Qty,
+		Alasan:       req.Alasan,
+		Keterangan:   req.Keterangan,
+	})
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu or ingredient not found or you don't have permission")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidWaste) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		InternalErrorResponse(c, "Failed to record waste", err)
+		return
+	}
+
+	CreatedResponse(c, "Waste recorded successfully", entry)
+}
+
+// GetWasteEntries retrieves the stan's waste entries (optional start_date and end_date)
+func (h *StanAdminHandler) GetWasteEntries(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	page, limit, offset := ParsePaginationParams(c)
+	entries, total, err := h.stanAdminService.GetWasteEntries(userID, startDate, endDate, limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get waste entries", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Waste entries retrieved successfully", entries, page, limit, int(total))
+}
+
+// GetWasteReport retrieves the stan's waste per reason, menu and ingredient alongside revenue
+// (format query param: json, csv, xlsx or pdf)
+func (h *StanAdminHandler) GetWasteReport(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	report, err := h.stanAdminService.GetWasteReport(userID, startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get waste report", err)
+		return
+	}
+
+	if format != "" {
+		sendReport(c, "laporan_waste", format, report.Table())
+		return
+	}
+	SuccessResponse(c, "Waste report retrieved successfully", report)
+}
+
+// GetSalesTimeSeries retrieves the stan's revenue, orders, average order value and items per
+// bucket (query bucket: hour, day, week or month) with the previous period for comparison
+// (format query param: json, csv, xlsx or pdf)
+func (h *StanAdminHandler) GetSalesTimeSeries(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	bucket, err := services.ParseBucket(c.Query("bucket"))
+	if err != nil {
+		BadRequestResponse(c, err.Error(), nil)
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	series, err := h.stanAdminService.GetSalesTimeSeries(userID, bucket, startDate, endDate)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPeriod) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to get sales time series", err)
+		}
+		return
+	}
+
+	if format != "" {
+		sendReport(c, "penjualan", format, series.Table())
+		return
+	}
+	SuccessResponse(c, "Sales time series retrieved successfully", series)
+}
+
+// GetMenuPerformance retrieves the stan's menus ranked by sales (query sort: qty, revenue or
+// buyers) with their discount share, and the menus that did not sell in the period
+// (format query param: json, csv, xlsx or pdf)
+func (h *StanAdminHandler) GetMenuPerformance(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	sortBy, err := services.ParseMenuSort(c.Query("sort"))
+	if err != nil {
+		BadRequestResponse(c, err.Error(), nil)
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	report, err := h.stanAdminService.GetMenuPerformance(userID, sortBy, startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get menu performance", err)
+		return
+	}
+
+	if format != "" {
+		sendReport(c, "performa_menu", format, report.Table())
+		return
+	}
+	SuccessResponse(c, "Menu performance retrieved successfully", report)
 }
 
+// GetPeakHours retrieves the stan's orders and average waiting and preparation time per weekday and hour
+// (format query param: json, csv, xlsx or pdf)
+func (h *StanAdminHandler) GetPeakHours(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	heatmap, err := h.stanAdminService.GetPeakHours(userID, startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get peak hours", err)
+		return
+	}
 
+	if format != "" {
+		sendReport(c, "jam_sibuk", format, heatmap.Table())
+		return
+	}
+	SuccessResponse(c, "Peak hours retrieved successfully", heatmap)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"swipeup-be/internal/models"
 	"swipeup-be/internal/services"
 
@@ -63,6 +64,17 @@ func (h *StudentHandler) UpdateSiswaProfile(c *gin.Context) {
 	if telp, ok := updateData["telp"].(string); ok {
 		updates["telp"] = telp
 	}
+	if alergi, ok := updateData["alergi"].(string); ok {
+		normalized, err := models.NormalizeAlergen(alergi)
+		if err != nil {
+			BadRequestResponse(c, "Invalid alergi", err)
+			return
+		}
+		updates["alergi"] = normalized
+	}
+	if blokirAlergen, ok := updateData["blokir_alergen"].(bool); ok {
+		updates["blokir_alergen"] = blokirAlergen
+	}
 
 	if len(updates) == 0 {
 		BadRequestResponse(c, "No valid fields to update", nil)
@@ -101,7 +113,19 @@ func (h *StudentHandler) AddToCart(c *gin.Context) {
 	}
 
 	if err := h.studentService.AddToCart(siswa.ID, &cart); err != nil {
-		InternalErrorResponse(c, "Failed to add item to cart", err)
+		if errors.Is(err, services.ErrInvalidOption) {
+			BadRequestResponse(c, "Invalid menu options", err)
+		} else if errors.Is(err, services.ErrInvalidNote) {
+			BadRequestResponse(c, "Invalid note", err)
+		} else if errors.Is(err, services.ErrInvalidCartItem) {
+			BadRequestResponse(c, "Invalid cart item", err)
+		} else if errors.Is(err, services.ErrMenuUnavailable) {
+			BadRequestResponse(c, "Bundle is not available", err)
+		} else if err.Error() == "record not found" {
+			NotFoundResponse(c, "Bundle not found")
+		} else {
+			InternalErrorResponse(c, "Failed to add item to cart", err)
+		}
 		return
 	}
 
@@ -129,10 +153,17 @@ func (h *StudentHandler) GetCart(c *gin.Context) {
 		return
 	}
 
+	warnings, err := h.studentService.CheckCartAlergen(siswa.ID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get cart", err)
+		return
+	}
+
 	response := gin.H{
-		"items":       carts,
-		"total_items": totalItems,
-		"total_price": totalPrice,
+		"items":            carts,
+		"total_items":      totalItems,
+		"total_price":      totalPrice,
+		"alergen_warnings": warnings,
 	}
 
 	SuccessResponse(c, "Cart retrieved successfully", response)
@@ -147,15 +178,22 @@ func (h *StudentHandler) UpdateCartItem(c *gin.Context) {
 	}
 
 	var req struct {
-		Qty int `json:"qty" binding:"required,min=1"`
+		Qty     int     `json:"qty" binding:"required,min=1"`
+		Catatan *string `json:"catatan"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
 
-	if err := h.studentService.UpdateCartItem(cartID, req.Qty); err != nil {
-		InternalErrorResponse(c, "Failed to update cart item", err)
+	if err := h.studentService.UpdateCartItem(cartID, req.Qty, req.Catatan); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Cart item not found")
+		} else if errors.Is(err, services.ErrInvalidNote) {
+			BadRequestResponse(c, "Invalid note", err)
+		} else {
+			InternalErrorResponse(c, "Failed to update cart item", err)
+		}
 		return
 	}
 
@@ -224,10 +262,18 @@ func (h *StudentHandler) CheckoutCart(c *gin.Context) {
 		return
 	}
 
-	transaksi, details, err := h.studentService.CheckoutCart(siswa.ID, req.StanID)
+	transaksi, details, warnings, err := h.studentService.CheckoutCart(siswa.ID, req.StanID)
 	if err != nil {
 		if err.Error() == "record not found" {
 			BadRequestResponse(c, "Cart is empty", nil)
+		} else if errors.Is(err, services.ErrAlergenConflict) {
+			BadRequestResponse(c, "Checkout blocked by allergy settings", err)
+		} else if errors.Is(err, services.ErrInsufficientStock) {
+			BadRequestResponse(c, "Insufficient stock", err)
+		} else if errors.Is(err, services.ErrMenuUnavailable) {
+			BadRequestResponse(c, "Menu is not available", err)
+		} else if errors.Is(err, services.ErrDiskonBudgetExhausted) {
+			BadRequestResponse(c, "Discount budget has been used up, please checkout again", err)
 		} else {
 			InternalErrorResponse(c, "Failed to checkout cart", err)
 		}
@@ -235,9 +281,10 @@ func (h *StudentHandler) CheckoutCart(c *gin.Context) {
 	}
 
 	response := gin.H{
-		"transaksi": transaksi,
-		"details":   details,
-		"message":   "Checkout successful",
+		"transaksi":        transaksi,
+		"details":          details,
+		"alergen_warnings": warnings,
+		"message":          "Checkout successful",
 	}
 
 	CreatedResponse(c, "Checkout successful", response)
@@ -300,3 +347,230 @@ func (h *StudentHandler) GetTransactionByID(c *gin.Context) {
 
 	SuccessResponse(c, "Transaction retrieved successfully", transaksi)
 }
+
+// GetFavorites retrieves the student's favorite menus
+func (h *StudentHandler) GetFavorites(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	// Get siswa ID from user
+	siswa, err := h.studentService.GetSiswaByUserID(userID)
+	if err != nil {
+		NotFoundResponse(c, "Siswa profile not found")
+		return
+	}
+
+	favorites, err := h.studentService.GetFavorites(siswa.ID)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get favorites", err)
+		return
+	}
+
+	SuccessResponse(c, "Favorites retrieved successfully", favorites)
+}
+
+// AddFavorite adds a menu (menu ID in the path) to the student's favorites
+func (h *StudentHandler) AddFavorite(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	// Get siswa ID from user
+	siswa, err := h.studentService.GetSiswaByUserID(userID)
+	if err != nil {
+		NotFoundResponse(c, "Siswa profile not found")
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	favorite, err := h.studentService.AddFavorite(siswa.ID, menuID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Menu not found")
+		} else {
+			InternalErrorResponse(c, "Failed to add favorite", err)
+		}
+		return
+	}
+
+	CreatedResponse(c, "Favorite added successfully", favorite)
+}
+
+// RemoveFavorite removes a menu (menu ID in the path) from the student's favorites
+func (h *StudentHandler) RemoveFavorite(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	// Get siswa ID from user
+	siswa, err := h.studentService.GetSiswaByUserID(userID)
+	if err != nil {
+		NotFoundResponse(c, "Siswa profile not found")
+		return
+	}
+
+	menuID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid menu ID", err)
+		return
+	}
+
+	if err := h.studentService.RemoveFavorite(siswa.ID, menuID); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Favorite not found")
+		} else {
+			InternalErrorResponse(c, "Failed to remove favorite", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Favorite removed successfully", nil)
+}
+
+// Reorder adds the items of a past transaction back to the cart at current prices
+// and reports the items that could not be added
+func (h *StudentHandler) Reorder(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	// Get siswa ID from user
+	siswa, err := h.studentService.GetSiswaByUserID(userID)
+	if err != nil {
+		NotFoundResponse(c, "Siswa profile not found")
+		return
+	}
+
+	transaksiID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid transaction ID", err)
+		return
+	}
+
+	result, err := h.studentService.Reorder(siswa.ID, transaksiID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Transaction not found or you don't have permission")
+		} else {
+			InternalErrorResponse(c, "Failed to reorder", err)
+		}
+		return
+	}
+
+	if len(result.Added) == 0 {
+		SuccessResponse(c, "None of the items can be ordered right now", result)
+		return
+	}
+	SuccessResponse(c, "Items added to cart successfully", result)
+}
+
+// CreateReview reviews a transaction that has arrived; item ratings are optional
+func (h *StudentHandler) CreateReview(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	// Get siswa ID from user
+	siswa, err := h.studentService.GetSiswaByUserID(userID)
+	if err != nil {
+		NotFoundResponse(c, "Siswa profile not found")
+		return
+	}
+
+	transaksiID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid transaction ID", err)
+		return
+	}
+
+	var req struct {
+		Rating   int    `json:"rating" binding:"required"`
+		Komentar string `json:"komentar"`
+		Items    []struct {
+			IDDetailTransaksi uint   `json:"id_detail_transaksi" binding:"required"`
+			Rating            int    `json:"rating" binding:"required"`
+			Komentar          string `json:"komentar"`
+		} `json:"items"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	review := models.Review{
+		Rating:   req.Rating,
+		Komentar: req.Komentar,
+	}
+	for _, item := range req.Items {
+		review.Items = append(review.Items, models.ReviewItem{
+			IDDetailTransaksi: item.IDDetailTransaksi,
+			Rating:            item.Rating,
+			Komentar:          item.Komentar,
+		})
+	}
+
+	if err := h.studentService.CreateReview(siswa.ID, transaksiID, &review); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Transaction not found or you don't have permission")
+		} else if errors.Is(err, services.ErrReviewNotAllowed) {
+			BadRequestResponse(c, "Transaction cannot be reviewed", err)
+		} else if errors.Is(err, services.ErrInvalidReview) {
+			BadRequestResponse(c, "Invalid review", err)
+		} else {
+			InternalErrorResponse(c, "Failed to create review", err)
+		}
+		return
+	}
+
+	CreatedResponse(c, "Review created successfully", review)
+}
+
+// GetReview retrieves the student's review of a transaction
+func (h *StudentHandler) GetReview(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		BadRequestResponse(c, "User not authenticated", nil)
+		return
+	}
+
+	// Get siswa ID from user
+	siswa, err := h.studentService.GetSiswaByUserID(userID)
+	if err != nil {
+		NotFoundResponse(c, "Siswa profile not found")
+		return
+	}
+
+	transaksiID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid transaction ID", err)
+		return
+	}
+
+	review, err := h.studentService.GetReview(siswa.ID, transaksiID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Review not found")
+		} else {
+			InternalErrorResponse(c, "Failed to get review", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Review retrieved successfully", review)
+}
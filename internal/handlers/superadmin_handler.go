@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"swipeup-be/internal/models"
 	"swipeup-be/internal/services"
+	"swipeup-be/pkg/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,13 +28,17 @@ func NewSuperadminHandler(
 	}
 }
 
-// GetRevenueByStanID retrieves revenue for a specific stan
+// GetRevenueByStanID retrieves revenue for a specific stan (format query param: json, csv, xlsx or pdf)
 func (h *SuperadminHandler) GetRevenueByStanID(c *gin.Context) {
 	stanID, err := GetIDParam(c)
 	if err != nil {
 		BadRequestResponse(c, "Invalid stan ID", err)
 		return
 	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
 
 	// Parse optional date range
 	startDate, endDate := parseDateRange(c)
@@ -42,11 +49,20 @@ func (h *SuperadminHandler) GetRevenueByStanID(c *gin.Context) {
 		return
 	}
 
+	if format != "" {
+		sendReport(c, "pendapatan_stan", format, services.StanRevenueTable([]services.StanRevenue{*revenue}, startDate, endDate))
+		return
+	}
 	SuccessResponse(c, "Revenue retrieved successfully", revenue)
 }
 
-// GetAllStanRevenue retrieves revenue for all stans
+// GetAllStanRevenue retrieves revenue for all stans (format query param: json, csv, xlsx or pdf)
 func (h *SuperadminHandler) GetAllStanRevenue(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
 	// Parse optional date range
 	startDate, endDate := parseDateRange(c)
 
@@ -56,11 +72,20 @@ func (h *SuperadminHandler) GetAllStanRevenue(c *gin.Context) {
 		return
 	}
 
+	if format != "" {
+		sendReport(c, "pendapatan_per_stan", format, services.StanRevenueTable(revenues, startDate, endDate))
+		return
+	}
 	SuccessResponse(c, "Revenues retrieved successfully", revenues)
 }
 
-// GetRevenueReport retrieves a comprehensive revenue report
+// GetRevenueReport retrieves a comprehensive revenue report (format query param: json, csv, xlsx or pdf)
 func (h *SuperadminHandler) GetRevenueReport(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
 	// Parse optional date range
 	startDate, endDate := parseDateRange(c)
 
@@ -70,9 +95,113 @@ func (h *SuperadminHandler) GetRevenueReport(c *gin.Context) {
 		return
 	}
 
+	if format != "" {
+		sendReport(c, "laporan_pendapatan", format, report.Table())
+		return
+	}
 	SuccessResponse(c, "Revenue report retrieved successfully", report)
 }
 
+// GetDiskonUsageReport retrieves redemptions, subsidy and the order change per discount and per stan
+// (format query param: json, csv, xlsx or pdf)
+func (h *SuperadminHandler) GetDiskonUsageReport(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	// Parse optional date range
+	startDate, endDate := parseDateRange(c)
+
+	report, err := h.superadminService.GetDiskonUsageReport(startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get discount usage report", err)
+		return
+	}
+
+	if format != "" {
+		sendReport(c, "pemakaian_diskon", format, services.DiskonUsageTable(report, startDate, endDate))
+		return
+	}
+	SuccessResponse(c, "Discount usage report retrieved successfully", report)
+}
+
+// SimulateDiskon estimates the subsidy of a proposed discount by replaying historical transactions
+func (h *SuperadminHandler) SimulateDiskon(c *gin.Context) {
+	var req struct {
+		NamaDiskon       string  `json:"nama_diskon"`
+		PersentaseDiskon float64 `json:"persentase_diskon" binding:"required,min=0,max=100"`
+		TipeDiskon       string  `json:"tipe_diskon" binding:"required,oneof=global stan menu kategori"`
+		IDStan           *uint   `json:"id_stan"`
+		MenuIDs          []uint  `json:"menu_ids"`
+		TargetJenis      *string `json:"target_jenis" binding:"omitempty,oneof=makanan minuman"`
+		TargetTag        string  `json:"target_tag" binding:"excludesall=0x2C"`
+		StartDate        string  `json:"start_date" binding:"required"`
+		EndDate          string  `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	// Parse dates
+	startDate, err := time.Parse(time.RFC3339, req.StartDate)
+	if err != nil {
+		BadRequestResponse(c, "Invalid start_date format", err)
+		return
+	}
+	endDate, err := time.Parse(time.RFC3339, req.EndDate)
+	if err != nil {
+		BadRequestResponse(c, "Invalid end_date format", err)
+		return
+	}
+	if endDate.Before(startDate) {
+		BadRequestResponse(c, "end_date must be after start_date", nil)
+		return
+	}
+
+	if req.TipeDiskon == "stan" && req.IDStan == nil {
+		BadRequestResponse(c, "id_stan is required for stan discount", nil)
+		return
+	}
+	if req.TipeDiskon == "menu" && len(req.MenuIDs) == 0 {
+		BadRequestResponse(c, "menu_ids is required for menu discount", nil)
+		return
+	}
+	targetTag := models.NormalizeTags(req.TargetTag)
+	if req.TipeDiskon == "kategori" && req.TargetJenis == nil && targetTag == "" {
+		BadRequestResponse(c, "target_jenis or target_tag is required for kategori discount", nil)
+		return
+	}
+
+	proposed := models.Diskon{
+		NamaDiskon:       req.NamaDiskon,
+		PersentaseDiskon: req.PersentaseDiskon,
+		TipeDiskon:       models.TipeDiskon(req.TipeDiskon),
+		IDStan:           req.IDStan,
+		TargetTag:        targetTag,
+	}
+	if req.TargetJenis != nil {
+		jenis := models.JenisMenu(*req.TargetJenis)
+		proposed.TargetJenis = &jenis
+	}
+	for _, menuID := range req.MenuIDs {
+		proposed.MenuDiskon = append(proposed.MenuDiskon, models.MenuDiskon{IDMenu: menuID})
+	}
+
+	simulation, err := h.superadminService.SimulateDiskon(proposed, startDate.UTC(), endDate.UTC())
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Stan not found")
+		} else {
+			InternalErrorResponse(c, "Failed to simulate discount", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Discount simulation completed successfully", simulation)
+}
+
 // GetGlobalDiscounts retrieves all global discounts
 func (h *SuperadminHandler) GetGlobalDiscounts(c *gin.Context) {
 	diskon, err := h.superadminService.GetGlobalDiscounts()
@@ -125,8 +254,8 @@ func (h *SuperadminHandler) CreateGlobalDiscount(c *gin.Context) {
 	}{
 		NamaDiskon:       diskonReq.NamaDiskon,
 		PersentaseDiskon: diskonReq.PersentaseDiskon,
-		TanggalAwal:      tanggalAwal,
-		TanggalAkhir:     tanggalAkhir,
+		TanggalAwal:      tanggalAwal.UTC(),
+		TanggalAkhir:     tanggalAkhir.UTC(),
 	}
 
 	// For now, return a success response
@@ -172,13 +301,17 @@ func (h *SuperadminHandler) DeleteGlobalDiscount(c *gin.Context) {
 	SuccessResponse(c, "Global discount deleted successfully", nil)
 }
 
-// GetStanStatistics retrieves statistics for a specific stan
+// GetStanStatistics retrieves statistics for a specific stan (format query param: json, csv, xlsx or pdf)
 func (h *SuperadminHandler) GetStanStatistics(c *gin.Context) {
 	stanID, err := GetIDParam(c)
 	if err != nil {
 		BadRequestResponse(c, "Invalid stan ID", err)
 		return
 	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
 
 	// Parse optional date range
 	startDate, endDate := parseDateRange(c)
@@ -189,11 +322,20 @@ func (h *SuperadminHandler) GetStanStatistics(c *gin.Context) {
 		return
 	}
 
+	if format != "" {
+		sendReport(c, "statistik_stan", format, services.StanStatisticsTable([]services.StanStatistics{*statistics}, startDate, endDate))
+		return
+	}
 	SuccessResponse(c, "Stan statistics retrieved successfully", statistics)
 }
 
-// GetAllStanStatistics retrieves statistics for all stans
+// GetAllStanStatistics retrieves statistics for all stans (format query param: json, csv, xlsx or pdf)
 func (h *SuperadminHandler) GetAllStanStatistics(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
 	// Parse optional date range
 	startDate, endDate := parseDateRange(c)
 
@@ -203,7 +345,211 @@ func (h *SuperadminHandler) GetAllStanStatistics(c *gin.Context) {
 		return
 	}
 
+	if format != "" {
+		sendReport(c, "statistik_stan", format, services.StanStatisticsTable(statistics, startDate, endDate))
+		return
+	}
 	SuccessResponse(c, "Stan statistics retrieved successfully", statistics)
 }
 
+// SetReviewHidden hides or unhides an abusive review
+func (h *SuperadminHandler) SetReviewHidden(c *gin.Context) {
+	reviewID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid review ID", err)
+		return
+	}
+
+	var req struct {
+		Hidden bool   `json:"hidden"`
+		Alasan string `json:"alasan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	if err := h.superadminService.SetReviewHidden(reviewID, req.Hidden, req.Alasan); err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Review not found")
+		} else if errors.Is(err, services.ErrInvalidReview) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to update review", err)
+		}
+		return
+	}
+
+	if req.Hidden {
+		SuccessResponse(c, "Review hidden successfully", nil)
+		return
+	}
+	SuccessResponse(c, "Review shown successfully", nil)
+}
+
+// GetStockOpnames retrieves stock opnames of all stans for audit (query stan_id and status to filter)
+func (h *SuperadminHandler) GetStockOpnames(c *gin.Context) {
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan_id", err)
+		return
+	}
+
+	page, limit, offset := ParsePaginationParams(c)
+	opnames, total, err := h.superadminService.GetStockOpnames(stanID, c.Query("status"), limit, offset)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get stock opnames", err)
+		return
+	}
+
+	PaginatedSuccessResponse(c, "Stock opnames retrieved successfully", opnames, page, limit, int(total))
+}
+
+// GetStockOpname retrieves a stock opname with its variance report
+func (h *SuperadminHandler) GetStockOpname(c *gin.Context) {
+	opnameID, err := GetIDParam(c)
+	if err != nil {
+		BadRequestResponse(c, "Invalid opname ID", err)
+		return
+	}
 
+	opname, err := h.superadminService.GetStockOpname(opnameID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			NotFoundResponse(c, "Stock opname not found")
+		} else {
+			InternalErrorResponse(c, "Failed to get stock opname", err)
+		}
+		return
+	}
+
+	SuccessResponse(c, "Stock opname retrieved successfully", opname)
+}
+
+// GetSalesTimeSeries retrieves revenue, orders, average order value and items per bucket
+// (query bucket: hour, day, week or month) with the previous period for comparison.
+// Query stan_id limits it to one stan; format query param: json, csv, xlsx or pdf.
+func (h *SuperadminHandler) GetSalesTimeSeries(c *gin.Context) {
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan_id", err)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	bucket, err := services.ParseBucket(c.Query("bucket"))
+	if err != nil {
+		BadRequestResponse(c, err.Error(), nil)
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	series, err := h.superadminService.GetSalesTimeSeries(stanID, bucket, startDate, endDate)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPeriod) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to get sales time series", err)
+		}
+		return
+	}
+
+	if format != "" {
+		sendReport(c, "penjualan", format, series.Table())
+		return
+	}
+	SuccessResponse(c, "Sales time series retrieved successfully", series)
+}
+
+// GetMenuPerformance retrieves menus ranked by sales (query sort: qty, revenue or buyers) with
+// their discount share, and the menus that did not sell. Query stan_id limits it to one stan;
+// format query param: json, csv, xlsx or pdf.
+func (h *SuperadminHandler) GetMenuPerformance(c *gin.Context) {
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan_id", err)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	sortBy, err := services.ParseMenuSort(c.Query("sort"))
+	if err != nil {
+		BadRequestResponse(c, err.Error(), nil)
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	report, err := h.superadminService.GetMenuPerformance(stanID, sortBy, startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get menu performance", err)
+		return
+	}
+
+	if format != "" {
+		sendReport(c, "performa_menu", format, report.Table())
+		return
+	}
+	SuccessResponse(c, "Menu performance retrieved successfully", report)
+}
+
+// GetPeakHours retrieves orders and average waiting and preparation time per weekday and hour
+// for planning break schedules. Query stan_id limits it to one stan; format query param:
+// json, csv, xlsx or pdf.
+func (h *SuperadminHandler) GetPeakHours(c *gin.Context) {
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan_id", err)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	startDate, endDate := parseDateRange(c)
+	heatmap, err := h.superadminService.GetPeakHours(stanID, startDate, endDate)
+	if err != nil {
+		InternalErrorResponse(c, "Failed to get peak hours", err)
+		return
+	}
+
+	if format != "" {
+		sendReport(c, "jam_sibuk", format, heatmap.Table())
+		return
+	}
+	SuccessResponse(c, "Peak hours retrieved successfully", heatmap)
+}
+
+// ExportTransactions streams the transactions in the period as a file (format query param:
+// csv, xlsx or pdf, default csv). Query stan_id limits it to one stan. XLSX and PDF files
+// are built in memory before they are sent, so long periods are best exported as CSV.
+func (h *SuperadminHandler) ExportTransactions(c *gin.Context) {
+	stanID, err := GetQueryParamUint(c, "stan_id")
+	if err != nil {
+		BadRequestResponse(c, "Invalid stan_id", err)
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+	if format == "" {
+		format = utils.FormatCSV
+	}
+
+	startDate, endDate := parseDateRange(c)
+	startReportDownload(c, "transaksi", format)
+	writer, err := utils.NewReportWriter(c.Writer, format, services.TransactionReportHeader(startDate, endDate))
+	if err == nil {
+		err = h.superadminService.ExportTransactions(stanID, startDate, endDate, writer)
+	}
+	if err != nil {
+		abortReportDownload(c, err)
+	}
+}
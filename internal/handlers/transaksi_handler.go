@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strings"
 
 	"swipeup-be/internal/models"
@@ -53,7 +54,18 @@ func (h *TransaksiHandler) Create(c *gin.Context) {
 		Status:  models.StatusBelumDikonfirm,
 	}
 
-	if err := h.service.CreateWithDetails(transaksi, req.Details); err != nil {
+	// Lines are priced on the server; client prices and discounts are not trusted
+	details, err := h.service.PriceDetails(req.IDStan, req.Details)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidOrderItem) || errors.Is(err, services.ErrInvalidOption) || errors.Is(err, services.ErrInvalidNote) {
+			BadRequestResponse(c, err.Error(), nil)
+		} else {
+			InternalErrorResponse(c, "Failed to price transaction", err)
+		}
+		return
+	}
+
+	if err := h.service.CreateWithDetails(transaksi, details); err != nil {
 		// Check for specific FK constraint errors
 		errMsg := err.Error()
 		if strings.Contains(errMsg, "fk_stans_transaksi") {
@@ -68,6 +80,14 @@ func (h *TransaksiHandler) Create(c *gin.Context) {
 			BadRequestResponse(c, "One or more menu items in details do not exist", nil)
 			return
 		}
+		if errors.Is(err, services.ErrDiskonBudgetExhausted) {
+			BadRequestResponse(c, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, services.ErrInsufficientStock) {
+			BadRequestResponse(c, "Insufficient stock", err)
+			return
+		}
 		InternalErrorResponse(c, "Failed to create transaction", err)
 		return
 	}
@@ -1,20 +1,40 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type Cart struct {
 	ID        uint      `json:"id" gorm:"column:id;primaryKey"`
 	IDSiswa   uint      `json:"id_siswa" gorm:"column:id_siswa;not null"`
-	IDMenu    uint      `json:"id_menu" gorm:"column:id_menu;not null"`
+	IDMenu    *uint     `json:"id_menu" gorm:"column:id_menu"`   // Diisi untuk item menu biasa
+	IDBundle  *uint     `json:"id_bundle" gorm:"column:id_bundle"` // Diisi untuk item paket
 	Qty       int       `json:"qty" gorm:"column:qty;not null;check:qty > 0"`
+	OpsiKey   string    `json:"-" gorm:"column:opsi_key;type:varchar(255);default:''"` // ID opsi terurut, membedakan item menu yang sama dengan pilihan berbeda
+	OptionIDs []uint    `json:"option_ids,omitempty" gorm:"-"`
+	Catatan   string    `json:"catatan" gorm:"column:catatan;type:varchar(200);default:''"` // Catatan untuk stan, contoh: "tidak pedas"
 	CreatedBy string    `json:"created_by" gorm:"column:created_by"`
 	UpdatedBy string    `json:"updated_by" gorm:"column:updated_by"`
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
 
 	// Relations
-	Siswa Siswa `json:"siswa" gorm:"foreignKey:IDSiswa;constraint:OnDelete:CASCADE"`
-	Menu  Menu  `json:"menu" gorm:"foreignKey:IDMenu;constraint:OnDelete:CASCADE"`
-}
\ No newline at end of file
+	Siswa   Siswa        `json:"siswa" gorm:"foreignKey:IDSiswa;constraint:OnDelete:CASCADE"`
+	Menu    *Menu        `json:"menu,omitempty" gorm:"foreignKey:IDMenu;constraint:OnDelete:CASCADE"`
+	Bundle  *MenuBundle  `json:"bundle,omitempty" gorm:"foreignKey:IDBundle;constraint:OnDelete:CASCADE"`
+	Options []CartOption `json:"options,omitempty" gorm:"foreignKey:IDCart;constraint:OnDelete:CASCADE"`
+}
+
+// MarshalJSON writes id_menu as a plain number, 0 for bundle items, as before bundles existed
+func (c Cart) MarshalJSON() ([]byte, error) {
+	type cart Cart
+	var idMenu uint
+	if c.IDMenu != nil {
+		idMenu = *c.IDMenu
+	}
+	return json.Marshal(struct {
+		cart
+		IDMenu uint `json:"id_menu"`
+	}{cart(c), idMenu})
+}
@@ -13,6 +13,11 @@ type DetailTransaksi struct {
 	Qty          int            `json:"qty" gorm:"column:qty;not null"`
 	HargaBeli    float64        `json:"harga_beli" gorm:"column:harga_beli;not null"`
 	NamaDiskon   string         `json:"nama_diskon" gorm:"column:nama_diskon;type:varchar(100);default:''"`
+	IDDiskon     *uint          `json:"id_diskon" gorm:"column:id_diskon;index"`                       // Diskon yang dipakai saat checkout
+	HargaAsli    float64        `json:"harga_asli" gorm:"column:harga_asli;default:0"`                 // Harga satuan sebelum diskon
+	Potongan     float64        `json:"potongan" gorm:"column:potongan;default:0"`                     // Total potongan diskon untuk baris ini (qty x potongan satuan)
+	Catatan      string         `json:"catatan" gorm:"column:catatan;type:varchar(200);default:''"`    // Catatan dari siswa, contoh: "no ice"
+	IDBundle     *uint          `json:"id_bundle" gorm:"column:id_bundle;index"`                       // Diisi jika baris ini komponen dari paket
 	CreatedBy    string         `json:"created_by" gorm:"column:created_by"`
 	UpdatedBy    string         `json:"updated_by" gorm:"column:updated_by"`
 	CreatedAt    time.Time      `json:"created_at" gorm:"column:created_at"`
@@ -20,6 +25,9 @@ type DetailTransaksi struct {
 	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
 	
 	// Relations
-	Transaksi Transaksi `json:"transaksi" gorm:"foreignKey:IDTransaksi;constraint:OnDelete:CASCADE"`
-	Menu      Menu      `json:"menu" gorm:"foreignKey:IDMenu;constraint:OnDelete:CASCADE"`
+	Transaksi Transaksi               `json:"transaksi" gorm:"foreignKey:IDTransaksi;constraint:OnDelete:CASCADE"`
+	Menu      Menu                    `json:"menu" gorm:"foreignKey:IDMenu;constraint:OnDelete:CASCADE"`
+	Diskon    *Diskon                 `json:"diskon,omitempty" gorm:"foreignKey:IDDiskon;constraint:OnDelete:SET NULL"`
+	Bundle    *MenuBundle             `json:"bundle,omitempty" gorm:"foreignKey:IDBundle;constraint:OnDelete:SET NULL"`
+	Options   []DetailTransaksiOption `json:"options,omitempty" gorm:"foreignKey:IDDetailTransaksi;constraint:OnDelete:CASCADE"`
 }
@@ -9,9 +9,10 @@ import (
 type TipeDiskon string
 
 const (
-	DiskonGlobal TipeDiskon = "global" // Diatur oleh superadmin, berlaku semua stan
-	DiskonStan   TipeDiskon = "stan"   // Diatur oleh admin stan, berlaku untuk stannya saja
-	DiskonMenu   TipeDiskon = "menu"   // Diatur oleh admin stan, berlaku untuk menu tertentu
+	DiskonGlobal   TipeDiskon = "global"   // Diatur oleh superadmin, berlaku semua stan
+	DiskonStan     TipeDiskon = "stan"     // Diatur oleh admin stan, berlaku untuk stannya saja
+	DiskonMenu     TipeDiskon = "menu"     // Diatur oleh admin stan, berlaku untuk menu tertentu
+	DiskonKategori TipeDiskon = "kategori" // Berlaku untuk semua menu dengan jenis/tag tertentu, dihitung saat pricing
 )
 
 type Diskon struct {
@@ -22,6 +23,11 @@ type Diskon struct {
 	TanggalAkhir     time.Time      `json:"tanggal_akhir" gorm:"column:tanggal_akhir;not null"`
 	TipeDiskon       TipeDiskon     `json:"tipe_diskon" gorm:"column:tipe_diskon;type:varchar(20);not null;default:'global'"`
 	IDStan           *uint          `json:"id_stan" gorm:"column:id_stan;index"` // NULL untuk global, berisi ID untuk diskon stan
+	TargetJenis      *JenisMenu     `json:"target_jenis,omitempty" gorm:"column:target_jenis;type:varchar(20)"` // Hanya untuk diskon kategori
+	TargetTag        string         `json:"target_tag,omitempty" gorm:"column:target_tag;type:varchar(50)"`     // Hanya untuk diskon kategori
+	AnggaranSubsidi  *float64       `json:"anggaran_subsidi" gorm:"column:anggaran_subsidi"`                    // NULL berarti tanpa batas anggaran
+	TotalSubsidi     float64        `json:"total_subsidi" gorm:"column:total_subsidi;default:0"`                // Akumulasi potongan yang sudah diberikan
+	IsActive         bool           `json:"is_active" gorm:"column:is_active;default:true"`                     // Otomatis false saat anggaran habis
 	CreatedBy        string         `json:"created_by" gorm:"column:created_by"`
 	UpdatedBy        string         `json:"updated_by" gorm:"column:updated_by"`
 	CreatedAt        time.Time      `json:"created_at" gorm:"column:created_at"`
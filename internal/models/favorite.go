@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// Favorite is a menu saved by a siswa for quick ordering
+type Favorite struct {
+	ID        uint      `json:"id" gorm:"column:id;primaryKey"`
+	IDSiswa   uint      `json:"id_siswa" gorm:"column:id_siswa;not null;uniqueIndex:idx_favorites_siswa_menu"`
+	IDMenu    uint      `json:"id_menu" gorm:"column:id_menu;not null;uniqueIndex:idx_favorites_siswa_menu"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+
+	// Relations
+	Menu *Menu `json:"menu,omitempty" gorm:"foreignKey:IDMenu;constraint:OnDelete:CASCADE"`
+}
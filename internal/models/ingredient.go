@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ingredient is a raw ingredient a stan keeps in stock, e.g. beras in gram
+type Ingredient struct {
+	ID        uint           `json:"id" gorm:"column:id;primaryKey"`
+	IDStan    uint           `json:"id_stan" gorm:"column:id_stan;not null;index"`
+	Nama      string         `json:"nama" gorm:"column:nama;type:varchar(100);not null"`
+	Satuan    string         `json:"satuan" gorm:"column:satuan;type:varchar(20);not null"` // Contoh: gram, ml, pcs
+	Stock     float64        `json:"stock" gorm:"column:stock;default:0"`                   // Dalam satuan bahan
+	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
+}
+
+// RecipeItem is the quantity of an ingredient used for one portion of a menu.
+// A menu with recipe items has its stock derived from ingredient stock.
+type RecipeItem struct {
+	ID           uint    `json:"id" gorm:"column:id;primaryKey"`
+	IDMenu       uint    `json:"id_menu" gorm:"column:id_menu;not null;uniqueIndex:idx_recipe_items_menu_ingredient"`
+	IDIngredient uint    `json:"id_ingredient" gorm:"column:id_ingredient;not null;uniqueIndex:idx_recipe_items_menu_ingredient"`
+	Qty          float64 `json:"qty" gorm:"column:qty;not null"` // Per porsi, dalam satuan bahan
+
+	// Relations
+	Ingredient *Ingredient `json:"ingredient,omitempty" gorm:"foreignKey:IDIngredient"`
+}
+
+// IngredientPurchase is a purchase (restock) of an ingredient
+type IngredientPurchase struct {
+	ID           uint      `json:"id" gorm:"column:id;primaryKey"`
+	IDIngredient uint      `json:"id_ingredient" gorm:"column:id_ingredient;not null;index"`
+	Qty          float64   `json:"qty" gorm:"column:qty;not null"`                  // Dalam satuan bahan
+	HargaTotal   float64   `json:"harga_total" gorm:"column:harga_total;default:0"` // Total harga pembelian
+	Supplier     string    `json:"supplier" gorm:"column:supplier;type:varchar(100);default:''"`
+	Keterangan   string    `json:"keterangan" gorm:"column:keterangan;type:varchar(255);default:''"`
+	IDUser       uint      `json:"id_user" gorm:"column:id_user;not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+
+	// Relations
+	Ingredient *Ingredient `json:"ingredient,omitempty" gorm:"foreignKey:IDIngredient"`
+}
+
+// IngredientMovement records a manual correction of an ingredient's stock, e.g. from an
+// approved stock opname. Purchases are recorded as IngredientPurchase.
+type IngredientMovement struct {
+	ID           uint        `json:"id" gorm:"column:id;primaryKey"`
+	IDIngredient uint        `json:"id_ingredient" gorm:"column:id_ingredient;not null;index"`
+	Delta        float64     `json:"delta" gorm:"column:delta;not null"`             // Perubahan stok, negatif untuk pengurangan
+	StockAfter   float64     `json:"stock_after" gorm:"column:stock_after;not null"` // Stok setelah perubahan
+	Reason       StockReason `json:"reason" gorm:"column:reason;type:varchar(20);not null"`
+	IDUser       *uint       `json:"id_user" gorm:"column:id_user"` // Kosong jika diubah oleh sistem
+	Keterangan   string      `json:"keterangan" gorm:"column:keterangan;type:varchar(255);default:''"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"column:created_at"`
+}
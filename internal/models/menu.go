@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,10 +11,21 @@ import (
 type JenisMenu string
 
 const (
-	JenisMakanan  JenisMenu = "makanan"
-	JenisMinuman  JenisMenu = "minuman"
+	JenisMakanan JenisMenu = "makanan"
+	JenisMinuman JenisMenu = "minuman"
 )
 
+// Alergen yang dilacak pada menu dan profil siswa
+const (
+	AlergenKacang  = "kacang"
+	AlergenSusu    = "susu"
+	AlergenSeafood = "seafood"
+	AlergenGluten  = "gluten"
+)
+
+// MaxLevelPedas is the highest spice level of a menu
+const MaxLevelPedas = 5
+
 type Menu struct {
 	ID           uint           `json:"id" gorm:"column:id;primaryKey"`
 	NamaMakanan  string         `json:"nama_makanan" gorm:"column:nama_makanan;type:varchar(100);not null"`
@@ -22,15 +35,136 @@ type Menu struct {
 	Deskripsi    string         `json:"deskripsi" gorm:"column:deskripsi;type:text"`
 	Stock        int            `json:"stock" gorm:"column:stock;default:0"`
 	IsAvailable  bool           `json:"is_available" gorm:"column:is_available;default:true"`
+	StokMinimum  int            `json:"stok_minimum" gorm:"column:stok_minimum;default:0"`          // Batas stok menipis untuk peringatan, 0 berarti hanya peringatan stok habis
+	StokPar      int            `json:"stok_par" gorm:"column:stok_par;default:0"`                  // Stok yang dikembalikan setiap hari oleh reset harian, 0 berarti tidak direset
+	Tags         string         `json:"tags" gorm:"column:tags;type:varchar(255)"`                  // Dipisah koma, contoh: "pedas,favorit"
+	Alergen      string         `json:"alergen" gorm:"column:alergen;type:varchar(100);default:''"` // Dipisah koma, contoh: "kacang,susu"
+	IsVegetarian bool           `json:"is_vegetarian" gorm:"column:is_vegetarian;default:false"`
+	IsHalal      bool           `json:"is_halal" gorm:"column:is_halal;default:false"`   // Bersertifikat halal
+	LevelPedas   int            `json:"level_pedas" gorm:"column:level_pedas;default:0"` // 0 (tidak pedas) sampai 5
+	RatingAvg    float64        `json:"rating_avg" gorm:"column:rating_avg;default:0"`   // Rata-rata rating ulasan per item yang tidak disembunyikan
+	RatingCount  int            `json:"rating_count" gorm:"column:rating_count;default:0"`
 	IDStan       uint           `json:"id_stan" gorm:"column:id_stan;not null"`
 	CreatedBy    string         `json:"created_by" gorm:"column:created_by"`
 	UpdatedBy    string         `json:"updated_by" gorm:"column:updated_by"`
 	CreatedAt    time.Time      `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt    time.Time      `json:"updated_at" gorm:"column:updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
-	
+
 	// Relations
 	Stan            Stan              `json:"stan" gorm:"foreignKey:IDStan;constraint:OnDelete:CASCADE"`
 	DetailTransaksi []DetailTransaksi `json:"detail_transaksi,omitempty" gorm:"foreignKey:IDMenu"`
 	MenuDiskon      []MenuDiskon      `json:"menu_diskon,omitempty" gorm:"foreignKey:IDMenu"`
+	OptionGroups    []MenuOptionGroup `json:"option_groups,omitempty" gorm:"foreignKey:IDMenu"`
+	Schedules       []MenuSchedule    `json:"schedules,omitempty" gorm:"foreignKey:IDMenu"`
+	Recipe          []RecipeItem      `json:"recipe,omitempty" gorm:"foreignKey:IDMenu"` // Jika ada, stok menu dihitung dari stok bahan
+
+	// Dihitung saat pricing, tidak disimpan di database
+	Promo      []MenuPromo `json:"promo,omitempty" gorm:"-"`
+	HargaPromo *float64    `json:"harga_promo,omitempty" gorm:"-"`
+}
+
+// MenuPromo is a discount badge shown on menu listings
+type MenuPromo struct {
+	IDDiskon         uint       `json:"id_diskon"`
+	NamaDiskon       string     `json:"nama_diskon"`
+	TipeDiskon       TipeDiskon `json:"tipe_diskon"`
+	PersentaseDiskon float64    `json:"persentase_diskon"`
+}
+
+// TagList returns the menu tags as a slice
+func (m *Menu) TagList() []string {
+	if m.Tags == "" {
+		return nil
+	}
+	return strings.Split(m.Tags, ",")
+}
+
+// HasTag checks whether the menu carries the given tag
+func (m *Menu) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range m.TagList() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags lowercases, trims and deduplicates a comma separated tag list
+func NormalizeTags(tags string) string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, t := range strings.Split(tags, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	return strings.Join(result, ",")
+}
+
+// IsScheduledAt checks the menu's availability schedules at t (in the school's timezone).
+// Schedules must be preloaded; a menu without schedules is always available.
+func (m *Menu) IsScheduledAt(t time.Time) bool {
+	if len(m.Schedules) == 0 {
+		return true
+	}
+	for i := range m.Schedules {
+		if m.Schedules[i].IsActiveAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScheduledOn checks whether the menu is offered at any time on the day of t
+func (m *Menu) IsScheduledOn(t time.Time) bool {
+	if len(m.Schedules) == 0 {
+		return true
+	}
+	for i := range m.Schedules {
+		if m.Schedules[i].AppliesOnDate(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAlergen normalizes a comma separated allergen list and rejects unknown allergens
+func NormalizeAlergen(alergen string) (string, error) {
+	normalized := NormalizeTags(alergen)
+	if normalized == "" {
+		return "", nil
+	}
+	for _, a := range strings.Split(normalized, ",") {
+		switch a {
+		case AlergenKacang, AlergenSusu, AlergenSeafood, AlergenGluten:
+		default:
+			return "", fmt.Errorf("unknown alergen %q", a)
+		}
+	}
+	return normalized, nil
+}
+
+// ConflictingAlergen returns the menu allergens found in the given comma separated allergy list
+func (m *Menu) ConflictingAlergen(alergi string) []string {
+	if m.Alergen == "" || alergi == "" {
+		return nil
+	}
+
+	avoid := make(map[string]bool)
+	for _, a := range strings.Split(alergi, ",") {
+		avoid[a] = true
+	}
+
+	var conflicts []string
+	for _, a := range strings.Split(m.Alergen, ",") {
+		if avoid[a] {
+			conflicts = append(conflicts, a)
+		}
+	}
+	return conflicts
 }
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MenuBundle is a paket (combo) of existing menus sold at its own price, e.g. nasi + ayam + es teh
+type MenuBundle struct {
+	ID          uint           `json:"id" gorm:"column:id;primaryKey"`
+	IDStan      uint           `json:"id_stan" gorm:"column:id_stan;not null;index"`
+	NamaBundle  string         `json:"nama_bundle" gorm:"column:nama_bundle;type:varchar(100);not null"`
+	Harga       float64        `json:"harga" gorm:"column:harga;not null"`
+	Foto        string         `json:"foto" gorm:"column:foto;type:varchar(255)"`
+	Deskripsi   string         `json:"deskripsi" gorm:"column:deskripsi;type:text"`
+	IsAvailable bool           `json:"is_available" gorm:"column:is_available;default:true"`
+	CreatedBy   string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedBy   string         `json:"updated_by" gorm:"column:updated_by"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
+
+	// Relations
+	Stan  *Stan            `json:"stan,omitempty" gorm:"foreignKey:IDStan;constraint:OnDelete:CASCADE"`
+	Items []MenuBundleItem `json:"items,omitempty" gorm:"foreignKey:IDBundle"`
+
+	// Dihitung dari stok menu komponen, tidak disimpan di database
+	Stock int `json:"stock" gorm:"-"`
+}
+
+// MenuBundleItem is a component menu of a bundle with its quantity per bundle
+type MenuBundleItem struct {
+	ID       uint `json:"id" gorm:"column:id;primaryKey"`
+	IDBundle uint `json:"id_bundle" gorm:"column:id_bundle;not null;index"`
+	IDMenu   uint `json:"id_menu" gorm:"column:id_menu;not null"`
+	Qty      int  `json:"qty" gorm:"column:qty;not null;default:1"`
+
+	// Relations
+	Menu Menu `json:"menu" gorm:"foreignKey:IDMenu;constraint:OnDelete:CASCADE"`
+}
+
+// DerivedStock returns how many bundles can be made from the component menus' stock.
+// Items must be preloaded with their Menu; an unavailable component makes the bundle unavailable.
+func (b *MenuBundle) DerivedStock() int {
+	if len(b.Items) == 0 {
+		return 0
+	}
+
+	stock := -1
+	for _, item := range b.Items {
+		if item.Qty <= 0 || item.Menu.ID == 0 || !item.Menu.IsAvailable {
+			return 0
+		}
+		possible := item.Menu.Stock / item.Qty
+		if stock < 0 || possible < stock {
+			stock = possible
+		}
+	}
+	return stock
+}
+
+// IsScheduledAt checks that every component menu is scheduled at t. Items must be preloaded
+// with Menu.Schedules.
+func (b *MenuBundle) IsScheduledAt(t time.Time) bool {
+	for i := range b.Items {
+		if !b.Items[i].Menu.IsScheduledAt(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// ProratedPrices splits the bundle price over its items by each component's list price,
+// returning the unit price per item in the same order as Items.
+// The split always adds up to the bundle price so revenue per menu stays consistent.
+func (b *MenuBundle) ProratedPrices() []float64 {
+	prices := make([]float64, len(b.Items))
+	if len(b.Items) == 0 {
+		return prices
+	}
+
+	total := 0.0
+	for _, item := range b.Items {
+		total += item.Menu.Harga * float64(item.Qty)
+	}
+
+	remaining := b.Harga
+	for i, item := range b.Items {
+		var share float64
+		switch {
+		case i == len(b.Items)-1:
+			share = remaining
+		case total > 0:
+			share = b.Harga * item.Menu.Harga * float64(item.Qty) / total
+		default:
+			share = b.Harga / float64(len(b.Items))
+		}
+		remaining -= share
+		prices[i] = share / float64(item.Qty)
+	}
+	return prices
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MenuOptionGroup groups choices for a menu, e.g. ukuran, level pedas, topping
+type MenuOptionGroup struct {
+	ID         uint           `json:"id" gorm:"column:id;primaryKey"`
+	IDMenu     uint           `json:"id_menu" gorm:"column:id_menu;not null;index"`
+	NamaGroup  string         `json:"nama_group" gorm:"column:nama_group;type:varchar(100);not null"`
+	IsRequired bool           `json:"is_required" gorm:"column:is_required;default:false"`
+	MinPilihan int            `json:"min_pilihan" gorm:"column:min_pilihan;default:0"`
+	MaxPilihan int            `json:"max_pilihan" gorm:"column:max_pilihan;default:1"`
+	Urutan     int            `json:"urutan" gorm:"column:urutan;default:0"`
+	CreatedBy  string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedBy  string         `json:"updated_by" gorm:"column:updated_by"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
+
+	// Relations
+	Options []MenuOption `json:"options,omitempty" gorm:"foreignKey:IDGroup"`
+}
+
+// MenuOption is a single choice within an option group with its price delta
+type MenuOption struct {
+	ID            uint           `json:"id" gorm:"column:id;primaryKey"`
+	IDGroup       uint           `json:"id_group" gorm:"column:id_group;not null;index"`
+	NamaOpsi      string         `json:"nama_opsi" gorm:"column:nama_opsi;type:varchar(100);not null"`
+	HargaTambahan float64        `json:"harga_tambahan" gorm:"column:harga_tambahan;default:0"`
+	Stock         *int           `json:"stock" gorm:"column:stock"` // NULL berarti stok tidak dilacak
+	IsAvailable   bool           `json:"is_available" gorm:"column:is_available;default:true"`
+	CreatedBy     string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedBy     string         `json:"updated_by" gorm:"column:updated_by"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
+
+	// Relations
+	Group *MenuOptionGroup `json:"group,omitempty" gorm:"foreignKey:IDGroup;constraint:OnDelete:CASCADE"`
+}
+
+// CartOption is an option chosen for a cart item
+type CartOption struct {
+	ID       uint `json:"id" gorm:"column:id;primaryKey"`
+	IDCart   uint `json:"id_cart" gorm:"column:id_cart;not null;index"`
+	IDOption uint `json:"id_option" gorm:"column:id_option;not null"`
+
+	// Relations
+	Option MenuOption `json:"option" gorm:"foreignKey:IDOption;constraint:OnDelete:CASCADE"`
+}
+
+// DetailTransaksiOption is a snapshot of an option chosen for an order line
+type DetailTransaksiOption struct {
+	ID                uint      `json:"id" gorm:"column:id;primaryKey"`
+	IDDetailTransaksi uint      `json:"id_detail_transaksi" gorm:"column:id_detail_transaksi;not null;index"`
+	IDOption          uint      `json:"id_option" gorm:"column:id_option;not null"`
+	NamaGroup         string    `json:"nama_group" gorm:"column:nama_group;type:varchar(100)"`
+	NamaOpsi          string    `json:"nama_opsi" gorm:"column:nama_opsi;type:varchar(100)"`
+	HargaTambahan     float64   `json:"harga_tambahan" gorm:"column:harga_tambahan;default:0"`
+	CreatedAt         time.Time `json:"created_at" gorm:"column:created_at"`
+}
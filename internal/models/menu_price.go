@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sumber perubahan harga menu
+const (
+	PriceSourceManual    = "manual"
+	PriceSourceImport    = "import"
+	PriceSourceScheduled = "jadwal"
+)
+
+// MenuPriceHistory records a single change of a menu's price
+type MenuPriceHistory struct {
+	ID        uint      `json:"id" gorm:"column:id;primaryKey"`
+	IDMenu    uint      `json:"id_menu" gorm:"column:id_menu;not null;index"`
+	HargaLama float64   `json:"harga_lama" gorm:"column:harga_lama;not null"`
+	HargaBaru float64   `json:"harga_baru" gorm:"column:harga_baru;not null"`
+	Sumber    string    `json:"sumber" gorm:"column:sumber;type:varchar(20);not null"` // manual, import atau jadwal
+	ChangedBy *uint     `json:"changed_by" gorm:"column:changed_by"`                   // ID user, kosong jika diubah oleh sistem
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:ChangedBy"`
+}
+
+// TableName keeps the history table name singular
+func (MenuPriceHistory) TableName() string {
+	return "menu_price_history"
+}
+
+type StatusPriceChange string
+
+const (
+	PriceChangePending   StatusPriceChange = "pending"
+	PriceChangeApplied   StatusPriceChange = "applied"
+	PriceChangeCancelled StatusPriceChange = "cancelled"
+)
+
+// MenuPriceChange is a price change scheduled to take effect at BerlakuMulai
+type MenuPriceChange struct {
+	ID           uint              `json:"id" gorm:"column:id;primaryKey"`
+	IDMenu       uint              `json:"id_menu" gorm:"column:id_menu;not null;index"`
+	HargaBaru    float64           `json:"harga_baru" gorm:"column:harga_baru;not null"`
+	BerlakuMulai time.Time         `json:"berlaku_mulai" gorm:"column:berlaku_mulai;not null"`
+	Status       StatusPriceChange `json:"status" gorm:"column:status;type:varchar(20);default:'pending'"`
+	AppliedAt    *time.Time        `json:"applied_at" gorm:"column:applied_at"`
+	IDUser       uint              `json:"id_user" gorm:"column:id_user;not null"` // User yang menjadwalkan perubahan
+	CreatedAt    time.Time         `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt    time.Time         `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt    gorm.DeletedAt    `json:"deleted_at" gorm:"column:deleted_at;index"`
+
+	// Relations
+	Menu *Menu `json:"menu,omitempty" gorm:"foreignKey:IDMenu"`
+}
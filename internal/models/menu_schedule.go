@@ -0,0 +1,114 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MenuSchedule limits when a menu can be ordered. A menu without schedules is always available;
+// a menu with schedules is available while at least one of them is active.
+type MenuSchedule struct {
+	ID             uint           `json:"id" gorm:"column:id;primaryKey"`
+	IDMenu         uint           `json:"id_menu" gorm:"column:id_menu;not null;index"`
+	Hari           string         `json:"hari" gorm:"column:hari;type:varchar(20);default:''"`              // Hari ISO dipisah koma, 1 = Senin ... 7 = Minggu; kosong berarti setiap hari
+	JamMulai       string         `json:"jam_mulai" gorm:"column:jam_mulai;type:varchar(5);default:''"`     // Format HH:MM, kosong berarti sejak awal hari
+	JamSelesai     string         `json:"jam_selesai" gorm:"column:jam_selesai;type:varchar(5);default:''"` // Format HH:MM, kosong berarti sampai akhir hari
+	TanggalMulai   *time.Time     `json:"tanggal_mulai" gorm:"column:tanggal_mulai"`
+	TanggalSelesai *time.Time     `json:"tanggal_selesai" gorm:"column:tanggal_selesai"`
+	CreatedBy      string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedBy      string         `json:"updated_by" gorm:"column:updated_by"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
+}
+
+// NormalizeHari validates and sorts a comma separated list of ISO weekdays (1-7)
+func NormalizeHari(hari string) (string, error) {
+	seen := make(map[int]bool)
+	var days []int
+	for _, part := range strings.Split(hari, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 1 || day > 7 {
+			return "", fmt.Errorf("invalid hari %q, use 1 (Senin) to 7 (Minggu)", part)
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+
+	parts := make([]string, 0, len(days))
+	for _, day := range days {
+		parts = append(parts, strconv.Itoa(day))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// NormalizeJam validates an HH:MM time of day and pads it to two-digit hours (9:30 becomes
+// 09:30), so times compare as strings. An empty value is allowed.
+func NormalizeJam(jam string) (string, error) {
+	if jam == "" {
+		return "", nil
+	}
+	t, err := time.Parse("15:04", jam)
+	if err != nil {
+		return "", fmt.Errorf("invalid jam %q, use HH:MM", jam)
+	}
+	return t.Format("15:04"), nil
+}
+
+// AppliesOnDate checks the weekday and date range of the schedule. t must be in the school's timezone.
+func (s *MenuSchedule) AppliesOnDate(t time.Time) bool {
+	if s.Hari != "" {
+		weekday := int(t.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		found := false
+		for _, part := range strings.Split(s.Hari, ",") {
+			if part == strconv.Itoa(weekday) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	date := t.Format("2006-01-02")
+	if s.TanggalMulai != nil && date < s.TanggalMulai.In(t.Location()).Format("2006-01-02") {
+		return false
+	}
+	if s.TanggalSelesai != nil && date > s.TanggalSelesai.In(t.Location()).Format("2006-01-02") {
+		return false
+	}
+	return true
+}
+
+// IsActiveAt checks the weekday, date range and time window of the schedule.
+// t must be in the school's timezone.
+func (s *MenuSchedule) IsActiveAt(t time.Time) bool {
+	if !s.AppliesOnDate(t) {
+		return false
+	}
+
+	jam := t.Format("15:04")
+	if s.JamMulai != "" && jam < s.JamMulai {
+		return false
+	}
+	if s.JamSelesai != "" && jam >= s.JamSelesai {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Tipe notifikasi
+const (
+	NotifLowStock = "low_stock"
+	NotifSoldOut  = "sold_out"
+)
+
+// Notification is a message stored for a user, e.g. a stock alert for a stan admin
+type Notification struct {
+	ID        uint       `json:"id" gorm:"column:id;primaryKey"`
+	IDUser    uint       `json:"id_user" gorm:"column:id_user;not null;index"`
+	Tipe      string     `json:"tipe" gorm:"column:tipe;type:varchar(30);not null"`
+	Judul     string     `json:"judul" gorm:"column:judul;type:varchar(150);not null"`
+	Pesan     string     `json:"pesan" gorm:"column:pesan;type:text"`
+	IDMenu    *uint      `json:"id_menu" gorm:"column:id_menu"` // Menu yang terkait, jika ada
+	IsRead    bool       `json:"is_read" gorm:"column:is_read;default:false"`
+	ReadAt    *time.Time `json:"read_at" gorm:"column:read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+}
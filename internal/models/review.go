@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Batas nilai rating ulasan
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review is a siswa's rating of a completed transaksi; there is at most one review per transaksi
+type Review struct {
+	ID           uint           `json:"id" gorm:"column:id;primaryKey"`
+	IDTransaksi  uint           `json:"id_transaksi" gorm:"column:id_transaksi;not null;uniqueIndex"`
+	IDSiswa      uint           `json:"id_siswa" gorm:"column:id_siswa;not null;index"`
+	IDStan       uint           `json:"id_stan" gorm:"column:id_stan;not null;index"`
+	Rating       int            `json:"rating" gorm:"column:rating;not null"` // 1 sampai 5
+	Komentar     string         `json:"komentar" gorm:"column:komentar;type:text"`
+	Balasan      string         `json:"balasan" gorm:"column:balasan;type:text"` // Balasan dari admin stan
+	BalasanAt    *time.Time     `json:"balasan_at" gorm:"column:balasan_at"`
+	IsHidden     bool           `json:"is_hidden" gorm:"column:is_hidden;default:false"` // Disembunyikan oleh superadmin
+	AlasanHidden string         `json:"alasan_hidden,omitempty" gorm:"column:alasan_hidden;type:varchar(255)"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt    time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index"`
+
+	// Relations
+	Items []ReviewItem `json:"items,omitempty" gorm:"foreignKey:IDReview"`
+
+	// Hanya nama siswa yang ditampilkan pada ulasan, tidak disimpan di database
+	NamaSiswa string `json:"nama_siswa,omitempty" gorm:"-"`
+}
+
+// ReviewItem is an optional rating of a single order line
+type ReviewItem struct {
+	ID                uint   `json:"id" gorm:"column:id;primaryKey"`
+	IDReview          uint   `json:"id_review" gorm:"column:id_review;not null;index"`
+	IDDetailTransaksi uint   `json:"id_detail_transaksi" gorm:"column:id_detail_transaksi;not null"`
+	IDMenu            uint   `json:"id_menu" gorm:"column:id_menu;not null;index"`
+	Rating            int    `json:"rating" gorm:"column:rating;not null"`
+	Komentar          string `json:"komentar" gorm:"column:komentar;type:text"`
+
+	// Relations
+	Menu *Menu `json:"menu,omitempty" gorm:"foreignKey:IDMenu"`
+}
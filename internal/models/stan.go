@@ -15,6 +15,9 @@ type Stan struct {
 	QrisImage       string         `json:"qris_image" gorm:"column:qris_image;type:varchar(255)"`
 	AcceptCash      bool           `json:"accept_cash" gorm:"column:accept_cash;default:true"`
 	AcceptQris      bool           `json:"accept_qris" gorm:"column:accept_qris;default:false"`
+	RatingAvg       float64        `json:"rating_avg" gorm:"column:rating_avg;default:0"` // Rata-rata rating ulasan yang tidak disembunyikan
+	RatingCount     int            `json:"rating_count" gorm:"column:rating_count;default:0"`
+	JamResetStok    string         `json:"jam_reset_stok" gorm:"column:jam_reset_stok;type:varchar(5);default:'05:00'"` // Format HH:MM waktu sekolah, kosong berarti tanpa reset otomatis
 	IDUser          uint           `json:"id_user" gorm:"column:id_user;not null"`
 	CreatedBy       string         `json:"created_by" gorm:"column:created_by"`
 	UpdatedBy       string         `json:"updated_by" gorm:"column:updated_by"`
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type StockReason string
+
+const (
+	StockRestock    StockReason = "restock"
+	StockSale       StockReason = "sale"
+	StockRefund     StockReason = "refund"
+	StockWaste      StockReason = "waste"
+	StockCorrection StockReason = "correction"
+	StockParReset   StockReason = "reset" // Reset harian ke stok_par
+)
+
+// StockMovement is a ledger entry for a change of a menu's stock.
+// The sum of a menu's deltas equals its current stock.
+type StockMovement struct {
+	ID          uint        `json:"id" gorm:"column:id;primaryKey"`
+	IDMenu      uint        `json:"id_menu" gorm:"column:id_menu;not null;index"`
+	Delta       int         `json:"delta" gorm:"column:delta;not null"`             // Perubahan stok, negatif untuk pengurangan
+	StockAfter  int         `json:"stock_after" gorm:"column:stock_after;not null"` // Stok setelah perubahan
+	Reason      StockReason `json:"reason" gorm:"column:reason;type:varchar(20);not null"`
+	IDTransaksi *uint       `json:"id_transaksi" gorm:"column:id_transaksi;index"` // Diisi untuk penjualan dan refund
+	IDUser      *uint       `json:"id_user" gorm:"column:id_user"`                 // Kosong jika diubah oleh sistem
+	Keterangan  string      `json:"keterangan" gorm:"column:keterangan;type:varchar(255);default:''"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"column:created_at"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:IDUser"`
+}
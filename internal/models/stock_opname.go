@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Status stock opname
+const (
+	OpnamePending  = "pending"
+	OpnameApproved = "approved"
+	OpnameRejected = "rejected"
+)
+
+// StockOpname is a physical stocktake of a stan. Counts are compared with system stock
+// when submitted; approving the opname applies the variances as stock corrections.
+type StockOpname struct {
+	ID            uint       `json:"id" gorm:"column:id;primaryKey"`
+	IDStan        uint       `json:"id_stan" gorm:"column:id_stan;not null;index"`
+	Status        string     `json:"status" gorm:"column:status;type:varchar(20);not null;default:'pending'"`
+	Keterangan    string     `json:"keterangan" gorm:"column:keterangan;type:varchar(255);default:''"`
+	TotalItems    int        `json:"total_items" gorm:"column:total_items;default:0"`
+	VarianceItems int        `json:"variance_items" gorm:"column:variance_items;default:0"` // Jumlah item dengan selisih
+	NilaiSelisih  float64    `json:"nilai_selisih" gorm:"column:nilai_selisih;default:0"`   // Total nilai selisih dalam rupiah
+	IDUser        uint       `json:"id_user" gorm:"column:id_user;not null"`                // Yang mengajukan hitungan
+	ReviewedBy    *uint      `json:"reviewed_by" gorm:"column:reviewed_by"`                 // Yang menyetujui atau menolak
+	ReviewedAt    *time.Time `json:"reviewed_at" gorm:"column:reviewed_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at"`
+
+	// Relations
+	Stan  *Stan             `json:"stan,omitempty" gorm:"foreignKey:IDStan"`
+	Items []StockOpnameItem `json:"items,omitempty" gorm:"foreignKey:IDOpname"`
+}
+
+// StockOpnameItem is the physical count of one menu or ingredient in a stock opname
+type StockOpnameItem struct {
+	ID           uint    `json:"id" gorm:"column:id;primaryKey"`
+	IDOpname     uint    `json:"id_opname" gorm:"column:id_opname;not null;index"`
+	IDMenu       *uint   `json:"id_menu" gorm:"column:id_menu"`             // Diisi untuk menu
+	IDIngredient *uint   `json:"id_ingredient" gorm:"column:id_ingredient"` // Diisi untuk bahan
+	Nama         string  `json:"nama" gorm:"column:nama;type:varchar(100);not null"`
+	Satuan       string  `json:"satuan" gorm:"column:satuan;type:varchar(20);default:''"` // Kosong untuk menu (porsi)
+	StokSistem   float64 `json:"stok_sistem" gorm:"column:stok_sistem;not null"`
+	StokFisik    float64 `json:"stok_fisik" gorm:"column:stok_fisik;not null"`
+	Selisih      float64 `json:"selisih" gorm:"column:selisih;not null"`            // stok_fisik - stok_sistem
+	HargaSatuan  float64 `json:"harga_satuan" gorm:"column:harga_satuan;default:0"` // Harga menu, atau rata-rata harga beli bahan
+	NilaiSelisih float64 `json:"nilai_selisih" gorm:"column:nilai_selisih;default:0"`
+}
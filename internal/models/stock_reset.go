@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Status reset stok harian
+const (
+	StockResetApplied = "applied"
+	StockResetSkipped = "skipped"
+)
+
+// Pemicu reset stok harian
+const (
+	StockResetScheduled = "jadwal"
+	StockResetManual    = "manual"
+)
+
+// StockReset records the daily par-level reset of a stan for one school day,
+// so a day is reset at most once and a skipped day is left alone by the scheduler
+type StockReset struct {
+	ID        uint      `json:"id" gorm:"column:id;primaryKey"`
+	IDStan    uint      `json:"id_stan" gorm:"column:id_stan;not null;uniqueIndex:idx_stock_resets_stan_tanggal"`
+	Tanggal   string    `json:"tanggal" gorm:"column:tanggal;type:varchar(10);not null;uniqueIndex:idx_stock_resets_stan_tanggal"` // Format YYYY-MM-DD waktu sekolah
+	Status    string    `json:"status" gorm:"column:status;type:varchar(20);not null"`
+	Pemicu    string    `json:"pemicu" gorm:"column:pemicu;type:varchar(20);default:''"` // jadwal atau manual, kosong untuk hari yang dilewati
+	MenuCount int       `json:"menu_count" gorm:"column:menu_count;default:0"`           // Jumlah menu yang direset
+	IDUser    *uint     `json:"id_user" gorm:"column:id_user"`                           // Kosong jika dijalankan oleh jadwal
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
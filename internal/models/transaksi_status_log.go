@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// TransaksiStatusLog records when a transaction entered a status, so waiting and
+// preparation times can be derived from the order's history
+type TransaksiStatusLog struct {
+	ID          uint            `json:"id" gorm:"column:id;primaryKey"`
+	IDTransaksi uint            `json:"id_transaksi" gorm:"column:id_transaksi;not null;index"`
+	Status      StatusTransaksi `json:"status" gorm:"column:status;type:varchar(20);not null"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"column:created_at"` // Waktu status mulai berlaku
+}
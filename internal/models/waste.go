@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+type WasteReason string
+
+const (
+	WasteSisaHarian WasteReason = "sisa_harian" // Tidak terjual sampai akhir hari
+	WasteKadaluarsa WasteReason = "kadaluarsa"  // Basi atau melewati tanggal kedaluwarsa
+	WasteRusak      WasteReason = "rusak"       // Jatuh, tumpah atau rusak
+	WasteSalahMasak WasteReason = "salah_masak" // Gagal masak atau salah pesanan
+	WasteLainnya    WasteReason = "lainnya"
+)
+
+// IsValid checks that the reason is one of the known waste reasons
+func (r WasteReason) IsValid() bool {
+	switch r {
+	case WasteSisaHarian, WasteKadaluarsa, WasteRusak, WasteSalahMasak, WasteLainnya:
+		return true
+	}
+	return false
+}
+
+// WasteEntry records food or ingredients thrown away, valued at the menu price
+// or at the ingredient cost
+type WasteEntry struct {
+	ID           uint        `json:"id" gorm:"column:id;primaryKey"`
+	IDStan       uint        `json:"id_stan" gorm:"column:id_stan;not null;index"`
+	IDMenu       *uint       `json:"id_menu" gorm:"column:id_menu;index"`       // Diisi untuk menu
+	IDIngredient *uint       `json:"id_ingredient" gorm:"column:id_ingredient"` // Diisi untuk bahan
+	Qty          float64     `json:"qty" gorm:"column:qty;not null"`            // Porsi untuk menu, satuan bahan untuk bahan
+	Alasan       WasteReason `json:"alasan" gorm:"column:alasan;type:varchar(20);not null"`
+	HargaSatuan  float64     `json:"harga_satuan" gorm:"column:harga_satuan;default:0"` // Harga menu, atau biaya bahan per satuan/porsi
+	NilaiWaste   float64     `json:"nilai_waste" gorm:"column:nilai_waste;default:0"`   // qty * harga_satuan
+	Keterangan   string      `json:"keterangan" gorm:"column:keterangan;type:varchar(255);default:''"`
+	IDUser       uint        `json:"id_user" gorm:"column:id_user;not null"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"column:created_at"`
+
+	// Relations
+	Menu       *Menu       `json:"menu,omitempty" gorm:"foreignKey:IDMenu"`
+	Ingredient *Ingredient `json:"ingredient,omitempty" gorm:"foreignKey:IDIngredient"`
+}
@@ -23,7 +23,7 @@ func (s *ActivityLogService) LogActivity(userID uint, action, description, ipAdd
 		Description: description,
 		IPAddress:   ipAddress,
 		UserAgent:   userAgent,
-		CreatedAt:   time.Now(),
+		CreatedAt:   time.Now().UTC(),
 	}
 
 	return s.db.Create(activity).Error
@@ -189,7 +189,7 @@ func (s *ActivityLogService) GetActivityStats() (map[string]interface{}, error)
 	s.db.Model(&models.ActivityLog{}).Distinct("id_user").Count(&stats.UniqueUsers)
 
 	// Today's activities
-	today := time.Now().Truncate(24 * time.Hour)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
 	tomorrow := today.Add(24 * time.Hour)
 	s.db.Model(&models.ActivityLog{}).Where("created_at >= ? AND created_at < ?", today, tomorrow).Count(&stats.TodayActivities)
 
@@ -204,6 +204,6 @@ func (s *ActivityLogService) GetActivityStats() (map[string]interface{}, error)
 
 // CleanOldLogs removes activity logs older than specified days
 func (s *ActivityLogService) CleanOldLogs(days int) error {
-	cutoffDate := time.Now().AddDate(0, 0, -days)
+	cutoffDate := time.Now().UTC().AddDate(0, 0, -days)
 	return s.db.Where("created_at < ?", cutoffDate).Delete(&models.ActivityLog{}).Error
 }
\ No newline at end of file
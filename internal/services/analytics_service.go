@@ -0,0 +1,567 @@
+package services
+
+import (
+	"fmt"
+	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Bucket is the size of a time-series bucket
+type Bucket string
+
+const (
+	BucketHour  Bucket = "hour"
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week" // ISO week, starting Monday
+	BucketMonth Bucket = "month"
+)
+
+// maxBuckets limits how many buckets one time series may have
+const maxBuckets = 1000
+
+// TimeSeriesPoint is the sales of one bucket, starting at Periode in the school's timezone
+type TimeSeriesPoint struct {
+	Periode  time.Time `json:"periode"`
+	Revenue  float64   `json:"revenue"`
+	Orders   int       `json:"orders"`
+	AvgOrder float64   `json:"avg_order"`
+	Items    int       `json:"items"`
+}
+
+// TimeSeriesPeriod is a time series over [StartDate, EndDate) with its totals
+type TimeSeriesPeriod struct {
+	StartDate time.Time         `json:"start_date"`
+	EndDate   time.Time         `json:"end_date"`
+	Points    []TimeSeriesPoint `json:"points"`
+	Totals    TimeSeriesPoint   `json:"totals"` // Periode is start_date
+}
+
+// TimeSeriesChange is the change of the current totals against the previous period, in percent.
+// A value is nil when the previous period had nothing to compare with.
+type TimeSeriesChange struct {
+	Revenue  *float64 `json:"revenue"`
+	Orders   *float64 `json:"orders"`
+	AvgOrder *float64 `json:"avg_order"`
+	Items    *float64 `json:"items"`
+}
+
+// TimeSeries is sales bucketed over a period, compared with the previous period of the same length
+type TimeSeries struct {
+	Bucket   Bucket           `json:"bucket"`
+	Timezone string           `json:"timezone"`
+	Current  TimeSeriesPeriod `json:"current"`
+	Previous TimeSeriesPeriod `json:"previous"`
+	Change   TimeSeriesChange `json:"change"`
+}
+
+// AnalyticsService builds sales analytics for dashboards
+type AnalyticsService struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsService(db *gorm.DB) *AnalyticsService {
+	return &AnalyticsService{db: db}
+}
+
+// truncate returns the start of the bucket that contains t, in t's location
+func (b Bucket) truncate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch b {
+	case BucketHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case BucketWeek:
+		weekday := (int(t.Weekday()) + 6) % 7 // Monday = 0
+		return time.Date(y, m, d-weekday, 0, 0, 0, 0, t.Location())
+	case BucketMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// next returns the start of the bucket after the one starting at t
+func (b Bucket) next(t time.Time) time.Time {
+	switch b {
+	case BucketHour:
+		return t.Add(time.Hour)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// previousStart returns the start of the period right before [start, end) with as many
+// buckets. start and end must be bucket starts; months are counted as calendar months.
+func (b Bucket) previousStart(start, end time.Time) time.Time {
+	if b == BucketMonth {
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+		return start.AddDate(0, -months, 0)
+	}
+	return b.truncate(start.Add(-end.Sub(start)))
+}
+
+// defaultSpan returns the start of the default period ending at end
+func (b Bucket) defaultSpan(end time.Time) time.Time {
+	switch b {
+	case BucketHour:
+		return end.Add(-24 * time.Hour)
+	case BucketWeek:
+		return end.AddDate(0, 0, -7*12)
+	case BucketMonth:
+		return end.AddDate(-1, 0, 0)
+	default:
+		return end.AddDate(0, 0, -30)
+	}
+}
+
+// ParseBucket validates a bucket size; an empty value means day
+func ParseBucket(bucket string) (Bucket, error) {
+	switch b := Bucket(bucket); b {
+	case "":
+		return BucketDay, nil
+	case BucketHour, BucketDay, BucketWeek, BucketMonth:
+		return b, nil
+	default:
+		return "", fmt.Errorf("%w: bucket must be hour, day, week or month", ErrInvalidPeriod)
+	}
+}
+
+// GetTimeSeries buckets revenue, orders, average order value and items sold between
+// startDate and endDate in the school's timezone, and compares them with the period of
+// the same length right before. Without a period a default span for the bucket size up
+// to now is used. stanID 0 covers all stans.
+func (s *AnalyticsService) GetTimeSeries(stanID uint, bucket Bucket, startDate, endDate time.Time) (*TimeSeries, error) {
+	loc := utils.SchoolLocation()
+	if endDate.IsZero() {
+		endDate = time.Now()
+	}
+	if startDate.IsZero() {
+		startDate = bucket.defaultSpan(endDate)
+	}
+	// Whole buckets only, so the first and last points are comparable with the others
+	start := bucket.truncate(startDate.In(loc))
+	end := bucket.truncate(endDate.In(loc))
+	if end.Before(endDate) {
+		end = bucket.next(end)
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: end_date must be after start_date", ErrInvalidPeriod)
+	}
+
+	current, err := s.period(stanID, bucket, start, end)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.period(stanID, bucket, bucket.previousStart(start, end), start)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TimeSeries{
+		Bucket:   bucket,
+		Timezone: loc.String(),
+		Current:  *current,
+		Previous: *previous,
+		Change: TimeSeriesChange{
+			Revenue:  percentChange(previous.Totals.Revenue, current.Totals.Revenue),
+			Orders:   percentChange(float64(previous.Totals.Orders), float64(current.Totals.Orders)),
+			AvgOrder: percentChange(previous.Totals.AvgOrder, current.Totals.AvgOrder),
+			Items:    percentChange(float64(previous.Totals.Items), float64(current.Totals.Items)),
+		},
+	}, nil
+}
+
+// period builds the points of [start, end), including empty buckets
+func (s *AnalyticsService) period(stanID uint, bucket Bucket, start, end time.Time) (*TimeSeriesPeriod, error) {
+	result := &TimeSeriesPeriod{StartDate: start, EndDate: end, Points: []TimeSeriesPoint{}}
+	index := make(map[time.Time]int)
+	for t := start; t.Before(end); t = bucket.next(t) {
+		if len(result.Points) == maxBuckets {
+			return nil, fmt.Errorf("%w: period has more than %d buckets, use a larger bucket", ErrInvalidPeriod, maxBuckets)
+		}
+		index[t] = len(result.Points)
+		result.Points = append(result.Points, TimeSeriesPoint{Periode: t})
+	}
+
+	// Aggregate by hour in SQL and roll the hours up into school-time buckets in Go.
+	// Order times are stored in UTC, see database.Connect.
+	var hours []struct {
+		Jam     time.Time
+		Revenue float64
+		Orders  int
+		Items   int
+	}
+	query := s.db.Table("detail_transaksis d").
+		Select("date_trunc('hour', t.tanggal) AS jam, SUM(d.qty * d.harga_beli) AS revenue, COUNT(DISTINCT t.id) AS orders, SUM(d.qty) AS items").
+		Joins("JOIN transaksis t ON t.id = d.id_transaksi AND t.deleted_at IS NULL").
+		Where("d.deleted_at IS NULL AND t.tanggal >= ? AND t.tanggal < ?", start.UTC(), end.UTC())
+	if stanID != 0 {
+		query = query.Where("t.id_stan = ?", stanID)
+	}
+	if err := query.Group("jam").Scan(&hours).Error; err != nil {
+		return nil, err
+	}
+
+	loc := start.Location()
+	result.Totals.Periode = start
+	for _, hour := range hours {
+		i, ok := index[bucket.truncate(hour.Jam.In(loc))]
+		if !ok {
+			continue
+		}
+		point := &result.Points[i]
+		point.Revenue += hour.Revenue
+		point.Orders += hour.Orders
+		point.Items += hour.Items
+
+		result.Totals.Revenue += hour.Revenue
+		result.Totals.Orders += hour.Orders
+		result.Totals.Items += hour.Items
+	}
+	for i := range result.Points {
+		result.Points[i].AvgOrder = averageOrder(result.Points[i].Revenue, result.Points[i].Orders)
+	}
+	result.Totals.AvgOrder = averageOrder(result.Totals.Revenue, result.Totals.Orders)
+	return result, nil
+}
+
+// averageOrder returns revenue per order, 0 without orders
+func averageOrder(revenue float64, orders int) float64 {
+	if orders == 0 {
+		return 0
+	}
+	return revenue / float64(orders)
+}
+
+// percentChange returns the change from previous to current in percent, nil when previous is 0
+func percentChange(previous, current float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := (current - previous) / previous * 100
+	return &change
+}
+
+// MenuSort is the metric menus are ranked by in the menu performance report
+type MenuSort string
+
+const (
+	MenuSortQty     MenuSort = "qty"
+	MenuSortRevenue MenuSort = "revenue"
+	MenuSortBuyers  MenuSort = "buyers"
+)
+
+// ParseMenuSort validates a menu ranking metric; an empty value means qty
+func ParseMenuSort(sort string) (MenuSort, error) {
+	switch m := MenuSort(sort); m {
+	case "":
+		return MenuSortQty, nil
+	case MenuSortQty, MenuSortRevenue, MenuSortBuyers:
+		return m, nil
+	default:
+		return "", fmt.Errorf("%w: sort must be qty, revenue or buyers", ErrInvalidReportSort)
+	}
+}
+
+// MenuPerformance is the sales of one menu in a period
+type MenuPerformance struct {
+	Rank         int     `json:"rank"`
+	MenuID       uint    `json:"menu_id"`
+	NamaMakanan  string  `json:"nama_makanan"`
+	StanID       uint    `json:"stan_id"`
+	NamaStan     string  `json:"nama_stan"`
+	Qty          int     `json:"qty"`
+	Revenue      float64 `json:"revenue"`
+	Buyers       int     `json:"buyers"` // Distinct students who bought it
+	Orders       int     `json:"orders"`
+	QtyDiskon    int     `json:"qty_diskon"`
+	Potongan     float64 `json:"potongan"`
+	DiskonShare  float64 `json:"diskon_share"`  // Percent of qty sold with a discount
+	RevenueShare float64 `json:"revenue_share"` // Percent of the period's total revenue
+	Deleted      bool    `json:"deleted"`       // Menu was deleted but sold in the period
+}
+
+// MenuPerformanceTotals is the sales of all menus in the report
+type MenuPerformanceTotals struct {
+	Qty         int     `json:"qty"`
+	Revenue     float64 `json:"revenue"`
+	Buyers      int     `json:"buyers"`
+	Orders      int     `json:"orders"`
+	QtyDiskon   int     `json:"qty_diskon"`
+	Potongan    float64 `json:"potongan"`
+	DiskonShare float64 `json:"diskon_share"`
+}
+
+// MenuPerformanceReport ranks menus by sales in a period and lists the menus that did not sell
+type MenuPerformanceReport struct {
+	StartDate time.Time             `json:"start_date"`
+	EndDate   time.Time             `json:"end_date"`
+	SortBy    MenuSort              `json:"sort_by"`
+	Totals    MenuPerformanceTotals `json:"totals"`
+	Menus     []MenuPerformance     `json:"menus"`
+	NeverSold []MenuPerformance     `json:"never_sold"`
+}
+
+// salesQuery returns the order lines in the period, of one stan when stanID is set
+func (s *AnalyticsService) salesQuery(stanID uint, startDate, endDate time.Time) *gorm.DB {
+	query := s.db.Table("detail_transaksis").
+		Joins("JOIN transaksis ON transaksis.id = detail_transaksis.id_transaksi AND transaksis.deleted_at IS NULL").
+		Where("detail_transaksis.deleted_at IS NULL")
+	if stanID != 0 {
+		query = query.Where("transaksis.id_stan = ?", stanID)
+	}
+	if !startDate.IsZero() && !endDate.IsZero() {
+		query = query.Where("transaksis.tanggal BETWEEN ? AND ?", startDate, endDate)
+	}
+	return query
+}
+
+// GetMenuPerformance ranks menus by quantity, revenue or distinct buyers in the period, with
+// their discount share, and lists the active menus that did not sell. stanID 0 covers all stans.
+func (s *AnalyticsService) GetMenuPerformance(stanID uint, sortBy MenuSort, startDate, endDate time.Time) (*MenuPerformanceReport, error) {
+	report := &MenuPerformanceReport{
+		StartDate: startDate,
+		EndDate:   endDate,
+		SortBy:    sortBy,
+		Menus:     []MenuPerformance{},
+		NeverSold: []MenuPerformance{},
+	}
+
+	sales := s.salesQuery(stanID, startDate, endDate).
+		Select("detail_transaksis.id_menu, SUM(detail_transaksis.qty) AS qty, " +
+			"SUM(detail_transaksis.qty * detail_transaksis.harga_beli) AS revenue, " +
+			"COUNT(DISTINCT transaksis.id_siswa) AS buyers, COUNT(DISTINCT transaksis.id) AS orders, " +
+			"COALESCE(SUM(detail_transaksis.qty) FILTER (WHERE detail_transaksis.id_diskon IS NOT NULL), 0) AS qty_diskon, " +
+			"COALESCE(SUM(detail_transaksis.potongan), 0) AS potongan").
+		Group("detail_transaksis.id_menu")
+
+	var rows []struct {
+		MenuID      uint
+		NamaMakanan string
+		StanID      uint
+		NamaStan    string
+		Qty         int
+		Revenue     float64
+		Buyers      int
+		Orders      int
+		QtyDiskon   int
+		Potongan    float64
+		Deleted     bool
+	}
+	query := s.db.Table("menus").
+		Select("menus.id AS menu_id, menus.nama_makanan, menus.id_stan AS stan_id, stans.nama_stan, "+
+			"COALESCE(sales.qty, 0) AS qty, COALESCE(sales.revenue, 0) AS revenue, "+
+			"COALESCE(sales.buyers, 0) AS buyers, COALESCE(sales.orders, 0) AS orders, "+
+			"COALESCE(sales.qty_diskon, 0) AS qty_diskon, COALESCE(sales.potongan, 0) AS potongan, "+
+			"menus.deleted_at IS NOT NULL AS deleted").
+		Joins("JOIN stans ON stans.id = menus.id_stan").
+		Joins("LEFT JOIN (?) AS sales ON sales.id_menu = menus.id", sales).
+		// Menus that did not sell are only listed while they and their stan still exist
+		Where("(menus.deleted_at IS NULL AND stans.deleted_at IS NULL) OR sales.id_menu IS NOT NULL")
+	if stanID != 0 {
+		query = query.Where("menus.id_stan = ?", stanID)
+	}
+	if err := query.Order("COALESCE(sales." + string(sortBy) + ", 0) DESC, menus.id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Distinct buyers and orders do not add up across menus, so count them once for the period
+	var totals struct {
+		Buyers int
+		Orders int
+	}
+	err := s.salesQuery(stanID, startDate, endDate).
+		Select("COUNT(DISTINCT transaksis.id_siswa) AS buyers, COUNT(DISTINCT transaksis.id) AS orders").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	report.Totals.Buyers = totals.Buyers
+	report.Totals.Orders = totals.Orders
+
+	for _, row := range rows {
+		report.Totals.Qty += row.Qty
+		report.Totals.Revenue += row.Revenue
+		report.Totals.QtyDiskon += row.QtyDiskon
+		report.Totals.Potongan += row.Potongan
+	}
+	report.Totals.DiskonShare = sharePercent(float64(report.Totals.QtyDiskon), float64(report.Totals.Qty))
+
+	for _, row := range rows {
+		menu := MenuPerformance{
+			MenuID:       row.MenuID,
+			NamaMakanan:  row.NamaMakanan,
+			StanID:       row.StanID,
+			NamaStan:     row.NamaStan,
+			Qty:          row.Qty,
+			Revenue:      row.Revenue,
+			Buyers:       row.Buyers,
+			Orders:       row.Orders,
+			QtyDiskon:    row.QtyDiskon,
+			Potongan:     row.Potongan,
+			DiskonShare:  sharePercent(float64(row.QtyDiskon), float64(row.Qty)),
+			RevenueShare: sharePercent(row.Revenue, report.Totals.Revenue),
+			Deleted:      row.Deleted,
+		}
+		if menu.Qty == 0 {
+			report.NeverSold = append(report.NeverSold, menu)
+			continue
+		}
+		menu.Rank = len(report.Menus) + 1
+		report.Menus = append(report.Menus, menu)
+	}
+
+	return report, nil
+}
+
+// sharePercent returns part as a percentage of whole, 0 when whole is 0
+func sharePercent(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return part / whole * 100
+}
+
+// hariNames labels the heatmap rows, Monday first
+var hariNames = []string{"Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu", "Minggu"}
+
+// HeatmapCell is the order load of one weekday and hour in the school's timezone
+type HeatmapCell struct {
+	Orders         int     `json:"orders"`
+	AvgWaitMinutes float64 `json:"avg_wait_minutes"` // From order to "dimasak"
+	AvgPrepMinutes float64 `json:"avg_prep_minutes"` // From "dimasak" to "diantar"
+	PrepSamples    int     `json:"prep_samples"`     // Orders with both timestamps, averages are 0 without samples
+}
+
+// PeakHourHeatmap is a weekday × hour matrix of orders and preparation times.
+// Cells[hari][jam] starts with Monday and hour 0.
+type PeakHourHeatmap struct {
+	Timezone       string          `json:"timezone"`
+	StartDate      time.Time       `json:"start_date"`
+	EndDate        time.Time       `json:"end_date"`
+	Hari           []string        `json:"hari"`
+	Cells          [][]HeatmapCell `json:"cells"`
+	TotalOrders    int             `json:"total_orders"`
+	AvgWaitMinutes float64         `json:"avg_wait_minutes"`
+	AvgPrepMinutes float64         `json:"avg_prep_minutes"`
+	PeakHari       string          `json:"peak_hari"`
+	PeakJam        int             `json:"peak_jam"`
+	PeakOrders     int             `json:"peak_orders"`
+}
+
+// heatmapSums accumulates a cell before its averages are taken
+type heatmapSums struct {
+	waitSeconds float64
+	waitCount   int
+	prepSeconds float64
+	prepCount   int
+}
+
+// GetPeakHours builds the weekday × hour matrix of orders with the average waiting and
+// preparation time, taken from the transactions' status history. stanID 0 covers all stans.
+func (s *AnalyticsService) GetPeakHours(stanID uint, startDate, endDate time.Time) (*PeakHourHeatmap, error) {
+	loc := utils.SchoolLocation()
+	heatmap := &PeakHourHeatmap{
+		Timezone:  loc.String(),
+		StartDate: startDate,
+		EndDate:   endDate,
+		Hari:      hariNames,
+		Cells:     make([][]HeatmapCell, len(hariNames)),
+	}
+	for i := range heatmap.Cells {
+		heatmap.Cells[i] = make([]HeatmapCell, 24)
+	}
+
+	// First time each order started cooking and was sent out
+	history := s.db.Table("transaksi_status_logs").
+		Select("id_transaksi, "+
+			"MIN(created_at) FILTER (WHERE status = ?) AS dimasak_at, "+
+			"MIN(created_at) FILTER (WHERE status = ?) AS diantar_at",
+			models.StatusDimasak, models.StatusDiantar).
+		Group("id_transaksi")
+
+	// Aggregate by hour in SQL and roll the hours up into school-time weekdays in Go.
+	// Order times are stored in UTC, see database.Connect.
+	var hours []struct {
+		Jam         time.Time
+		Orders      int
+		WaitSeconds float64
+		WaitCount   int
+		PrepSeconds float64
+		PrepCount   int
+	}
+	query := s.db.Table("transaksis").
+		Select("date_trunc('hour', transaksis.tanggal) AS jam, COUNT(*) AS orders, "+
+			"COALESCE(SUM(EXTRACT(EPOCH FROM history.dimasak_at - transaksis.tanggal)), 0) AS wait_seconds, "+
+			"COUNT(history.dimasak_at) AS wait_count, "+
+			"COALESCE(SUM(EXTRACT(EPOCH FROM history.diantar_at - history.dimasak_at)), 0) AS prep_seconds, "+
+			"COUNT(history.diantar_at - history.dimasak_at) AS prep_count").
+		Joins("LEFT JOIN (?) AS history ON history.id_transaksi = transaksis.id", history).
+		Where("transaksis.deleted_at IS NULL")
+	if stanID != 0 {
+		query = query.Where("transaksis.id_stan = ?", stanID)
+	}
+	if !startDate.IsZero() && !endDate.IsZero() {
+		query = query.Where("transaksis.tanggal BETWEEN ? AND ?", startDate.UTC(), endDate.UTC())
+	}
+	if err := query.Group("jam").Scan(&hours).Error; err != nil {
+		return nil, err
+	}
+
+	sums := make([][]heatmapSums, len(hariNames))
+	for i := range sums {
+		sums[i] = make([]heatmapSums, 24)
+	}
+	var total heatmapSums
+	for _, hour := range hours {
+		t := hour.Jam.In(loc)
+		hari := (int(t.Weekday()) + 6) % 7 // Monday = 0
+		cell := &heatmap.Cells[hari][t.Hour()]
+		cell.Orders += hour.Orders
+		cell.PrepSamples += hour.PrepCount
+
+		sum := &sums[hari][t.Hour()]
+		sum.waitSeconds += hour.WaitSeconds
+		sum.waitCount += hour.WaitCount
+		sum.prepSeconds += hour.PrepSeconds
+		sum.prepCount += hour.PrepCount
+
+		heatmap.TotalOrders += hour.Orders
+		total.waitSeconds += hour.WaitSeconds
+		total.waitCount += hour.WaitCount
+		total.prepSeconds += hour.PrepSeconds
+		total.prepCount += hour.PrepCount
+	}
+
+	for hari := range heatmap.Cells {
+		for jam := range heatmap.Cells[hari] {
+			cell := &heatmap.Cells[hari][jam]
+			cell.AvgWaitMinutes, cell.AvgPrepMinutes = sums[hari][jam].averages()
+			if cell.Orders > heatmap.PeakOrders {
+				heatmap.PeakHari = hariNames[hari]
+				heatmap.PeakJam = jam
+				heatmap.PeakOrders = cell.Orders
+			}
+		}
+	}
+	heatmap.AvgWaitMinutes, heatmap.AvgPrepMinutes = total.averages()
+
+	return heatmap, nil
+}
+
+// averages returns the average waiting and preparation time in minutes
+func (h heatmapSums) averages() (wait, prep float64) {
+	if h.waitCount > 0 {
+		wait = h.waitSeconds / float64(h.waitCount) / 60
+	}
+	if h.prepCount > 0 {
+		prep = h.prepSeconds / float64(h.prepCount) / 60
+	}
+	return wait, prep
+}
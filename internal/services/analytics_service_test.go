@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketTruncate(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	// Wednesday 2026-10-14 01:30 WIB is still Tuesday in UTC
+	at := time.Date(2026, 10, 14, 1, 30, 45, 0, jakarta)
+	tests := []struct {
+		bucket Bucket
+		want   time.Time
+	}{
+		{BucketHour, time.Date(2026, 10, 14, 1, 0, 0, 0, jakarta)},
+		{BucketDay, time.Date(2026, 10, 14, 0, 0, 0, 0, jakarta)},
+		{BucketWeek, time.Date(2026, 10, 12, 0, 0, 0, 0, jakarta)},
+		{BucketMonth, time.Date(2026, 10, 1, 0, 0, 0, 0, jakarta)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.bucket), func(t *testing.T) {
+			if got := tt.bucket.truncate(at); !got.Equal(tt.want) {
+				t.Fatalf("truncate(%v) = %v, want %v", at, got, tt.want)
+			}
+		})
+	}
+
+	// A Sunday belongs to the week that started the Monday before
+	sunday := time.Date(2026, 10, 18, 23, 0, 0, 0, jakarta)
+	if got, want := BucketWeek.truncate(sunday), time.Date(2026, 10, 12, 0, 0, 0, 0, jakarta); !got.Equal(want) {
+		t.Fatalf("truncate(%v) = %v, want %v", sunday, got, want)
+	}
+}
+
+func TestBucketNext(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	tests := []struct {
+		bucket Bucket
+		start  time.Time
+		want   time.Time
+	}{
+		{BucketHour, time.Date(2026, 10, 14, 23, 0, 0, 0, jakarta), time.Date(2026, 10, 15, 0, 0, 0, 0, jakarta)},
+		{BucketDay, time.Date(2026, 10, 31, 0, 0, 0, 0, jakarta), time.Date(2026, 11, 1, 0, 0, 0, 0, jakarta)},
+		{BucketWeek, time.Date(2026, 12, 28, 0, 0, 0, 0, jakarta), time.Date(2027, 1, 4, 0, 0, 0, 0, jakarta)},
+		{BucketMonth, time.Date(2026, 12, 1, 0, 0, 0, 0, jakarta), time.Date(2027, 1, 1, 0, 0, 0, 0, jakarta)},
+		{BucketMonth, time.Date(2026, 1, 1, 0, 0, 0, 0, jakarta), time.Date(2026, 2, 1, 0, 0, 0, 0, jakarta)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.bucket), func(t *testing.T) {
+			if got := tt.bucket.next(tt.start); !got.Equal(tt.want) {
+				t.Fatalf("next(%v) = %v, want %v", tt.start, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketPreviousStart(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	date := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, jakarta) }
+	tests := []struct {
+		name       string
+		bucket     Bucket
+		start, end time.Time
+		want       time.Time
+	}{
+		{"hours", BucketHour, date(2026, 10, 14, 8), date(2026, 10, 14, 12), date(2026, 10, 14, 4)},
+		{"days across a month", BucketDay, date(2026, 10, 1, 0), date(2026, 10, 8, 0), date(2026, 9, 24, 0)},
+		{"weeks", BucketWeek, date(2026, 10, 12, 0), date(2026, 10, 26, 0), date(2026, 9, 28, 0)},
+		{"one month", BucketMonth, date(2026, 3, 1, 0), date(2026, 4, 1, 0), date(2026, 2, 1, 0)},
+		{"months across a year", BucketMonth, date(2026, 1, 1, 0), date(2026, 4, 1, 0), date(2025, 10, 1, 0)},
+		{"a year of months", BucketMonth, date(2025, 11, 1, 0), date(2026, 11, 1, 0), date(2024, 11, 1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.bucket.previousStart(tt.start, tt.end); !got.Equal(tt.want) {
+				t.Fatalf("previousStart(%v, %v) = %v, want %v", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
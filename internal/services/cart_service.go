@@ -1,38 +1,95 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CartService struct {
 	*BaseService[models.Cart]
-	db *gorm.DB
+	db            *gorm.DB
+	pricing       *PricingService
+	optionService *MenuOptionService
 }
 
 func NewCartService(db *gorm.DB) *CartService {
 	return &CartService{
-		BaseService: NewBaseService[models.Cart](db),
-		db:          db,
+		BaseService:   NewBaseService[models.Cart](db),
+		db:            db,
+		pricing:       NewPricingService(db),
+		optionService: NewMenuOptionService(db),
 	}
 }
 
-// AddToCart adds item to cart or updates quantity if already exists
+// AddToCart adds item to cart or updates quantity if the same menu with the same options and note already exists
 func (s *CartService) AddToCart(cart *models.Cart) error {
-	var existingCart models.Cart
-	err := s.db.Where("id_siswa = ? AND id_menu = ?", cart.IDSiswa, cart.IDMenu).First(&existingCart).Error
+	catatan, err := utils.SanitizeNote(cart.Catatan)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNote, err)
+	}
+	cart.Catatan = catatan
 
-	if err == gorm.ErrRecordNotFound {
-		// Create new cart item
-		return s.db.Create(cart).Error
-	} else if err != nil {
-		return err
+	// id_menu 0 is how bundle items are shown, so it means no menu
+	if cart.IDMenu != nil && *cart.IDMenu == 0 {
+		cart.IDMenu = nil
+	}
+	if (cart.IDMenu == nil) == (cart.IDBundle == nil) {
+		return ErrInvalidCartItem
+	}
+
+	var options []models.MenuOption
+	if cart.IDBundle != nil {
+		// Bundles are sold as a fixed paket without options
+		if len(cart.OptionIDs) > 0 {
+			return fmt.Errorf("%w: bundles do not have options", ErrInvalidOption)
+		}
+		var bundle models.MenuBundle
+		if err := s.db.Preload("Items.Menu.Schedules").First(&bundle, *cart.IDBundle).Error; err != nil {
+			return err
+		}
+		if !bundle.IsAvailable || !bundle.IsScheduledAt(utils.SchoolNow()) {
+			return fmt.Errorf("%w: bundle %s is not available now", ErrMenuUnavailable, bundle.NamaBundle)
+		}
 	} else {
+		options, err = s.optionService.ResolveSelection(*cart.IDMenu, cart.OptionIDs)
+		if err != nil {
+			return err
+		}
+	}
+	cart.OpsiKey = OptionKey(options)
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("id_siswa = ? AND opsi_key = ? AND catatan = ?", cart.IDSiswa, cart.OpsiKey, cart.Catatan)
+		if cart.IDBundle != nil {
+			query = query.Where("id_bundle = ?", *cart.IDBundle)
+		} else {
+			query = query.Where("id_menu = ?", *cart.IDMenu)
+		}
+
+		var existingCart models.Cart
+		err := query.First(&existingCart).Error
+
+		if err == gorm.ErrRecordNotFound {
+			// Create new cart item with its chosen options
+			cart.Options = nil
+			for _, option := range options {
+				cart.Options = append(cart.Options, models.CartOption{IDOption: option.ID})
+			}
+			return tx.Create(cart).Error
+		} else if err != nil {
+			return err
+		}
+
 		// Update existing cart item quantity
 		existingCart.Qty += cart.Qty
-		return s.db.Save(&existingCart).Error
-	}
+		return tx.Save(&existingCart).Error
+	})
 }
 
 // GetCartBySiswaID gets all cart items for a siswa
@@ -40,14 +97,54 @@ func (s *CartService) GetCartBySiswaID(siswaID uint) ([]models.Cart, error) {
 	var carts []models.Cart
 	err := s.db.Where("id_siswa = ?", siswaID).
 		Preload("Menu.Stan").
+		Preload("Bundle.Items.Menu").
+		Preload("Options.Option.Group").
 		Preload("Siswa").
 		Find(&carts).Error
 	return carts, err
 }
 
-// UpdateCartItem updates quantity of a cart item
-func (s *CartService) UpdateCartItem(cartID uint, qty int) error {
-	return s.db.Model(&models.Cart{}).Where("id = ?", cartID).Update("qty", qty).Error
+// UpdateCartItem sets the quantity of a cart item and, when catatan is given, replaces its note.
+// When the new note makes the item equal to another line of the cart (same menu or bundle,
+// options and note), the item is merged into that line, as AddToCart would have done.
+func (s *CartService) UpdateCartItem(cartID uint, qty int, catatan *string) error {
+	if catatan != nil {
+		note, err := utils.SanitizeNote(*catatan)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidNote, err)
+		}
+		catatan = &note
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var cart models.Cart
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cart, cartID).Error; err != nil {
+			return err
+		}
+		if catatan == nil || *catatan == cart.Catatan {
+			return tx.Model(&cart).Update("qty", qty).Error
+		}
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id <> ? AND id_siswa = ? AND opsi_key = ? AND catatan = ?", cart.ID, cart.IDSiswa, cart.OpsiKey, *catatan)
+		if cart.IDBundle != nil {
+			query = query.Where("id_bundle = ?", *cart.IDBundle)
+		} else {
+			query = query.Where("id_menu = ?", *cart.IDMenu)
+		}
+		var existing models.Cart
+		err := query.First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Model(&cart).Updates(map[string]interface{}{"qty": qty, "catatan": *catatan}).Error
+		} else if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&existing).Update("qty", existing.Qty+qty).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&cart).Error
+	})
 }
 
 // RemoveFromCart removes item from cart
@@ -58,7 +155,7 @@ func (s *CartService) RemoveFromCart(cartID uint) error {
 // GetCartByID gets a single cart item by ID
 func (s *CartService) GetCartByID(cartID uint) (*models.Cart, error) {
 	var cart models.Cart
-	err := s.db.Preload("Menu.Stan").Preload("Siswa").First(&cart, cartID).Error
+	err := s.db.Preload("Menu.Stan").Preload("Bundle.Items.Menu").Preload("Options.Option.Group").Preload("Siswa").First(&cart, cartID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -70,45 +167,363 @@ func (s *CartService) ClearCart(siswaID uint) error {
 	return s.db.Where("id_siswa = ?", siswaID).Delete(&models.Cart{}).Error
 }
 
-// GetCartTotal calculates total items and price for a siswa's cart
+// GetCartTotal calculates total items and price for a siswa's cart, applying options and active discounts
 func (s *CartService) GetCartTotal(siswaID uint) (int, float64, error) {
-	var result struct {
-		TotalItems int
-		TotalPrice float64
+	carts, err := s.loadCartForPricing(siswaID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	quotes, err := s.quoteCart(carts, time.Now().UTC())
+	if err != nil {
+		return 0, 0, err
 	}
 
-	err := s.db.Table("carts").
-		Select("COALESCE(SUM(carts.qty), 0) as total_items, COALESCE(SUM(carts.qty * menus.harga), 0) as total_price").
-		Joins("JOIN menus ON carts.id_menu = menus.id").
-		Where("carts.id_siswa = ?", siswaID).
-		Scan(&result).Error
+	totalItems := 0
+	totalPrice := 0.0
+	for i, cart := range carts {
+		totalItems += cart.Qty
+		totalPrice += float64(cart.Qty) * quotes[i].HargaAkhir
+	}
 
-	return result.TotalItems, result.TotalPrice, err
+	return totalItems, totalPrice, nil
 }
 
 // CheckoutCart converts cart items to transaction details and clears cart
 func (s *CartService) CheckoutCart(siswaID uint, stanID uint) ([]models.DetailTransaksi, error) {
-	var carts []models.Cart
-	err := s.db.Where("id_siswa = ?", siswaID).
-		Preload("Menu").
-		Find(&carts).Error
+	details, err := s.PrepareCheckout(siswaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear cart after checkout
+	if err := s.ClearCart(siswaID); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// PrepareCheckout converts cart items to transaction details without clearing the cart.
+// Each line is priced from the menu price plus option deltas, with the best discount active at checkout time.
+func (s *CartService) PrepareCheckout(siswaID uint) ([]models.DetailTransaksi, error) {
+	carts, err := s.loadCartForPricing(siswaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCartSchedules(carts, utils.SchoolNow()); err != nil {
+		return nil, err
+	}
+
+	quotes, err := s.quoteCart(carts, time.Now().UTC())
 	if err != nil {
 		return nil, err
 	}
 
 	var details []models.DetailTransaksi
+	for i, cart := range carts {
+		if cart.Bundle != nil {
+			details = append(details, bundleDetails(cart)...)
+			continue
+		}
+		if cart.Menu == nil {
+			continue
+		}
+
+		options := make([]models.MenuOption, 0, len(cart.Options))
+		for _, cartOption := range cart.Options {
+			options = append(options, cartOption.Option)
+		}
+		details = append(details, pricedDetail(cart.Menu.ID, cart.Qty, cart.Catatan, options, quotes[i]))
+	}
+
+	return details, nil
+}
+
+// pricedDetail builds an order line of a menu at the quoted unit price, with a snapshot of
+// the chosen options (with their group loaded)
+func pricedDetail(menuID uint, qty int, catatan string, options []models.MenuOption, quote PriceQuote) models.DetailTransaksi {
+	detail := models.DetailTransaksi{
+		IDMenu:    menuID,
+		Qty:       qty,
+		HargaBeli: quote.HargaAkhir,
+		HargaAsli: quote.HargaAsli,
+		Catatan:   catatan,
+	}
+	if quote.Diskon != nil {
+		detail.NamaDiskon = quote.Diskon.NamaDiskon
+		detail.IDDiskon = &quote.Diskon.ID
+		detail.Potongan = quote.Potongan * float64(qty)
+	}
+	for _, menuOption := range options {
+		option := models.DetailTransaksiOption{
+			IDOption:      menuOption.ID,
+			NamaOpsi:      menuOption.NamaOpsi,
+			HargaTambahan: menuOption.HargaTambahan,
+		}
+		if menuOption.Group != nil {
+			option.NamaGroup = menuOption.Group.NamaGroup
+		}
+		detail.Options = append(detail.Options, option)
+	}
+	return detail
+}
+
+// checkCartSchedules rejects cart items whose menu (or bundle component) is not scheduled at t
+func checkCartSchedules(carts []models.Cart, t time.Time) error {
 	for _, cart := range carts {
+		if cart.Menu != nil && !cart.Menu.IsScheduledAt(t) {
+			return fmt.Errorf("%w: %s is not available at this time", ErrMenuUnavailable, cart.Menu.NamaMakanan)
+		}
+		if cart.Bundle != nil && !cart.Bundle.IsScheduledAt(t) {
+			return fmt.Errorf("%w: %s is not available at this time", ErrMenuUnavailable, cart.Bundle.NamaBundle)
+		}
+	}
+	return nil
+}
+
+// bundleDetails expands a bundle cart item into one order line per component menu.
+// The bundle price is prorated over the components so stock and revenue are tracked per menu.
+func bundleDetails(cart models.Cart) []models.DetailTransaksi {
+	prices := cart.Bundle.ProratedPrices()
+	details := make([]models.DetailTransaksi, 0, len(cart.Bundle.Items))
+	for i, item := range cart.Bundle.Items {
 		details = append(details, models.DetailTransaksi{
-			IDMenu:    cart.IDMenu,
-			Qty:       cart.Qty,
-			HargaBeli: cart.Menu.Harga,
+			IDMenu:    item.IDMenu,
+			IDBundle:  &cart.Bundle.ID,
+			Qty:       cart.Qty * item.Qty,
+			HargaBeli: prices[i],
+			HargaAsli: prices[i],
+			Catatan:   cart.Catatan,
 		})
 	}
+	return details
+}
 
-	// Clear cart after checkout
-	if err := s.ClearCart(siswaID); err != nil {
+// loadCartForPricing loads a siswa's cart items with the data needed to price them
+func (s *CartService) loadCartForPricing(siswaID uint) ([]models.Cart, error) {
+	var carts []models.Cart
+	err := s.db.Where("id_siswa = ?", siswaID).
+		Preload("Menu.Schedules").
+		Preload("Bundle.Items.Menu.Schedules").
+		Preload("Options.Option.Group").
+		Find(&carts).Error
+	return carts, err
+}
+
+// quoteCart prices each cart line at the menu price plus the chosen option deltas.
+// Bundles are sold at their own price and are not discounted further.
+func (s *CartService) quoteCart(carts []models.Cart, at time.Time) ([]PriceQuote, error) {
+	menus := make([]models.Menu, 0, len(carts))
+	for _, cart := range carts {
+		if cart.Menu != nil {
+			menus = append(menus, *cart.Menu)
+		}
+	}
+
+	diskonByStan, err := s.pricing.getActiveDiskonByStan(menus, at)
+	if err != nil {
 		return nil, err
 	}
 
-	return details, nil
-}
\ No newline at end of file
+	quotes := make([]PriceQuote, len(carts))
+	for i := range carts {
+		switch {
+		case carts[i].Bundle != nil:
+			quotes[i] = PriceQuote{HargaAsli: carts[i].Bundle.Harga, HargaAkhir: carts[i].Bundle.Harga}
+		case carts[i].Menu != nil:
+			hargaDasar := carts[i].Menu.Harga
+			for _, cartOption := range carts[i].Options {
+				hargaDasar += cartOption.Option.HargaTambahan
+			}
+			quotes[i] = QuotePrice(carts[i].Menu, hargaDasar, diskonByStan[carts[i].Menu.IDStan])
+		}
+	}
+	return quotes, nil
+}
+
+// ReorderItem is an order line considered for reorder
+type ReorderItem struct {
+	IDMenu   *uint  `json:"id_menu,omitempty"`
+	IDBundle *uint  `json:"id_bundle,omitempty"`
+	Nama     string `json:"nama"`
+	Qty      int    `json:"qty"`
+	Reason   string `json:"reason,omitempty"` // Alasan item tidak bisa ditambahkan
+}
+
+// ReorderResult reports which lines of a past order were added back to the cart
+type ReorderResult struct {
+	Added       []ReorderItem `json:"added"`
+	Unavailable []ReorderItem `json:"unavailable"`
+}
+
+// Reorder adds the lines of a past transaksi back to the siswa's cart with the same options
+// and notes. Prices are not copied: the cart is priced at checkout as usual. Lines whose menu,
+// bundle or options are gone, unavailable or out of stock are reported instead of added.
+// Stock is checked against everything added so far, so lines of the same menu (on their own
+// or in bundles) cannot add more than is in stock together. The lines are added in one
+// transaction: either all added lines are in the cart or none are.
+func (s *CartService) Reorder(siswaID uint, transaksi *models.Transaksi) (*ReorderResult, error) {
+	result := &ReorderResult{Added: []ReorderItem{}, Unavailable: []ReorderItem{}}
+	now := utils.SchoolNow()
+
+	type bundleKey struct {
+		id      uint
+		catatan string
+	}
+	seenBundles := make(map[bundleKey]bool)
+	reserved := make(map[uint]int) // Qty per menu added by this reorder
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		txService := NewCartService(tx)
+		for _, detail := range transaksi.DetailTransaksi {
+			if detail.IDBundle != nil {
+				// Bundle lines are expanded per component at checkout; add the bundle back once
+				key := bundleKey{*detail.IDBundle, detail.Catatan}
+				if seenBundles[key] {
+					continue
+				}
+				seenBundles[key] = true
+				item, err := txService.reorderBundle(siswaID, *detail.IDBundle, detail, now, reserved)
+				if err != nil {
+					return err
+				}
+				result.add(item)
+				continue
+			}
+
+			item, err := txService.reorderMenu(siswaID, detail, now, reserved)
+			if err != nil {
+				return err
+			}
+			result.add(item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *ReorderResult) add(item ReorderItem) {
+	if item.Reason != "" {
+		r.Unavailable = append(r.Unavailable, item)
+	} else {
+		r.Added = append(r.Added, item)
+	}
+}
+
+// reorderMenu adds a single menu order line back to the cart and adds its qty to reserved
+func (s *CartService) reorderMenu(siswaID uint, detail models.DetailTransaksi, now time.Time, reserved map[uint]int) (ReorderItem, error) {
+	menuID := detail.IDMenu
+	item := ReorderItem{IDMenu: &menuID, Nama: detail.Menu.NamaMakanan, Qty: detail.Qty}
+
+	var menu models.Menu
+	err := s.db.Preload("Schedules").First(&menu, detail.IDMenu).Error
+	if err == gorm.ErrRecordNotFound {
+		item.Reason = "menu is no longer sold"
+		return item, nil
+	} else if err != nil {
+		return item, err
+	}
+	item.Nama = menu.NamaMakanan
+
+	stock := menu.Stock - reserved[menu.ID]
+	switch {
+	case !menu.IsAvailable || stock <= 0:
+		item.Reason = "out of stock"
+		return item, nil
+	case stock < detail.Qty:
+		item.Reason = fmt.Sprintf("only %d left in stock", stock)
+		return item, nil
+	case !menu.IsScheduledAt(now):
+		item.Reason = "not available at this time"
+		return item, nil
+	}
+
+	optionIDs := make([]uint, 0, len(detail.Options))
+	for _, option := range detail.Options {
+		optionIDs = append(optionIDs, option.IDOption)
+	}
+
+	cart := models.Cart{
+		IDSiswa:   siswaID,
+		IDMenu:    &menuID,
+		Qty:       detail.Qty,
+		OptionIDs: optionIDs,
+		Catatan:   detail.Catatan,
+	}
+	if err := s.AddToCart(&cart); err != nil {
+		if errors.Is(err, ErrInvalidOption) || errors.Is(err, ErrInvalidNote) {
+			item.Reason = err.Error()
+			return item, nil
+		}
+		return item, err
+	}
+	reserved[menu.ID] += detail.Qty
+	return item, nil
+}
+
+// reorderBundle adds a bundle back to the cart and adds its component qty to reserved. The
+// bundle quantity is recovered from the component line and the bundle's quantity of that component.
+func (s *CartService) reorderBundle(siswaID uint, bundleID uint, detail models.DetailTransaksi, now time.Time, reserved map[uint]int) (ReorderItem, error) {
+	item := ReorderItem{IDBundle: &bundleID}
+	if detail.Bundle != nil {
+		item.Nama = detail.Bundle.NamaBundle
+	}
+
+	var bundle models.MenuBundle
+	err := s.db.Preload("Items.Menu.Schedules").First(&bundle, bundleID).Error
+	if err == gorm.ErrRecordNotFound {
+		item.Reason = "bundle is no longer sold"
+		return item, nil
+	} else if err != nil {
+		return item, err
+	}
+	item.Nama = bundle.NamaBundle
+
+	item.Qty = 1
+	for _, bundleItem := range bundle.Items {
+		if bundleItem.IDMenu == detail.IDMenu && bundleItem.Qty > 0 {
+			item.Qty = max(detail.Qty/bundleItem.Qty, 1)
+			break
+		}
+	}
+	for i := range bundle.Items {
+		bundle.Items[i].Menu.Stock -= reserved[bundle.Items[i].IDMenu]
+	}
+
+	stock := bundle.DerivedStock()
+	switch {
+	case !bundle.IsAvailable || stock <= 0:
+		item.Reason = "out of stock"
+		return item, nil
+	case stock < item.Qty:
+		item.Reason = fmt.Sprintf("only %d left in stock", stock)
+		return item, nil
+	case !bundle.IsScheduledAt(now):
+		item.Reason = "not available at this time"
+		return item, nil
+	}
+
+	cart := models.Cart{
+		IDSiswa:  siswaID,
+		IDBundle: &bundleID,
+		Qty:      item.Qty,
+		Catatan:  detail.Catatan,
+	}
+	if err := s.AddToCart(&cart); err != nil {
+		if errors.Is(err, ErrInvalidNote) {
+			item.Reason = err.Error()
+			return item, nil
+		}
+		return item, err
+	}
+	for _, bundleItem := range bundle.Items {
+		reserved[bundleItem.IDMenu] += item.Qty * bundleItem.Qty
+	}
+	return item, nil
+}
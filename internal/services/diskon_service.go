@@ -1,10 +1,14 @@
 package services
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"swipeup-be/internal/models"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DiskonService struct {
@@ -17,19 +21,23 @@ func NewDiskonService(db *gorm.DB) *DiskonService {
 	}
 }
 
+// diskonBudgetLeft keeps discounts whose subsidy budget is not spent yet. Checkout rejects a
+// spent discount, so one that was turned on again or had its budget lowered must not be offered.
+const diskonBudgetLeft = "(anggaran_subsidi IS NULL OR total_subsidi < anggaran_subsidi)"
+
 func (s *DiskonService) GetActiveDiskon() ([]models.Diskon, error) {
 	var diskon []models.Diskon
-	now := time.Now()
-	err := s.GetDB().Where("tanggal_awal <= ? AND tanggal_akhir >= ?", now, now).Preload("Stan").Find(&diskon).Error
+	now := time.Now().UTC()
+	err := s.GetDB().Where("tanggal_awal <= ? AND tanggal_akhir >= ? AND is_active = ?", now, now, true).Where(diskonBudgetLeft).Preload("Stan").Find(&diskon).Error
 	return diskon, err
 }
 
 func (s *DiskonService) GetActiveDiskonByStan(stanID uint) ([]models.Diskon, error) {
 	var diskon []models.Diskon
-	now := time.Now()
+	now := time.Now().UTC()
 	// Get global discounts (id_stan is NULL) OR discounts for specific stan
-	err := s.GetDB().Where("tanggal_awal <= ? AND tanggal_akhir >= ? AND (id_stan IS NULL OR id_stan = ?)", 
-		now, now, stanID).Preload("Stan").Find(&diskon).Error
+	err := s.GetDB().Where("tanggal_awal <= ? AND tanggal_akhir >= ? AND (id_stan IS NULL OR id_stan = ?) AND is_active = ?", 
+		now, now, stanID, true).Where(diskonBudgetLeft).Preload("Stan").Find(&diskon).Error
 	return diskon, err
 }
 
@@ -46,9 +54,61 @@ func (s *DiskonService) GetGlobalDiskon() ([]models.Diskon, error) {
 }
 
 func (s *DiskonService) UpdateFields(id uint, updates map[string]interface{}) error {
+	if err := s.validateTargetUpdates(id, updates); err != nil {
+		return err
+	}
 	return s.GetDB().Model(&models.Diskon{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// validateTargetUpdates checks target_jenis and target_tag of an update and normalizes target_tag.
+// They are only accepted for kategori discounts, target_jenis must be makanan or minuman and
+// target_tag must be a single tag. A kategori discount must keep at least one target after the
+// update, otherwise it would apply to every menu.
+func (s *DiskonService) validateTargetUpdates(id uint, updates map[string]interface{}) error {
+	targetJenis, hasJenis := updates["target_jenis"]
+	targetTag, hasTag := updates["target_tag"]
+	_, hasTipe := updates["tipe_diskon"]
+	if !hasJenis && !hasTag && !hasTipe {
+		return nil
+	}
+
+	var diskon models.Diskon
+	if err := s.GetDB().Select("tipe_diskon", "target_jenis", "target_tag").First(&diskon, id).Error; err != nil {
+		return err
+	}
+	tipe, ok := updates["tipe_diskon"].(string)
+	if !ok {
+		tipe = string(diskon.TipeDiskon)
+	}
+	if models.TipeDiskon(tipe) != models.DiskonKategori {
+		if hasJenis || hasTag {
+			return fmt.Errorf("%w: target_jenis and target_tag are only allowed for kategori discounts", ErrInvalidDiskonTarget)
+		}
+		return nil
+	}
+
+	jenisSet := diskon.TargetJenis != nil
+	if hasJenis {
+		if jenis, _ := targetJenis.(string); jenis != string(models.JenisMakanan) && jenis != string(models.JenisMinuman) {
+			return fmt.Errorf("%w: target_jenis must be makanan or minuman", ErrInvalidDiskonTarget)
+		}
+		jenisSet = true
+	}
+	tag := diskon.TargetTag
+	if hasTag {
+		tag, _ = targetTag.(string)
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("%w: target_tag must be a single tag", ErrInvalidDiskonTarget)
+		}
+		tag = models.NormalizeTags(tag)
+		updates["target_tag"] = tag
+	}
+	if !jenisSet && tag == "" {
+		return fmt.Errorf("%w: target_jenis or target_tag is required for kategori discount", ErrInvalidDiskonTarget)
+	}
+	return nil
+}
+
 func (s *DiskonService) GetByDateRange(startDate, endDate time.Time) ([]models.Diskon, error) {
 	var diskon []models.Diskon
 	err := s.GetDB().Where("(tanggal_awal BETWEEN ? AND ?) OR (tanggal_akhir BETWEEN ? AND ?)", 
@@ -67,3 +127,42 @@ func (s *DiskonService) AssignToMenu(diskonID, menuID uint) error {
 func (s *DiskonService) RemoveFromMenu(diskonID, menuID uint) error {
 	return s.GetDB().Where("id_menu = ? AND id_diskon = ?", menuID, diskonID).Delete(&models.MenuDiskon{}).Error
 }
+
+// recordDiskonUsage adds the subsidy of each applied discount to its running total and
+// deactivates discounts whose budget cap has been spent. It must run inside the checkout transaction.
+// Each discount row is locked first, so concurrent checkouts cannot both spend the last of a budget;
+// a checkout that finds the discount already deactivated or spent fails with ErrDiskonBudgetExhausted.
+func recordDiskonUsage(tx *gorm.DB, details []models.DetailTransaksi) error {
+	subsidi := make(map[uint]float64)
+	var diskonIDs []uint
+	for _, detail := range details {
+		if detail.IDDiskon != nil && detail.Potongan > 0 {
+			if _, ok := subsidi[*detail.IDDiskon]; !ok {
+				diskonIDs = append(diskonIDs, *detail.IDDiskon)
+			}
+			subsidi[*detail.IDDiskon] += detail.Potongan
+		}
+	}
+	// Lock in ID order so checkouts sharing discounts cannot deadlock
+	sort.Slice(diskonIDs, func(i, j int) bool { return diskonIDs[i] < diskonIDs[j] })
+
+	for _, diskonID := range diskonIDs {
+		var diskon models.Diskon
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&diskon, diskonID).Error; err != nil {
+			return err
+		}
+		if !diskon.IsActive || (diskon.AnggaranSubsidi != nil && diskon.TotalSubsidi >= *diskon.AnggaranSubsidi) {
+			return ErrDiskonBudgetExhausted
+		}
+
+		updates := map[string]interface{}{"total_subsidi": diskon.TotalSubsidi + subsidi[diskonID]}
+		if diskon.AnggaranSubsidi != nil && diskon.TotalSubsidi+subsidi[diskonID] >= *diskon.AnggaranSubsidi {
+			updates["is_active"] = false
+		}
+		if err := tx.Model(&models.Diskon{}).Where("id = ?", diskonID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"swipeup-be/internal/models"
+	"testing"
+	"time"
+)
+
+func TestUpdateFieldsKeepsKategoriTarget(t *testing.T) {
+	db := openTestDB(t, &models.Diskon{})
+
+	now := time.Now().UTC()
+	stanDiskon := models.Diskon{NamaDiskon: "Stan", PersentaseDiskon: 10, TipeDiskon: models.DiskonStan,
+		TanggalAwal: now, TanggalAkhir: now.Add(time.Hour), IsActive: true}
+	jenis := models.JenisMinuman
+	kategori := models.Diskon{NamaDiskon: "Minuman", PersentaseDiskon: 10, TipeDiskon: models.DiskonKategori,
+		TanggalAwal: now, TanggalAkhir: now.Add(time.Hour), TargetJenis: &jenis, IsActive: true}
+	tagged := models.Diskon{NamaDiskon: "Pedas", PersentaseDiskon: 10, TipeDiskon: models.DiskonKategori,
+		TanggalAwal: now, TanggalAkhir: now.Add(time.Hour), TargetTag: "pedas", IsActive: true}
+	if err := db.Create(&[]*models.Diskon{&stanDiskon, &kategori, &tagged}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewDiskonService(db)
+	tests := []struct {
+		name    string
+		id      uint
+		updates map[string]interface{}
+		wantErr bool
+	}{
+		{"switch to kategori without target", stanDiskon.ID, map[string]interface{}{"tipe_diskon": "kategori"}, true},
+		{"clear the only tag", tagged.ID, map[string]interface{}{"target_tag": ""}, true},
+		{"switch to kategori with target", stanDiskon.ID, map[string]interface{}{"tipe_diskon": "kategori", "target_tag": "Manis"}, false},
+		{"clear tag while jenis is kept", kategori.ID, map[string]interface{}{"target_tag": ""}, false},
+	}
+	for _, tt := range tests {
+		err := service.UpdateFields(tt.id, tt.updates)
+		if tt.wantErr && !errors.Is(err, ErrInvalidDiskonTarget) {
+			t.Errorf("%s: err = %v, want ErrInvalidDiskonTarget", tt.name, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
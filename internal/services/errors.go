@@ -0,0 +1,45 @@
+package services
+
+import "errors"
+
+var (
+	// ErrInvalidOption is returned when chosen options do not satisfy the menu's option groups
+	ErrInvalidOption = errors.New("invalid menu option")
+	// ErrInsufficientStock is returned when an order needs more stock than is available
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidNote is returned when an order note is too long or contains a blocked word
+	ErrInvalidNote = errors.New("invalid note")
+	// ErrInvalidCartItem is returned when a cart item names neither or both of a menu and a bundle
+	ErrInvalidCartItem = errors.New("cart item must have either id_menu or id_bundle")
+	// ErrInvalidOrderItem is returned when an order line has no quantity or is not a menu of the order's stan
+	ErrInvalidOrderItem = errors.New("invalid order item")
+	// ErrAlergenConflict is returned when checkout is blocked by the student's allergy profile
+	ErrAlergenConflict = errors.New("cart contains items conflicting with allergies")
+	// ErrMenuUnavailable is returned when a menu is ordered outside its availability schedule
+	ErrMenuUnavailable = errors.New("menu unavailable")
+	// ErrInvalidPriceChange is returned when a scheduled price change is invalid or can no longer be changed
+	ErrInvalidPriceChange = errors.New("invalid price change")
+	// ErrReviewNotAllowed is returned when an order cannot be reviewed (not arrived yet or already reviewed)
+	ErrReviewNotAllowed = errors.New("review not allowed")
+	// ErrInvalidReview is returned when a rating is out of range or a comment is rejected
+	ErrInvalidReview = errors.New("invalid review")
+	// ErrInvalidStockChange is returned when a manual stock change does not match its reason
+	ErrInvalidStockChange = errors.New("invalid stock change")
+	// ErrInvalidStockReset is returned when a day's stock reset was already applied or cannot be skipped
+	ErrInvalidStockReset = errors.New("invalid stock reset")
+	// ErrInvalidIngredient is returned when an ingredient, recipe or ingredient purchase is invalid
+	ErrInvalidIngredient = errors.New("invalid ingredient")
+	// ErrInvalidStockOpname is returned when stocktake counts are incomplete or the opname was already reviewed
+	ErrInvalidStockOpname = errors.New("invalid stock opname")
+	// ErrInvalidWaste is returned when a waste entry has an unknown reason or more than is in stock
+	ErrInvalidWaste = errors.New("invalid waste entry")
+	// ErrInvalidPeriod is returned when a report period or bucket size is invalid
+	ErrInvalidPeriod = errors.New("invalid report period")
+	// ErrInvalidReportSort is returned when a report is sorted by an unknown metric
+	ErrInvalidReportSort = errors.New("invalid report sort")
+	// ErrDiskonBudgetExhausted is returned at checkout when a discount's subsidy budget was spent meanwhile
+	ErrDiskonBudgetExhausted = errors.New("discount budget exhausted")
+	// ErrInvalidDiskonTarget is returned when a discount update sets target_jenis or target_tag invalidly
+	// or leaves a kategori discount without a target
+	ErrInvalidDiskonTarget = errors.New("invalid discount target")
+)
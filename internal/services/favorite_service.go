@@ -0,0 +1,70 @@
+package services
+
+import (
+	"swipeup-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type FavoriteService struct {
+	db      *gorm.DB
+	pricing *PricingService
+}
+
+func NewFavoriteService(db *gorm.DB) *FavoriteService {
+	return &FavoriteService{
+		db:      db,
+		pricing: NewPricingService(db),
+	}
+}
+
+// GetBySiswaID retrieves a siswa's favorite menus with current promo prices, newest first.
+// Favorites of deleted menus are left out.
+func (s *FavoriteService) GetBySiswaID(siswaID uint) ([]models.Favorite, error) {
+	var favorites []models.Favorite
+	err := s.db.Joins("JOIN menus ON menus.id = favorites.id_menu AND menus.deleted_at IS NULL").
+		Preload("Menu.Stan").
+		Where("favorites.id_siswa = ?", siswaID).
+		Order("favorites.created_at DESC").
+		Find(&favorites).Error
+	if err != nil {
+		return nil, err
+	}
+
+	menus := make([]models.Menu, len(favorites))
+	for i := range favorites {
+		menus[i] = *favorites[i].Menu
+	}
+	if err := s.pricing.AttachPromo(menus); err != nil {
+		return nil, err
+	}
+	for i := range favorites {
+		favorites[i].Menu = &menus[i]
+	}
+	return favorites, nil
+}
+
+// Add saves a menu as favorite; adding an existing favorite is a no-op
+func (s *FavoriteService) Add(siswaID uint, menuID uint) (*models.Favorite, error) {
+	if err := s.db.First(&models.Menu{}, menuID).Error; err != nil {
+		return nil, err
+	}
+
+	favorite := models.Favorite{IDSiswa: siswaID, IDMenu: menuID}
+	if err := s.db.Where(&favorite).FirstOrCreate(&favorite).Error; err != nil {
+		return nil, err
+	}
+	return &favorite, nil
+}
+
+// Remove deletes a menu from a siswa's favorites
+func (s *FavoriteService) Remove(siswaID uint, menuID uint) error {
+	result := s.db.Where("id_siswa = ? AND id_menu = ?", siswaID, menuID).Delete(&models.Favorite{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
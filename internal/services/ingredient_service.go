@@ -0,0 +1,422 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"swipeup-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recipeStockNote is the ledger note for menu stock changes derived from ingredient stock
+const recipeStockNote = "stok bahan"
+
+// recipeCorrection records menu stock re-derived from ingredients outside of a sale
+var recipeCorrection = StockChange{Reason: models.StockCorrection, Keterangan: recipeStockNote}
+
+type IngredientService struct {
+	db *gorm.DB
+}
+
+func NewIngredientService(db *gorm.DB) *IngredientService {
+	return &IngredientService{db: db}
+}
+
+// GetByStanID retrieves the stan's ingredients ordered by name
+func (s *IngredientService) GetByStanID(stanID uint) ([]models.Ingredient, error) {
+	var ingredients []models.Ingredient
+	err := s.db.Where("id_stan = ?", stanID).Order("nama, id").Find(&ingredients).Error
+	return ingredients, err
+}
+
+// GetByID retrieves an ingredient
+func (s *IngredientService) GetByID(id uint) (*models.Ingredient, error) {
+	var ingredient models.Ingredient
+	if err := s.db.First(&ingredient, id).Error; err != nil {
+		return nil, err
+	}
+	return &ingredient, nil
+}
+
+// Create adds an ingredient with zero stock; stock is added through purchases
+func (s *IngredientService) Create(ingredient *models.Ingredient) error {
+	if err := validateIngredient(ingredient.Nama, ingredient.Satuan); err != nil {
+		return err
+	}
+	ingredient.Stock = 0
+	return s.db.Create(ingredient).Error
+}
+
+// Update changes the name and unit of an ingredient
+func (s *IngredientService) Update(id uint, nama, satuan string) error {
+	if err := validateIngredient(nama, satuan); err != nil {
+		return err
+	}
+	return s.db.Model(&models.Ingredient{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"nama":   strings.TrimSpace(nama),
+		"satuan": strings.TrimSpace(satuan),
+	}).Error
+}
+
+// Delete removes an ingredient that is not used in any recipe
+func (s *IngredientService) Delete(id uint) error {
+	var used int64
+	if err := s.db.Model(&models.RecipeItem{}).Where("id_ingredient = ?", id).Count(&used).Error; err != nil {
+		return err
+	}
+	if used > 0 {
+		return fmt.Errorf("%w: ingredient is used in %d recipe(s)", ErrInvalidIngredient, used)
+	}
+	return s.db.Delete(&models.Ingredient{}, id).Error
+}
+
+// validateIngredient checks the name and unit of an ingredient
+func validateIngredient(nama, satuan string) error {
+	if strings.TrimSpace(nama) == "" || len(nama) > 100 {
+		return fmt.Errorf("%w: nama is required and must be at most 100 characters", ErrInvalidIngredient)
+	}
+	if strings.TrimSpace(satuan) == "" || len(satuan) > 20 {
+		return fmt.Errorf("%w: satuan is required and must be at most 20 characters", ErrInvalidIngredient)
+	}
+	return nil
+}
+
+// GetRecipe retrieves the recipe of a menu with its ingredients
+func (s *IngredientService) GetRecipe(menuID uint) ([]models.RecipeItem, error) {
+	var items []models.RecipeItem
+	err := s.db.Preload("Ingredient").Where("id_menu = ?", menuID).Order("id").Find(&items).Error
+	return items, err
+}
+
+// HasRecipe reports whether a menu's stock is derived from a recipe
+func (s *IngredientService) HasRecipe(menuID uint) (bool, error) {
+	return hasRecipe(s.db, menuID)
+}
+
+// hasRecipe reports whether a menu's stock follows the ingredients of its recipe
+func hasRecipe(db *gorm.DB, menuID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.RecipeItem{}).Where("id_menu = ?", menuID).Count(&count).Error
+	return count > 0, err
+}
+
+// recipeMenuIDs returns which of the menus have a recipe
+func recipeMenuIDs(db *gorm.DB, menuIDs []uint) (map[uint]bool, error) {
+	result := make(map[uint]bool)
+	if len(menuIDs) == 0 {
+		return result, nil
+	}
+	var ids []uint
+	if err := db.Model(&models.RecipeItem{}).Where("id_menu IN ?", menuIDs).Distinct("id_menu").Pluck("id_menu", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// SetRecipe replaces the recipe of a menu. Ingredients must belong to the menu's stan.
+// With a recipe the menu's stock is derived from ingredient stock; an empty recipe
+// leaves the current stock to be managed by hand again.
+func (s *IngredientService) SetRecipe(menu *models.Menu, items []models.RecipeItem) error {
+	seen := make(map[uint]bool, len(items))
+	ids := make([]uint, 0, len(items))
+	for i := range items {
+		if items[i].Qty <= 0 {
+			return fmt.Errorf("%w: qty must be greater than zero", ErrInvalidIngredient)
+		}
+		if seen[items[i].IDIngredient] {
+			return fmt.Errorf("%w: ingredient %d is listed more than once", ErrInvalidIngredient, items[i].IDIngredient)
+		}
+		seen[items[i].IDIngredient] = true
+		ids = append(ids, items[i].IDIngredient)
+		items[i].ID = 0
+		items[i].IDMenu = menu.ID
+	}
+
+	if len(ids) > 0 {
+		var found int64
+		if err := s.db.Model(&models.Ingredient{}).Where("id IN ? AND id_stan = ?", ids, menu.IDStan).Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(ids) {
+			return fmt.Errorf("%w: ingredient not found in this stan", ErrInvalidIngredient)
+		}
+	}
+
+	var alerts []*StockAlert
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_menu = ?", menu.ID).Delete(&models.RecipeItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		var err error
+		alerts, err = syncRecipeStock(tx, ids, recipeCorrection)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	dispatchStockAlerts(alerts...)
+	return nil
+}
+
+// Purchase records a purchase of an ingredient, adds it to the ingredient's stock and
+// updates the stock of the menus that use it
+func (s *IngredientService) Purchase(purchase *models.IngredientPurchase) error {
+	if purchase.Qty <= 0 {
+		return fmt.Errorf("%w: qty must be greater than zero", ErrInvalidIngredient)
+	}
+	if purchase.HargaTotal < 0 {
+		return fmt.Errorf("%w: harga_total must not be negative", ErrInvalidIngredient)
+	}
+
+	var alerts []*StockAlert
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Ingredient{}).Where("id = ?", purchase.IDIngredient).
+			Update("stock", gorm.Expr("stock + ?", purchase.Qty))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(purchase).Error; err != nil {
+			return err
+		}
+		var err error
+		alerts, err = syncRecipeStock(tx, []uint{purchase.IDIngredient}, recipeCorrection)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	dispatchStockAlerts(alerts...)
+	return nil
+}
+
+// correctIngredientStock changes an ingredient's stock by delta, never below zero, and
+// records the applied difference. Deleted ingredients are skipped.
+func correctIngredientStock(tx *gorm.DB, ingredientID uint, delta float64, change StockChange) error {
+	var ingredient models.Ingredient
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ingredient, ingredientID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	current := ingredient.Stock
+	stock := math.Max(current+delta, 0)
+	if stock == current {
+		return nil
+	}
+	if err := tx.Model(&ingredient).Update("stock", stock).Error; err != nil {
+		return err
+	}
+	movement := models.IngredientMovement{
+		IDIngredient: ingredientID,
+		Delta:        stock - current,
+		StockAfter:   stock,
+		Reason:       change.Reason,
+		Keterangan:   change.Keterangan,
+	}
+	if change.IDUser != 0 {
+		movement.IDUser = &change.IDUser
+	}
+	return tx.Create(&movement).Error
+}
+
+// GetPurchases retrieves a page of the purchases of a stan's ingredients, newest first.
+// With ingredientID set, only purchases of that ingredient are returned.
+func (s *IngredientService) GetPurchases(stanID uint, ingredientID uint, limit, offset int) ([]models.IngredientPurchase, int64, error) {
+	query := s.db.Model(&models.IngredientPurchase{}).
+		Where("id_ingredient IN (?)", s.db.Model(&models.Ingredient{}).Unscoped().Select("id").Where("id_stan = ?", stanID))
+	if ingredientID != 0 {
+		query = query.Where("id_ingredient = ?", ingredientID)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var purchases []models.IngredientPurchase
+	err := query.Preload("Ingredient", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&purchases).Error
+	return purchases, total, err
+}
+
+// ingredientUnitCosts returns the average purchase price per unit of the given ingredients.
+// Ingredients that were never bought with a price are left out.
+func ingredientUnitCosts(db *gorm.DB, ingredientIDs []uint) (map[uint]float64, error) {
+	costs := make(map[uint]float64, len(ingredientIDs))
+	if len(ingredientIDs) == 0 {
+		return costs, nil
+	}
+
+	var rows []struct {
+		IDIngredient uint
+		HargaSatuan  float64
+	}
+	err := db.Model(&models.IngredientPurchase{}).
+		Select("id_ingredient, SUM(harga_total) / SUM(qty) AS harga_satuan").
+		Where("id_ingredient IN ? AND harga_total > 0", ingredientIDs).
+		Group("id_ingredient").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		costs[row.IDIngredient] = row.HargaSatuan
+	}
+	return costs, nil
+}
+
+// recipeQuantities sums the ingredients used by the menus (menu ID -> portions). The
+// ingredient IDs are returned sorted, so rows are always locked in the same order and
+// concurrent orders cannot deadlock.
+func recipeQuantities(tx *gorm.DB, menuQty map[uint]int) (map[uint]float64, []uint, error) {
+	menuIDs := make([]uint, 0, len(menuQty))
+	for menuID := range menuQty {
+		menuIDs = append(menuIDs, menuID)
+	}
+
+	var recipe []models.RecipeItem
+	if err := tx.Where("id_menu IN ?", menuIDs).Find(&recipe).Error; err != nil {
+		return nil, nil, err
+	}
+
+	quantities := make(map[uint]float64)
+	var ids []uint
+	for _, item := range recipe {
+		if _, ok := quantities[item.IDIngredient]; !ok {
+			ids = append(ids, item.IDIngredient)
+		}
+		quantities[item.IDIngredient] += item.Qty * float64(menuQty[item.IDMenu])
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return quantities, ids, nil
+}
+
+// deductIngredients subtracts the ingredients used by the ordered menus (menu ID -> portions).
+// Each update is conditional so concurrent checkouts cannot drive ingredient stock below zero.
+// It returns the IDs of the ingredients that were used.
+func deductIngredients(tx *gorm.DB, menuQty map[uint]int) ([]uint, error) {
+	if len(menuQty) == 0 {
+		return nil, nil
+	}
+	needed, ids, err := recipeQuantities(tx, menuQty)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ingredientID := range ids {
+		qty := needed[ingredientID]
+		result := tx.Model(&models.Ingredient{}).
+			Where("id = ? AND stock >= ?", ingredientID, qty).
+			Update("stock", gorm.Expr("stock - ?", qty))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, fmt.Errorf("%w: ingredient %d", ErrInsufficientStock, ingredientID)
+		}
+	}
+	return ids, nil
+}
+
+// restoreIngredients gives back the ingredients used by the menus (menu ID -> portions),
+// e.g. when a transaction is deleted. It returns the IDs of the ingredients given back.
+func restoreIngredients(tx *gorm.DB, menuQty map[uint]int) ([]uint, error) {
+	if len(menuQty) == 0 {
+		return nil, nil
+	}
+	used, ids, err := recipeQuantities(tx, menuQty)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ingredientID := range ids {
+		err := tx.Model(&models.Ingredient{}).Where("id = ?", ingredientID).
+			Update("stock", gorm.Expr("stock + ?", used[ingredientID])).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// syncRecipeStock sets the stock of every menu that uses one of the ingredients to the
+// number of portions its recipe can make from current ingredient stock, recording the
+// difference in the ledger as the given change
+func syncRecipeStock(tx *gorm.DB, ingredientIDs []uint, change StockChange) ([]*StockAlert, error) {
+	if len(ingredientIDs) == 0 {
+		return nil, nil
+	}
+
+	var items []recipeStock
+	err := tx.Raw(`
+		SELECT r.id_menu, r.qty, COALESCE(i.stock, 0) AS stock
+		FROM recipe_items r
+		JOIN menus m ON m.id = r.id_menu AND m.deleted_at IS NULL
+		LEFT JOIN ingredients i ON i.id = r.id_ingredient AND i.deleted_at IS NULL
+		WHERE r.id_menu IN (SELECT id_menu FROM recipe_items WHERE id_ingredient IN ?)
+		ORDER BY r.id_menu`, ingredientIDs).Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	portions := recipePortions(items)
+	menuIDs := make([]uint, 0, len(portions))
+	for menuID := range portions {
+		menuIDs = append(menuIDs, menuID)
+	}
+	// Menus are locked in ID order so concurrent syncs do not deadlock
+	sort.Slice(menuIDs, func(i, j int) bool { return menuIDs[i] < menuIDs[j] })
+
+	var alerts []*StockAlert
+	for _, menuID := range menuIDs {
+		alert, err := setStock(tx, menuID, portions[menuID], change)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// recipeStock is one recipe line with the current stock of its ingredient
+type recipeStock struct {
+	IDMenu uint
+	Qty    float64
+	Stock  float64
+}
+
+// recipePortions returns per menu how many whole portions its recipe can make: the
+// smallest stock / qty over its ingredients, never below zero
+func recipePortions(items []recipeStock) map[uint]int {
+	portions := make(map[uint]int)
+	for _, item := range items {
+		porsi := 0
+		if item.Qty > 0 && item.Stock > 0 {
+			porsi = int(math.Floor(item.Stock / item.Qty))
+		}
+		if current, ok := portions[item.IDMenu]; !ok || porsi < current {
+			portions[item.IDMenu] = porsi
+		}
+	}
+	return portions
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecipePortions(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []recipeStock
+		want  map[uint]int
+	}{
+		{
+			name:  "single ingredient",
+			items: []recipeStock{{IDMenu: 1, Qty: 0.2, Stock: 1}},
+			want:  map[uint]int{1: 5},
+		},
+		{
+			name:  "rounds down to whole portions",
+			items: []recipeStock{{IDMenu: 1, Qty: 150, Stock: 1000}},
+			want:  map[uint]int{1: 6},
+		},
+		{
+			name: "scarcest ingredient decides",
+			items: []recipeStock{
+				{IDMenu: 1, Qty: 100, Stock: 1000},
+				{IDMenu: 1, Qty: 2, Stock: 7},
+			},
+			want: map[uint]int{1: 3},
+		},
+		{
+			name: "missing ingredient makes nothing",
+			items: []recipeStock{
+				{IDMenu: 1, Qty: 1, Stock: 10},
+				{IDMenu: 1, Qty: 1, Stock: 0},
+			},
+			want: map[uint]int{1: 0},
+		},
+		{
+			name:  "negative stock is zero",
+			items: []recipeStock{{IDMenu: 1, Qty: 1, Stock: -2}},
+			want:  map[uint]int{1: 0},
+		},
+		{
+			name: "menus are counted separately",
+			items: []recipeStock{
+				{IDMenu: 1, Qty: 1, Stock: 4},
+				{IDMenu: 2, Qty: 2, Stock: 4},
+			},
+			want: map[uint]int{1: 4, 2: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recipePortions(tt.items); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("recipePortions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type MenuBundleService struct {
+	*BaseService[models.MenuBundle]
+}
+
+func NewMenuBundleService(db *gorm.DB) *MenuBundleService {
+	return &MenuBundleService{
+		BaseService: NewBaseService[models.MenuBundle](db),
+	}
+}
+
+// GetByStanID retrieves all bundles of a stan with their component menus and derived stock
+func (s *MenuBundleService) GetByStanID(stanID uint) ([]models.MenuBundle, error) {
+	var bundles []models.MenuBundle
+	if err := s.GetDB().Preload("Items.Menu.Schedules").Where("id_stan = ?", stanID).Find(&bundles).Error; err != nil {
+		return nil, err
+	}
+	for i := range bundles {
+		bundles[i].Stock = bundles[i].DerivedStock()
+	}
+	return bundles, nil
+}
+
+// GetAvailableByStanID retrieves bundles that can currently be made from component stock
+// and whose components are all scheduled right now
+func (s *MenuBundleService) GetAvailableByStanID(stanID uint) ([]models.MenuBundle, error) {
+	bundles, err := s.GetByStanID(stanID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := utils.SchoolNow()
+	available := make([]models.MenuBundle, 0, len(bundles))
+	for _, bundle := range bundles {
+		if bundle.IsAvailable && bundle.Stock > 0 && bundle.IsScheduledAt(now) {
+			available = append(available, bundle)
+		}
+	}
+	return available, nil
+}
+
+// GetWithItems retrieves a bundle with its component menus and derived stock
+func (s *MenuBundleService) GetWithItems(id uint) (*models.MenuBundle, error) {
+	bundle, err := s.FindByID(id, "Items.Menu")
+	if err != nil {
+		return nil, err
+	}
+	bundle.Stock = bundle.DerivedStock()
+	return bundle, nil
+}
+
+// UpdateFields updates fields of a bundle
+func (s *MenuBundleService) UpdateFields(id uint, updates map[string]interface{}) error {
+	return s.GetDB().Model(&models.MenuBundle{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ReplaceItems replaces the component menus of a bundle
+func (s *MenuBundleService) ReplaceItems(id uint, items []models.MenuBundleItem) error {
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_bundle = ?", id).Delete(&models.MenuBundleItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].ID = 0
+			items[i].IDBundle = id
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+// DeleteBundle deletes a bundle and its items
+func (s *MenuBundleService) DeleteBundle(id uint) error {
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_bundle = ?", id).Delete(&models.MenuBundleItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.MenuBundle{}, id).Error
+	})
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// menuImportColumns are the spreadsheet columns for menu import and export, in export order.
+// id is optional on import; rows with an id (or a nama_makanan already in the stan) update that menu.
+var menuImportColumns = []string{"id", "nama_makanan", "harga", "jenis", "stock", "deskripsi", "foto"}
+
+// MenuImportRow is a parsed spreadsheet row
+type MenuImportRow struct {
+	Row         int              `json:"row"`
+	ID          uint             `json:"id,omitempty"`
+	NamaMakanan string           `json:"nama_makanan"`
+	Harga       float64          `json:"harga"`
+	Jenis       models.JenisMenu `json:"jenis"`
+	Stock       int              `json:"stock"`
+	Deskripsi   string           `json:"deskripsi"`
+	Foto        string           `json:"-"`
+	Action      string           `json:"action"` // "create" atau "update"
+}
+
+// MenuImportError is a validation error for a single row (row 1 is the header)
+type MenuImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// MenuImportResult reports what an import did, or would do in dry-run mode
+type MenuImportResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Applied bool              `json:"applied"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Rows    []MenuImportRow   `json:"rows"`
+	Errors  []MenuImportError `json:"errors,omitempty"`
+}
+
+type MenuImportService struct {
+	db *gorm.DB
+}
+
+func NewMenuImportService(db *gorm.DB) *MenuImportService {
+	return &MenuImportService{db: db}
+}
+
+// ParseMenuRows converts spreadsheet records (with a header row) into import rows.
+// Columns are matched by header name so their order does not matter.
+func ParseMenuRows(records [][]string) ([]MenuImportRow, []MenuImportError) {
+	if len(records) == 0 {
+		return nil, []MenuImportError{{Row: 1, Message: "file is empty"}}
+	}
+
+	index := make(map[string]int)
+	for i, name := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"nama_makanan", "harga", "jenis"} {
+		if _, ok := index[required]; !ok {
+			return nil, []MenuImportError{{Row: 1, Field: required, Message: "missing column"}}
+		}
+	}
+
+	var rows []MenuImportRow
+	var errs []MenuImportError
+	for i, record := range records[1:] {
+		rowNum := i + 2
+		get := func(column string) string {
+			if j, ok := index[column]; ok && j < len(record) {
+				return utils.UnescapeFormula(strings.TrimSpace(record[j]))
+			}
+			return ""
+		}
+
+		// Skip blank lines
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := MenuImportRow{
+			Row:         rowNum,
+			NamaMakanan: get("nama_makanan"),
+			Jenis:       models.JenisMenu(strings.ToLower(get("jenis"))),
+			Deskripsi:   get("deskripsi"),
+			Foto:        get("foto"),
+		}
+
+		if v := get("id"); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				errs = append(errs, MenuImportError{Row: rowNum, Field: "id", Message: "must be a number"})
+			}
+			row.ID = uint(id)
+		}
+		if row.NamaMakanan == "" {
+			errs = append(errs, MenuImportError{Row: rowNum, Field: "nama_makanan", Message: "is required"})
+		} else if len(row.NamaMakanan) > 100 {
+			errs = append(errs, MenuImportError{Row: rowNum, Field: "nama_makanan", Message: "must be at most 100 characters"})
+		}
+		harga, err := strconv.ParseFloat(get("harga"), 64)
+		if err != nil || harga < 0 {
+			errs = append(errs, MenuImportError{Row: rowNum, Field: "harga", Message: "must be a non-negative number"})
+		}
+		row.Harga = harga
+		if row.Jenis != models.JenisMakanan && row.Jenis != models.JenisMinuman {
+			errs = append(errs, MenuImportError{Row: rowNum, Field: "jenis", Message: "must be makanan or minuman"})
+		}
+		if v := get("stock"); v != "" {
+			stock, err := strconv.Atoi(v)
+			if err != nil || stock < 0 {
+				errs = append(errs, MenuImportError{Row: rowNum, Field: "stock", Message: "must be a non-negative whole number"})
+			}
+			row.Stock = stock
+		}
+		// Exported files contain stored image paths, which are kept as-is on re-import
+		if row.Foto != "" && !utils.IsBase64Image(row.Foto) && !strings.HasPrefix(row.Foto, utils.ImageUploadDir+"/") &&
+			!strings.HasPrefix(row.Foto, "http://") && !strings.HasPrefix(row.Foto, "https://") {
+			errs = append(errs, MenuImportError{Row: rowNum, Field: "foto", Message: "must be an image URL or base64 image"})
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && len(errs) == 0 {
+		errs = append(errs, MenuImportError{Row: 1, Message: "file has no menu rows"})
+	}
+	return rows, errs
+}
+
+// ImportMenus validates rows against the stan's existing menus and applies them in one transaction.
+// Nothing is written when any row is invalid or when dryRun is set.
+func (s *MenuImportService) ImportMenus(stanID uint, userID uint, rows []MenuImportRow, parseErrors []MenuImportError, dryRun bool) (*MenuImportResult, error) {
+	result := &MenuImportResult{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Errors: parseErrors,
+	}
+
+	var existing []models.Menu
+	if err := s.db.Where("id_stan = ?", stanID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Menu, len(existing))
+	byName := make(map[string]*models.Menu, len(existing))
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byName[strings.ToLower(existing[i].NamaMakanan)] = &existing[i]
+	}
+
+	menuIDs := make([]uint, len(existing))
+	for i := range existing {
+		menuIDs[i] = existing[i].ID
+	}
+	recipeMenus, err := recipeMenuIDs(s.db, menuIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	seenNames := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		name := strings.ToLower(row.NamaMakanan)
+		if first, ok := seenNames[name]; ok && name != "" {
+			result.Errors = append(result.Errors, MenuImportError{
+				Row: row.Row, Field: "nama_makanan", Message: fmt.Sprintf("duplicates row %d", first),
+			})
+		}
+		seenNames[name] = row.Row
+
+		switch {
+		case row.ID != 0:
+			if _, ok := byID[row.ID]; !ok {
+				result.Errors = append(result.Errors, MenuImportError{
+					Row: row.Row, Field: "id", Message: "menu not found in this stan",
+				})
+				continue
+			}
+			row.Action = "update"
+		case byName[name] != nil:
+			row.ID = byName[name].ID
+			row.Action = "update"
+		default:
+			row.Action = "create"
+		}
+
+		// The stock of a menu with a recipe follows its ingredients; an unchanged stock is
+		// accepted so an exported file can be imported again
+		if row.Action == "update" && recipeMenus[row.ID] && row.Stock != byID[row.ID].Stock {
+			result.Errors = append(result.Errors, MenuImportError{
+				Row: row.Row, Field: "stock", Message: "stock of a menu with a recipe follows its ingredient stock",
+			})
+		}
+
+		if row.Action == "create" {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+	result.Rows = rows
+
+	if len(result.Errors) > 0 || dryRun {
+		return result, nil
+	}
+
+	var savedImages []string
+	var alerts []*StockAlert
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range rows {
+			row := &rows[i]
+			foto := row.Foto
+			if foto != "" && utils.IsBase64Image(foto) {
+				imagePath, err := utils.SaveBase64Image(foto)
+				if err != nil {
+					return fmt.Errorf("row %d: %w", row.Row, err)
+				}
+				savedImages = append(savedImages, imagePath)
+				foto = imagePath
+			}
+
+			if row.Action == "create" {
+				menu := models.Menu{
+					NamaMakanan: row.NamaMakanan,
+					Harga:       row.Harga,
+					Jenis:       row.Jenis,
+					Stock:       row.Stock,
+					Deskripsi:   row.Deskripsi,
+					Foto:        foto,
+					IsAvailable: row.Stock > 0,
+					IDStan:      stanID,
+				}
+				if err := tx.Create(&menu).Error; err != nil {
+					return fmt.Errorf("row %d: %w", row.Row, err)
+				}
+				if err := recordStockMovement(tx, menu.ID, menu.Stock, menu.Stock, StockChange{
+					Reason:     models.StockRestock,
+					IDUser:     userID,
+					Keterangan: "stok awal (import)",
+				}); err != nil {
+					return fmt.Errorf("row %d: %w", row.Row, err)
+				}
+				row.ID = menu.ID
+				continue
+			}
+
+			updates := map[string]interface{}{
+				"nama_makanan": row.NamaMakanan,
+				"harga":        row.Harga,
+				"jenis":        row.Jenis,
+				"deskripsi":    row.Deskripsi,
+			}
+			if foto != "" {
+				updates["foto"] = foto
+			}
+			var menu models.Menu
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND id_stan = ?", row.ID, stanID).First(&menu).Error; err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			hargaLama := menu.Harga
+			if err := tx.Model(&menu).Updates(updates).Error; err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			if err := recordPriceChange(tx, menu.ID, hargaLama, row.Harga, userID, models.PriceSourceImport); err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			if recipeMenus[menu.ID] {
+				continue
+			}
+			alert, err := setStock(tx, menu.ID, row.Stock, StockChange{
+				Reason:     models.StockCorrection,
+				IDUser:     userID,
+				Keterangan: "import",
+			})
+			if err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			alerts = append(alerts, alert)
+		}
+		return nil
+	})
+	if err != nil {
+		for _, imagePath := range savedImages {
+			utils.DeleteImage(imagePath)
+		}
+		return nil, err
+	}
+
+	dispatchStockAlerts(alerts...)
+	result.Applied = true
+	return result, nil
+}
+
+// ExportMenus returns the stan's menus as spreadsheet records in the import format.
+// Text that starts like a formula is escaped, and ParseMenuRows removes the escape again.
+func (s *MenuImportService) ExportMenus(stanID uint) ([][]string, error) {
+	var menus []models.Menu
+	if err := s.db.Where("id_stan = ?", stanID).Order("id").Find(&menus).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([][]string, 0, len(menus)+1)
+	records = append(records, menuImportColumns)
+	for _, menu := range menus {
+		records = append(records, []string{
+			strconv.FormatUint(uint64(menu.ID), 10),
+			utils.EscapeFormula(menu.NamaMakanan),
+			strconv.FormatFloat(menu.Harga, 'f', -1, 64),
+			string(menu.Jenis),
+			strconv.Itoa(menu.Stock),
+			utils.EscapeFormula(menu.Deskripsi),
+			utils.EscapeFormula(menu.Foto),
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"swipeup-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MenuOptionService struct {
+	*BaseService[models.MenuOptionGroup]
+}
+
+func NewMenuOptionService(db *gorm.DB) *MenuOptionService {
+	return &MenuOptionService{
+		BaseService: NewBaseService[models.MenuOptionGroup](db),
+	}
+}
+
+// GetGroupsByMenuID retrieves option groups with their options for a menu
+func (s *MenuOptionService) GetGroupsByMenuID(menuID uint) ([]models.MenuOptionGroup, error) {
+	var groups []models.MenuOptionGroup
+	err := s.GetDB().Preload("Options").Where("id_menu = ?", menuID).Order("urutan, id").Find(&groups).Error
+	return groups, err
+}
+
+// UpdateGroupFields updates fields of an option group. A new min_pilihan or max_pilihan is
+// checked together with the stored value of the other.
+func (s *MenuOptionService) UpdateGroupFields(id uint, updates map[string]interface{}) error {
+	_, hasMin := updates["min_pilihan"]
+	_, hasMax := updates["max_pilihan"]
+	if !hasMin && !hasMax {
+		return s.GetDB().Model(&models.MenuOptionGroup{}).Where("id = ?", id).Updates(updates).Error
+	}
+
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		var group models.MenuOptionGroup
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, id).Error; err != nil {
+			return err
+		}
+		if minPilihan, ok := updates["min_pilihan"].(int); ok {
+			group.MinPilihan = minPilihan
+		}
+		if maxPilihan, ok := updates["max_pilihan"].(int); ok {
+			group.MaxPilihan = maxPilihan
+		}
+		if group.MinPilihan < 0 || group.MaxPilihan < 1 {
+			return fmt.Errorf("%w: min_pilihan must not be negative and max_pilihan must be at least 1", ErrInvalidOption)
+		}
+		if group.MinPilihan > group.MaxPilihan {
+			return fmt.Errorf("%w: min_pilihan cannot be greater than max_pilihan", ErrInvalidOption)
+		}
+		return tx.Model(&models.MenuOptionGroup{}).Where("id = ?", id).Updates(updates).Error
+	})
+}
+
+// DeleteGroup deletes an option group and its options
+func (s *MenuOptionService) DeleteGroup(id uint) error {
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_group = ?", id).Delete(&models.MenuOption{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.MenuOptionGroup{}, id).Error
+	})
+}
+
+// CreateOption adds an option to a group
+func (s *MenuOptionService) CreateOption(option *models.MenuOption) error {
+	return s.GetDB().Create(option).Error
+}
+
+// GetOptionByID retrieves an option with its group
+func (s *MenuOptionService) GetOptionByID(id uint) (*models.MenuOption, error) {
+	var option models.MenuOption
+	if err := s.GetDB().Preload("Group").First(&option, id).Error; err != nil {
+		return nil, err
+	}
+	return &option, nil
+}
+
+// UpdateOptionFields updates fields of an option
+func (s *MenuOptionService) UpdateOptionFields(id uint, updates map[string]interface{}) error {
+	return s.GetDB().Model(&models.MenuOption{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteOption deletes an option
+func (s *MenuOptionService) DeleteOption(id uint) error {
+	return s.GetDB().Delete(&models.MenuOption{}, id).Error
+}
+
+// ResolveSelection validates the chosen option IDs against the menu's option groups and
+// returns the chosen options (with their group) in a stable order.
+// Every group must satisfy its min/max choices and required groups must have at least one choice.
+func (s *MenuOptionService) ResolveSelection(menuID uint, optionIDs []uint) ([]models.MenuOption, error) {
+	groups, err := s.GetGroupsByMenuID(menuID)
+	if err != nil {
+		return nil, err
+	}
+
+	chosen := make(map[uint]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if chosen[id] {
+			return nil, fmt.Errorf("%w: option %d is chosen more than once", ErrInvalidOption, id)
+		}
+		chosen[id] = true
+	}
+
+	var selected []models.MenuOption
+	for _, group := range groups {
+		count := 0
+		for _, option := range group.Options {
+			if !chosen[option.ID] {
+				continue
+			}
+			if !option.IsAvailable || (option.Stock != nil && *option.Stock <= 0) {
+				return nil, fmt.Errorf("%w: option %s is not available", ErrInvalidOption, option.NamaOpsi)
+			}
+			delete(chosen, option.ID)
+			g := group
+			g.Options = nil
+			option.Group = &g
+			selected = append(selected, option)
+			count++
+		}
+
+		minPilihan := group.MinPilihan
+		if group.IsRequired && minPilihan < 1 {
+			minPilihan = 1
+		}
+		if count < minPilihan {
+			return nil, fmt.Errorf("%w: %s requires at least %d choice(s)", ErrInvalidOption, group.NamaGroup, minPilihan)
+		}
+		if group.MaxPilihan > 0 && count > group.MaxPilihan {
+			return nil, fmt.Errorf("%w: %s allows at most %d choice(s)", ErrInvalidOption, group.NamaGroup, group.MaxPilihan)
+		}
+	}
+
+	if len(chosen) > 0 {
+		return nil, fmt.Errorf("%w: one or more options do not belong to this menu", ErrInvalidOption)
+	}
+
+	return selected, nil
+}
+
+// OptionKey builds the cart key for a set of chosen options, e.g. "3,7,12"
+func OptionKey(options []models.MenuOption) string {
+	ids := make([]int, 0, len(options))
+	for _, option := range options {
+		ids = append(ids, int(option.ID))
+	}
+	sort.Ints(ids)
+
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, ",")
+}
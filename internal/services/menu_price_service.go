@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"swipeup-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MenuPriceService struct {
+	db *gorm.DB
+}
+
+func NewMenuPriceService(db *gorm.DB) *MenuPriceService {
+	return &MenuPriceService{db: db}
+}
+
+// recordPriceChange writes a price history entry when the price actually changed.
+// changedBy 0 means the change was made by the system.
+func recordPriceChange(tx *gorm.DB, menuID uint, hargaLama, hargaBaru float64, changedBy uint, sumber string) error {
+	if hargaLama == hargaBaru {
+		return nil
+	}
+	history := models.MenuPriceHistory{
+		IDMenu:    menuID,
+		HargaLama: hargaLama,
+		HargaBaru: hargaBaru,
+		Sumber:    sumber,
+	}
+	if changedBy != 0 {
+		history.ChangedBy = &changedBy
+	}
+	return tx.Create(&history).Error
+}
+
+// GetHistory retrieves the price changes of a menu, newest first
+func (s *MenuPriceService) GetHistory(menuID uint) ([]models.MenuPriceHistory, error) {
+	var history []models.MenuPriceHistory
+	err := s.db.Preload("User").Where("id_menu = ?", menuID).Order("created_at DESC, id DESC").Find(&history).Error
+	return history, err
+}
+
+// SchedulePriceChange schedules a new price for a menu at a future time
+func (s *MenuPriceService) SchedulePriceChange(change *models.MenuPriceChange) error {
+	if change.HargaBaru < 0 {
+		return fmt.Errorf("%w: harga_baru must not be negative", ErrInvalidPriceChange)
+	}
+	if !change.BerlakuMulai.After(time.Now()) {
+		return fmt.Errorf("%w: berlaku_mulai must be in the future", ErrInvalidPriceChange)
+	}
+	change.Status = models.PriceChangePending
+	return s.db.Create(change).Error
+}
+
+// GetScheduledChanges retrieves the price changes scheduled for a menu, soonest first
+func (s *MenuPriceService) GetScheduledChanges(menuID uint) ([]models.MenuPriceChange, error) {
+	var changes []models.MenuPriceChange
+	err := s.db.Where("id_menu = ?", menuID).Order("berlaku_mulai, id").Find(&changes).Error
+	return changes, err
+}
+
+// GetScheduledChangeByID retrieves a scheduled price change with its menu
+func (s *MenuPriceService) GetScheduledChangeByID(id uint) (*models.MenuPriceChange, error) {
+	var change models.MenuPriceChange
+	if err := s.db.Preload("Menu").First(&change, id).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// CancelScheduledChange cancels a price change that has not been applied yet
+func (s *MenuPriceService) CancelScheduledChange(id uint) error {
+	result := s.db.Model(&models.MenuPriceChange{}).
+		Where("id = ? AND status = ?", id, models.PriceChangePending).
+		Update("status", models.PriceChangeCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: price change is no longer pending", ErrInvalidPriceChange)
+	}
+	return nil
+}
+
+// ApplyDuePriceChanges applies every pending price change whose time has come and
+// returns how many were applied. Changes for the same menu are applied in order.
+func (s *MenuPriceService) ApplyDuePriceChanges(now time.Time) (int, error) {
+	var changes []models.MenuPriceChange
+	err := s.db.Where("status = ? AND berlaku_mulai <= ?", models.PriceChangePending, now).
+		Order("berlaku_mulai, id").Find(&changes).Error
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	var errs []error
+	for _, change := range changes {
+		claimed := false
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			// Claim the change so concurrent runs do not apply it twice
+			result := tx.Model(&models.MenuPriceChange{}).
+				Where("id = ? AND status = ?", change.ID, models.PriceChangePending).
+				Updates(map[string]interface{}{"status": models.PriceChangeApplied, "applied_at": now})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			var menu models.Menu
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&menu, change.IDMenu).Error; err != nil {
+				return err
+			}
+			hargaLama := menu.Harga
+			if err := tx.Model(&menu).Update("harga", change.HargaBaru).Error; err != nil {
+				return err
+			}
+			if err := recordPriceChange(tx, menu.ID, hargaLama, change.HargaBaru, change.IDUser, models.PriceSourceScheduled); err != nil {
+				return err
+			}
+			claimed = true
+			return nil
+		})
+		if err != nil {
+			// Keep going so one broken change does not hold back the others
+			errs = append(errs, fmt.Errorf("price change %d: %w", change.ID, err))
+			continue
+		}
+		if claimed {
+			applied++
+		}
+	}
+	return applied, errors.Join(errs...)
+}
+
+// RunScheduler applies due price changes every interval until ctx is cancelled
+func (s *MenuPriceService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if applied, err := s.ApplyDuePriceChanges(time.Now().UTC()); err != nil {
+			log.Printf("Failed to apply scheduled price changes: %v", err)
+		} else if applied > 0 {
+			log.Printf("Applied %d scheduled price change(s)", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"swipeup-be/internal/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// createPriceTestMenu creates a menu priced at harga for the price history tests
+func createPriceTestMenu(t *testing.T, db *gorm.DB, harga float64) models.Menu {
+	t.Helper()
+	menu := models.Menu{NamaMakanan: "Mie Ayam", Harga: harga, Jenis: models.JenisMakanan, Stock: 5, IDStan: 1}
+	if err := db.Create(&menu).Error; err != nil {
+		t.Fatal(err)
+	}
+	return menu
+}
+
+// assertPriceHistory checks the menu's only price history entry
+func assertPriceHistory(t *testing.T, db *gorm.DB, menuID uint, hargaLama, hargaBaru float64, sumber string) {
+	t.Helper()
+	var history []models.MenuPriceHistory
+	if err := db.Where("id_menu = ?", menuID).Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("recorded %d price history entries, want 1", len(history))
+	}
+	h := history[0]
+	if h.HargaLama != hargaLama || h.HargaBaru != hargaBaru || h.Sumber != sumber {
+		t.Errorf("history = %v -> %v (%s), want %v -> %v (%s)", h.HargaLama, h.HargaBaru, h.Sumber, hargaLama, hargaBaru, sumber)
+	}
+}
+
+func TestUpdateFieldsByRecordsPriceChange(t *testing.T) {
+	db := openTestDB(t, &models.Menu{}, &models.MenuPriceHistory{})
+	menu := createPriceTestMenu(t, db, 10000)
+
+	if err := NewMenuService(db).UpdateFieldsBy(menu.ID, map[string]interface{}{"harga": 12000.0}, 3); err != nil {
+		t.Fatalf("UpdateFieldsBy: %v", err)
+	}
+	assertPriceHistory(t, db, menu.ID, 10000, 12000, models.PriceSourceManual)
+}
+
+func TestApplyDuePriceChangesRecordsPriceChange(t *testing.T) {
+	db := openTestDB(t, &models.Menu{}, &models.MenuPriceHistory{}, &models.MenuPriceChange{})
+	menu := createPriceTestMenu(t, db, 10000)
+
+	now := time.Now().UTC()
+	change := models.MenuPriceChange{IDMenu: menu.ID, HargaBaru: 8000, BerlakuMulai: now.Add(-time.Minute), Status: models.PriceChangePending, IDUser: 3}
+	if err := db.Create(&change).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := NewMenuPriceService(db).ApplyDuePriceChanges(now)
+	if err != nil {
+		t.Fatalf("ApplyDuePriceChanges: %v", err)
+	}
+	if applied != 1 {
+		t.Fatalf("applied %d changes, want 1", applied)
+	}
+	assertPriceHistory(t, db, menu.ID, 10000, 8000, models.PriceSourceScheduled)
+}
+
+func TestImportMenusRecordsPriceChange(t *testing.T) {
+	db := openTestDB(t, &models.Stan{}, &models.Menu{}, &models.MenuPriceHistory{}, &models.RecipeItem{}, &models.StockMovement{}, &models.Notification{})
+	menu := createPriceTestMenu(t, db, 10000)
+
+	rows := []MenuImportRow{{Row: 2, ID: menu.ID, NamaMakanan: menu.NamaMakanan, Harga: 11000, Jenis: menu.Jenis, Stock: menu.Stock}}
+	result, err := NewMenuImportService(db).ImportMenus(menu.IDStan, 3, rows, nil, false)
+	if err != nil {
+		t.Fatalf("ImportMenus: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("ImportMenus errors: %v", result.Errors)
+	}
+	assertPriceHistory(t, db, menu.ID, 10000, 11000, models.PriceSourceImport)
+}
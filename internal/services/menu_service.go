@@ -1,28 +1,110 @@
 package services
 
 import (
+	"fmt"
+	"strings"
 	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MenuService struct {
 	*BaseService[models.Menu]
+	pricing *PricingService
+	bundles *MenuBundleService
 }
 
 func NewMenuService(db *gorm.DB) *MenuService {
 	return &MenuService{
 		BaseService: NewBaseService[models.Menu](db),
+		pricing:     NewPricingService(db),
+		bundles:     NewMenuBundleService(db),
 	}
 }
 
 func (s *MenuService) GetByStanID(stanID uint) ([]models.Menu, error) {
-	return s.FindWithCondition(map[string]interface{}{"id_stan": stanID}, "Stan")
+	menus, err := s.FindWithCondition(map[string]interface{}{"id_stan": stanID}, "Stan", "OptionGroups.Options")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pricing.AttachPromo(menus); err != nil {
+		return nil, err
+	}
+	return menus, nil
 }
 
 func (s *MenuService) UpdateFields(id uint, updates map[string]interface{}) error {
-	return s.GetDB().Model(&models.Menu{}).Where("id = ?", id).Updates(updates).Error
+	return s.UpdateFieldsBy(id, updates, 0)
+}
+
+// UpdateFieldsBy updates fields of a menu attributed to userID. A price change is recorded
+// in the price history and a stock change in the stock ledger as a correction. The stock of
+// a menu with a recipe follows its ingredients and cannot be set.
+func (s *MenuService) UpdateFieldsBy(id uint, updates map[string]interface{}, userID uint) error {
+	harga, hasHarga := updates["harga"].(float64)
+	stock, hasStock := updates["stock"].(int)
+	if !hasHarga && !hasStock {
+		return s.GetDB().Model(&models.Menu{}).Where("id = ?", id).Updates(updates).Error
+	}
+
+	fields := make(map[string]interface{}, len(updates))
+	for key, value := range updates {
+		if key != "stock" {
+			fields[key] = value
+		}
+	}
+
+	var alert *StockAlert
+	err := s.GetDB().Transaction(func(tx *gorm.DB) error {
+		var menu models.Menu
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&menu, id).Error; err != nil {
+			return err
+		}
+		// Updates writes the new values back into menu, so keep the old price
+		hargaLama := menu.Harga
+		if len(fields) > 0 {
+			if err := tx.Model(&menu).Updates(fields).Error; err != nil {
+				return err
+			}
+		}
+		if hasHarga {
+			if err := recordPriceChange(tx, menu.ID, hargaLama, harga, userID, models.PriceSourceManual); err != nil {
+				return err
+			}
+		}
+		if hasStock {
+			recipe, err := hasRecipe(tx, menu.ID)
+			if err != nil {
+				return err
+			}
+			if recipe {
+				return fmt.Errorf("%w: stock of a menu with a recipe follows its ingredient stock", ErrInvalidStockChange)
+			}
+			alert, err = setStock(tx, menu.ID, stock, StockChange{Reason: models.StockCorrection, IDUser: userID})
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		dispatchStockAlerts(alert)
+	}
+	return err
+}
+
+// Create creates a menu and records its initial stock in the stock ledger
+func (s *MenuService) Create(menu *models.Menu) error {
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(menu).Error; err != nil {
+			return err
+		}
+		return recordStockMovement(tx, menu.ID, menu.Stock, menu.Stock, StockChange{
+			Reason:     models.StockRestock,
+			Keterangan: "stok awal",
+		})
+	})
 }
 
 func (s *MenuService) GetByJenis(jenis models.JenisMenu) ([]models.Menu, error) {
@@ -30,15 +112,16 @@ func (s *MenuService) GetByJenis(jenis models.JenisMenu) ([]models.Menu, error)
 }
 
 func (s *MenuService) GetWithDiskon(id uint) (*models.Menu, error) {
-	return s.FindByID(id, "Stan", "MenuDiskon", "MenuDiskon.Diskon")
+	return s.FindByID(id, "Stan", "MenuDiskon", "MenuDiskon.Diskon", "OptionGroups.Options")
 }
 
 func (s *MenuService) GetMenuWithActiveDiskon(id uint) (*models.Menu, error) {
 	var menu models.Menu
-	now := time.Now()
+	now := time.Now().UTC()
 	err := s.GetDB().Preload("Stan").
 		Preload("MenuDiskon", "deleted_at IS NULL").
 		Preload("MenuDiskon.Diskon", "tanggal_awal <= ? AND tanggal_akhir >= ?", now, now).
+		Preload("OptionGroups.Options").
 		First(&menu, id).Error
 	if err != nil {
 		return nil, err
@@ -52,39 +135,289 @@ func (s *MenuService) SearchByName(name string) ([]models.Menu, error) {
 	return menus, err
 }
 
-// UpdateStock updates the stock of a menu item
-func (s *MenuService) UpdateStock(id uint, stock int) error {
-	isAvailable := stock > 0
-	return s.GetDB().Model(&models.Menu{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"stock":        stock,
-		"is_available": isAvailable,
-	}).Error
+// Menu search sort options
+const (
+	SortRelevance = "relevance"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortPopular   = "popular"
+)
+
+// MenuSearchParams holds the query, filters and paging for a menu search
+type MenuSearchParams struct {
+	Query         string
+	StanID        uint
+	Jenis         models.JenisMenu
+	MinHarga      *float64
+	MaxHarga      *float64
+	AvailableOnly bool
+	HasDiskon     bool
+	Diet          DietFilter
+	Sort          string
+	Limit         int
+	Offset        int
+}
+
+// DietFilter narrows menu listings by dietary labels and allergens
+type DietFilter struct {
+	Vegetarian     bool
+	Halal          bool
+	ExcludeAlergen []string
+	MaxLevelPedas  *int
 }
 
-// AdjustStock adjusts stock by delta (positive or negative)
-func (s *MenuService) AdjustStock(id uint, delta int) error {
-	return s.GetDB().Transaction(func(tx *gorm.DB) error {
-		var menu models.Menu
-		if err := tx.First(&menu, id).Error; err != nil {
-			return err
+// apply adds the filter conditions to a menus query
+func (f DietFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Vegetarian {
+		query = query.Where("menus.is_vegetarian = ?", true)
+	}
+	if f.Halal {
+		query = query.Where("menus.is_halal = ?", true)
+	}
+	for _, alergen := range f.ExcludeAlergen {
+		query = query.Where("',' || COALESCE(menus.alergen, '') || ',' NOT LIKE ?", "%,"+alergen+",%")
+	}
+	if f.MaxLevelPedas != nil {
+		query = query.Where("menus.level_pedas <= ?", *f.MaxLevelPedas)
+	}
+	return query
+}
+
+// Matches checks a loaded menu against the filter
+func (f DietFilter) Matches(menu *models.Menu) bool {
+	if f.Vegetarian && !menu.IsVegetarian {
+		return false
+	}
+	if f.Halal && !menu.IsHalal {
+		return false
+	}
+	if len(menu.ConflictingAlergen(strings.Join(f.ExcludeAlergen, ","))) > 0 {
+		return false
+	}
+	if f.MaxLevelPedas != nil && menu.LevelPedas > *f.MaxLevelPedas {
+		return false
+	}
+	return true
+}
+
+// FilterMenus keeps the menus matching the diet filter
+func FilterMenus(menus []models.Menu, filter DietFilter) []models.Menu {
+	result := make([]models.Menu, 0, len(menus))
+	for i := range menus {
+		if filter.Matches(&menus[i]) {
+			result = append(result, menus[i])
+		}
+	}
+	return result
+}
+
+// FilterBundles returns the bundles whose component menus all match the filter
+func FilterBundles(bundles []models.MenuBundle, filter DietFilter) []models.MenuBundle {
+	result := make([]models.MenuBundle, 0, len(bundles))
+	for i := range bundles {
+		matches := true
+		for j := range bundles[i].Items {
+			if !filter.Matches(&bundles[i].Items[j].Menu) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, bundles[i])
 		}
-		
-		newStock := menu.Stock + delta
-		if newStock < 0 {
-			newStock = 0
+	}
+	return result
+}
+
+// activeDiskonCondition matches menus with at least one discount active right now,
+// mirroring DiskonAppliesToMenu for every discount type
+const activeDiskonCondition = `EXISTS (
+	SELECT 1 FROM diskons d
+	WHERE d.deleted_at IS NULL AND d.is_active = TRUE
+	AND d.tanggal_awal <= ? AND d.tanggal_akhir >= ?
+	AND (d.id_stan IS NULL OR d.id_stan = menus.id_stan)
+	AND (
+		d.tipe_diskon IN ('global', 'stan')
+		OR (d.tipe_diskon = 'menu' AND EXISTS (
+			SELECT 1 FROM menu_diskons md
+			WHERE md.id_diskon = d.id AND md.id_menu = menus.id AND md.deleted_at IS NULL))
+		OR (d.tipe_diskon = 'kategori'
+			AND (d.target_jenis IS NULL OR d.target_jenis = menus.jenis)
+			AND (COALESCE(d.target_tag, '') = '' OR ',' || COALESCE(menus.tags, '') || ',' LIKE '%,' || LOWER(d.target_tag) || ',%'))
+	))`
+
+// Search finds menus by nama_makanan and deskripsi using full-text and trigram matching,
+// so small typos still match. Results are filtered, sorted and paginated; total is the
+// number of matches before paging.
+func (s *MenuService) Search(params MenuSearchParams) ([]models.Menu, int64, error) {
+	query := s.GetDB().Model(&models.Menu{})
+
+	if params.Query != "" {
+		query = query.Where(
+			"menus.search_vector @@ plainto_tsquery('simple', ?) OR menus.nama_makanan % ? OR ? <% menus.nama_makanan OR menus.deskripsi ILIKE ?",
+			params.Query, params.Query, params.Query, "%"+params.Query+"%",
+		)
+	}
+	if params.StanID != 0 {
+		query = query.Where("menus.id_stan = ?", params.StanID)
+	}
+	if params.Jenis != "" {
+		query = query.Where("menus.jenis = ?", params.Jenis)
+	}
+	if params.MinHarga != nil {
+		query = query.Where("menus.harga >= ?", *params.MinHarga)
+	}
+	if params.MaxHarga != nil {
+		query = query.Where("menus.harga <= ?", *params.MaxHarga)
+	}
+	if params.AvailableOnly {
+		query = query.Where("menus.is_available = ? AND menus.stock > 0", true)
+	}
+	if params.HasDiskon {
+		now := time.Now().UTC()
+		query = query.Where(activeDiskonCondition, now, now)
+	}
+	query = params.Diet.apply(query)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	switch params.Sort {
+	case SortPriceAsc:
+		query = query.Order("menus.harga ASC, menus.id")
+	case SortPriceDesc:
+		query = query.Order("menus.harga DESC, menus.id")
+	case SortPopular:
+		query = query.Joins("LEFT JOIN (SELECT id_menu, SUM(qty) AS terjual FROM detail_transaksis WHERE deleted_at IS NULL GROUP BY id_menu) pop ON pop.id_menu = menus.id").
+			Order("COALESCE(pop.terjual, 0) DESC, menus.id")
+	default:
+		if params.Query != "" {
+			query = query.Clauses(clause.OrderBy{
+				Expression: clause.Expr{
+					SQL:  "ts_rank(menus.search_vector, plainto_tsquery('simple', ?)) + similarity(menus.nama_makanan, ?) DESC, menus.id",
+					Vars: []interface{}{params.Query, params.Query},
+				},
+			})
+		} else {
+			query = query.Order("menus.id")
 		}
-		
-		isAvailable := newStock > 0
-		return tx.Model(&menu).Updates(map[string]interface{}{
-			"stock":        newStock,
-			"is_available": isAvailable,
-		}).Error
+	}
+
+	var menus []models.Menu
+	err := query.Select("menus.*").Preload("Stan").
+		Limit(params.Limit).Offset(params.Offset).
+		Find(&menus).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.pricing.AttachPromo(menus); err != nil {
+		return nil, 0, err
+	}
+	return menus, total, nil
+}
+
+// UpdateStock sets the stock of a menu item and records the change in the stock ledger
+func (s *MenuService) UpdateStock(id uint, stock int, change StockChange) error {
+	var alert *StockAlert
+	err := s.GetDB().Transaction(func(tx *gorm.DB) (err error) {
+		alert, err = setStock(tx, id, stock, change)
+		return err
+	})
+	if err == nil {
+		dispatchStockAlerts(alert)
+	}
+	return err
+}
+
+// AdjustStock adjusts stock by delta (positive or negative), never below zero,
+// and records the applied change in the stock ledger
+func (s *MenuService) AdjustStock(id uint, delta int, change StockChange) error {
+	var alert *StockAlert
+	err := s.GetDB().Transaction(func(tx *gorm.DB) (err error) {
+		alert, err = adjustStock(tx, id, delta, change)
+		return err
 	})
+	if err == nil {
+		dispatchStockAlerts(alert)
+	}
+	return err
 }
 
-// GetAvailableMenuByStanID gets only available (in-stock) menu items
+// GetAvailableMenuByStanID gets only available (in-stock) menu items that are scheduled right now
 func (s *MenuService) GetAvailableMenuByStanID(stanID uint) ([]models.Menu, error) {
 	var menus []models.Menu
-	err := s.GetDB().Preload("Stan").Where("id_stan = ? AND is_available = ? AND stock > 0", stanID, true).Find(&menus).Error
-	return menus, err
+	err := s.GetDB().Preload("Stan").Preload("Schedules").Preload("OptionGroups.Options", "is_available = ?", true).
+		Where("id_stan = ? AND is_available = ? AND stock > 0", stanID, true).
+		Find(&menus).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := utils.SchoolNow()
+	scheduled := make([]models.Menu, 0, len(menus))
+	for i := range menus {
+		if menus[i].IsScheduledAt(now) {
+			scheduled = append(scheduled, menus[i])
+		}
+	}
+	if err := s.pricing.AttachPromo(scheduled); err != nil {
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+// GetAvailableBundlesByStanID gets the stan's bundles that can be made from current component stock
+func (s *MenuService) GetAvailableBundlesByStanID(stanID uint) ([]models.MenuBundle, error) {
+	return s.bundles.GetAvailableByStanID(stanID)
+}
+
+// GetTodayMenuByStanID gets the menus a stan offers today with their schedules,
+// including menus whose time window has not started yet or has already passed
+func (s *MenuService) GetTodayMenuByStanID(stanID uint) ([]models.Menu, error) {
+	var menus []models.Menu
+	err := s.GetDB().Preload("Schedules").
+		Where("id_stan = ? AND is_available = ?", stanID, true).
+		Find(&menus).Error
+	if err != nil {
+		return nil, err
+	}
+
+	today := utils.SchoolNow()
+	result := make([]models.Menu, 0, len(menus))
+	for i := range menus {
+		if menus[i].IsScheduledOn(today) {
+			result = append(result, menus[i])
+		}
+	}
+	if err := s.pricing.AttachPromo(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSchedules retrieves the availability schedules of a menu
+func (s *MenuService) GetSchedules(menuID uint) ([]models.MenuSchedule, error) {
+	var schedules []models.MenuSchedule
+	err := s.GetDB().Where("id_menu = ?", menuID).Order("id").Find(&schedules).Error
+	return schedules, err
+}
+
+// ReplaceSchedules replaces the availability schedules of a menu; an empty list makes it always available
+func (s *MenuService) ReplaceSchedules(menuID uint, schedules []models.MenuSchedule) error {
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_menu = ?", menuID).Delete(&models.MenuSchedule{}).Error; err != nil {
+			return err
+		}
+		for i := range schedules {
+			schedules[i].ID = 0
+			schedules[i].IDMenu = menuID
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		return tx.Create(&schedules).Error
+	})
 }
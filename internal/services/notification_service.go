@@ -0,0 +1,54 @@
+package services
+
+import (
+	"swipeup-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationService struct {
+	db *gorm.DB
+}
+
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+// GetByUserID retrieves a page of a user's notifications, newest first
+func (s *NotificationService) GetByUserID(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error) {
+	query := s.db.Model(&models.Notification{}).Where("id_user = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// MarkRead marks one of the user's notifications as read
+func (s *NotificationService) MarkRead(userID uint, id uint) error {
+	result := s.db.Model(&models.Notification{}).
+		Where("id = ? AND id_user = ?", id, userID).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now().UTC()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks all of the user's unread notifications as read
+func (s *NotificationService) MarkAllRead(userID uint) error {
+	return s.db.Model(&models.Notification{}).
+		Where("id_user = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now().UTC()}).Error
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"sort"
+	"swipeup-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PricingService resolves the final price of menu items from the discounts active at a given time
+type PricingService struct {
+	db *gorm.DB
+}
+
+func NewPricingService(db *gorm.DB) *PricingService {
+	return &PricingService{db: db}
+}
+
+// PriceQuote represents the priced result for a single unit of a menu item
+type PriceQuote struct {
+	HargaAsli  float64        `json:"harga_asli"`
+	HargaAkhir float64        `json:"harga_akhir"`
+	Potongan   float64        `json:"potongan"`
+	Diskon     *models.Diskon `json:"diskon,omitempty"`
+}
+
+// GetActiveDiskonForStan loads all discounts that may apply to menus of a stan at the given time.
+// Discounts whose subsidy budget is spent are left out.
+func (s *PricingService) GetActiveDiskonForStan(stanID uint, at time.Time) ([]models.Diskon, error) {
+	var diskon []models.Diskon
+	err := s.db.Preload("MenuDiskon").
+		Where("tanggal_awal <= ? AND tanggal_akhir >= ? AND (id_stan IS NULL OR id_stan = ?) AND is_active = ?", at, at, stanID, true).
+		Where(diskonBudgetLeft).
+		Find(&diskon).Error
+	return diskon, err
+}
+
+// DiskonAppliesToMenu checks whether a discount targets the given menu.
+// Category discounts are matched dynamically against the menu's jenis and tags.
+func DiskonAppliesToMenu(diskon *models.Diskon, menu *models.Menu) bool {
+	if diskon.IDStan != nil && *diskon.IDStan != menu.IDStan {
+		return false
+	}
+
+	switch diskon.TipeDiskon {
+	case models.DiskonGlobal, models.DiskonStan:
+		return true
+	case models.DiskonMenu:
+		for _, md := range diskon.MenuDiskon {
+			if md.IDMenu == menu.ID {
+				return true
+			}
+		}
+		return false
+	case models.DiskonKategori:
+		if diskon.TargetJenis != nil && *diskon.TargetJenis != menu.Jenis {
+			return false
+		}
+		if diskon.TargetTag != "" && !menu.HasTag(diskon.TargetTag) {
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+// diskonPriority ranks discount types from most to least specific: Menu > Kategori > Stan > Global
+func diskonPriority(tipe models.TipeDiskon) int {
+	switch tipe {
+	case models.DiskonMenu:
+		return 0
+	case models.DiskonKategori:
+		return 1
+	case models.DiskonStan:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// ApplicableDiskon returns the discounts that apply to the menu, ordered by priority
+// and then by highest percentage within the same level
+func ApplicableDiskon(menu *models.Menu, diskon []models.Diskon) []models.Diskon {
+	var result []models.Diskon
+	for i := range diskon {
+		if DiskonAppliesToMenu(&diskon[i], menu) {
+			result = append(result, diskon[i])
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		pi, pj := diskonPriority(result[i].TipeDiskon), diskonPriority(result[j].TipeDiskon)
+		if pi != pj {
+			return pi < pj
+		}
+		return result[i].PersentaseDiskon > result[j].PersentaseDiskon
+	})
+	return result
+}
+
+// QuotePrice prices a unit of the menu at hargaDasar using the first applicable discount.
+// Discounts do not stack; a more specific discount replaces the general ones.
+func QuotePrice(menu *models.Menu, hargaDasar float64, diskon []models.Diskon) PriceQuote {
+	quote := PriceQuote{
+		HargaAsli:  hargaDasar,
+		HargaAkhir: hargaDasar,
+	}
+
+	applicable := ApplicableDiskon(menu, diskon)
+	if len(applicable) == 0 {
+		return quote
+	}
+
+	best := applicable[0]
+	quote.Diskon = &best
+	quote.Potongan = hargaDasar * best.PersentaseDiskon / 100
+	quote.HargaAkhir = hargaDasar - quote.Potongan
+	return quote
+}
+
+// getActiveDiskonByStan loads active discounts once for every stan the menus belong to
+func (s *PricingService) getActiveDiskonByStan(menus []models.Menu, at time.Time) (map[uint][]models.Diskon, error) {
+	diskonByStan := make(map[uint][]models.Diskon)
+	for i := range menus {
+		if _, ok := diskonByStan[menus[i].IDStan]; ok {
+			continue
+		}
+		diskon, err := s.GetActiveDiskonForStan(menus[i].IDStan, at)
+		if err != nil {
+			return nil, err
+		}
+		diskonByStan[menus[i].IDStan] = diskon
+	}
+	return diskonByStan, nil
+}
+
+// QuoteMenus prices every menu at its current Harga at the given time
+func (s *PricingService) QuoteMenus(menus []models.Menu, at time.Time) (map[uint]PriceQuote, error) {
+	diskonByStan, err := s.getActiveDiskonByStan(menus, at)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[uint]PriceQuote, len(menus))
+	for i := range menus {
+		quotes[menus[i].ID] = QuotePrice(&menus[i], menus[i].Harga, diskonByStan[menus[i].IDStan])
+	}
+	return quotes, nil
+}
+
+// AttachPromo fills the promo badges and promo price of each menu using currently active discounts
+func (s *PricingService) AttachPromo(menus []models.Menu) error {
+	diskonByStan, err := s.getActiveDiskonByStan(menus, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	for i := range menus {
+		applicable := ApplicableDiskon(&menus[i], diskonByStan[menus[i].IDStan])
+		if len(applicable) == 0 {
+			continue
+		}
+
+		menus[i].Promo = make([]models.MenuPromo, 0, len(applicable))
+		for _, d := range applicable {
+			menus[i].Promo = append(menus[i].Promo, models.MenuPromo{
+				IDDiskon:         d.ID,
+				NamaDiskon:       d.NamaDiskon,
+				TipeDiskon:       d.TipeDiskon,
+				PersentaseDiskon: d.PersentaseDiskon,
+			})
+		}
+
+		quote := QuotePrice(&menus[i], menus[i].Harga, applicable)
+		menus[i].HargaPromo = &quote.HargaAkhir
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"swipeup-be/internal/models"
+	"testing"
+	"time"
+)
+
+func TestGetActiveDiskonForStanSkipsSpentBudget(t *testing.T) {
+	db := openTestDB(t, &models.Diskon{}, &models.MenuDiskon{})
+
+	now := time.Now().UTC()
+	anggaran := 50000.0
+	spent := models.Diskon{NamaDiskon: "Habis", PersentaseDiskon: 20, TipeDiskon: models.DiskonGlobal,
+		TanggalAwal: now.Add(-time.Hour), TanggalAkhir: now.Add(time.Hour), AnggaranSubsidi: &anggaran, TotalSubsidi: anggaran, IsActive: true}
+	left := models.Diskon{NamaDiskon: "Masih", PersentaseDiskon: 10, TipeDiskon: models.DiskonGlobal,
+		TanggalAwal: now.Add(-time.Hour), TanggalAkhir: now.Add(time.Hour), AnggaranSubsidi: &anggaran, TotalSubsidi: 1000, IsActive: true}
+	if err := db.Create(&[]*models.Diskon{&spent, &left}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	diskon, err := NewPricingService(db).GetActiveDiskonForStan(1, now)
+	if err != nil {
+		t.Fatalf("GetActiveDiskonForStan: %v", err)
+	}
+	if len(diskon) != 1 || diskon[0].ID != left.ID {
+		t.Fatalf("got %d discounts, want only %q", len(diskon), left.NamaDiskon)
+	}
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// recommendationWindow is how far back order history is used for recommendations
+	recommendationWindow = 90 * 24 * time.Hour
+	// maxRelatedMenus is how many co-occurring menus are kept per menu
+	maxRelatedMenus = 20
+	// popularNowSpread is how many hours before and after the current hour count as "now"
+	popularNowSpread = 1
+)
+
+// scoredMenu is a menu with a recommendation score (number of orders)
+type scoredMenu struct {
+	IDMenu uint
+	Score  int64
+}
+
+// Recommendations are the menu suggestions for a student
+type Recommendations struct {
+	Frequent   []models.Menu `json:"frequent"`    // Menu yang paling sering dibeli siswa
+	AlsoBought []models.Menu `json:"also_bought"` // Sering dibeli bersama menu favorit siswa
+	PopularNow []models.Menu `json:"popular_now"` // Paling laris di jam yang sama
+	ComputedAt time.Time     `json:"computed_at"` // Waktu data co-occurrence dan popular dihitung
+}
+
+// RecommendationService suggests menus from order history. Co-occurrence and popularity
+// by hour are precomputed by Refresh; a student's own frequent items are read on demand.
+type RecommendationService struct {
+	db      *gorm.DB
+	pricing *PricingService
+
+	mu         sync.RWMutex
+	related    map[uint][]scoredMenu           // id_menu -> menu yang sering dibeli bersamanya
+	popular    map[uint]map[int]map[uint]int64 // id_stan -> jam -> id_menu -> jumlah pesanan
+	computedAt time.Time
+}
+
+func NewRecommendationService(db *gorm.DB) *RecommendationService {
+	return &RecommendationService{
+		db:      db,
+		pricing: NewPricingService(db),
+	}
+}
+
+// Refresh recomputes the co-occurrence and popular-by-hour tables from recent orders
+func (s *RecommendationService) Refresh() error {
+	since := time.Now().UTC().Add(-recommendationWindow)
+
+	var pairs []struct {
+		MenuA uint
+		MenuB uint
+		Total int64
+	}
+	err := s.db.Raw(`
+		SELECT a.id_menu AS menu_a, b.id_menu AS menu_b, COUNT(DISTINCT a.id_transaksi) AS total
+		FROM detail_transaksis a
+		JOIN detail_transaksis b ON b.id_transaksi = a.id_transaksi AND b.id_menu <> a.id_menu AND b.deleted_at IS NULL
+		JOIN transaksis t ON t.id = a.id_transaksi AND t.deleted_at IS NULL
+		WHERE a.deleted_at IS NULL AND t.tanggal >= ?
+		GROUP BY a.id_menu, b.id_menu`, since).Scan(&pairs).Error
+	if err != nil {
+		return err
+	}
+
+	related := make(map[uint][]scoredMenu)
+	for _, pair := range pairs {
+		related[pair.MenuA] = append(related[pair.MenuA], scoredMenu{IDMenu: pair.MenuB, Score: pair.Total})
+	}
+	for menuID, menus := range related {
+		sortScored(menus)
+		if len(menus) > maxRelatedMenus {
+			menus = menus[:maxRelatedMenus]
+		}
+		related[menuID] = menus
+	}
+
+	// Group by the hour in SQL and convert to the school's hour of day in Go
+	var hourly []struct {
+		IDStan uint
+		IDMenu uint
+		Jam    time.Time
+		Total  int64
+	}
+	err = s.db.Raw(`
+		SELECT t.id_stan, d.id_menu, date_trunc('hour', t.tanggal) AS jam, COUNT(DISTINCT t.id) AS total
+		FROM detail_transaksis d
+		JOIN transaksis t ON t.id = d.id_transaksi AND t.deleted_at IS NULL
+		WHERE d.deleted_at IS NULL AND t.tanggal >= ?
+		GROUP BY t.id_stan, d.id_menu, date_trunc('hour', t.tanggal)`, since).Scan(&hourly).Error
+	if err != nil {
+		return err
+	}
+
+	loc := utils.SchoolLocation()
+	popular := make(map[uint]map[int]map[uint]int64)
+	for _, row := range hourly {
+		byHour, ok := popular[row.IDStan]
+		if !ok {
+			byHour = make(map[int]map[uint]int64)
+			popular[row.IDStan] = byHour
+		}
+		hour := row.Jam.In(loc).Hour()
+		if byHour[hour] == nil {
+			byHour[hour] = make(map[uint]int64)
+		}
+		byHour[hour][row.IDMenu] += row.Total
+	}
+
+	s.mu.Lock()
+	s.related = related
+	s.popular = popular
+	s.computedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// RunScheduler refreshes the precomputed tables every interval until ctx is cancelled
+func (s *RecommendationService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(); err != nil {
+			log.Printf("Failed to refresh recommendations: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetForSiswa returns up to limit menus per recommendation list for a siswa.
+// With stanID set, only that stan's menus are suggested. Only menus that can be
+// ordered right now are returned.
+func (s *RecommendationService) GetForSiswa(siswaID uint, stanID uint, limit int) (*Recommendations, error) {
+	s.mu.RLock()
+	computed := !s.computedAt.IsZero()
+	s.mu.RUnlock()
+	if !computed {
+		if err := s.Refresh(); err != nil {
+			return nil, err
+		}
+	}
+
+	frequent, err := s.frequentBySiswa(siswaID, stanID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	alsoBought := s.alsoBought(frequent)
+	popularNow := s.popularAt(stanID, utils.SchoolNow().Hour())
+	computedAt := s.computedAt
+	s.mu.RUnlock()
+
+	result := &Recommendations{ComputedAt: computedAt}
+	if result.Frequent, err = s.loadOrderable(frequent, stanID, limit); err != nil {
+		return nil, err
+	}
+	if result.AlsoBought, err = s.loadOrderable(alsoBought, stanID, limit); err != nil {
+		return nil, err
+	}
+	if result.PopularNow, err = s.loadOrderable(popularNow, stanID, limit); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// frequentBySiswa ranks the menus a siswa ordered recently by number of orders
+func (s *RecommendationService) frequentBySiswa(siswaID uint, stanID uint) ([]scoredMenu, error) {
+	query := s.db.Table("detail_transaksis d").
+		Select("d.id_menu, COUNT(DISTINCT t.id) AS score").
+		Joins("JOIN transaksis t ON t.id = d.id_transaksi AND t.deleted_at IS NULL").
+		Where("d.deleted_at IS NULL AND t.id_siswa = ? AND t.tanggal >= ?", siswaID, time.Now().UTC().Add(-recommendationWindow))
+	if stanID != 0 {
+		query = query.Where("t.id_stan = ?", stanID)
+	}
+
+	var menus []scoredMenu
+	err := query.Group("d.id_menu").Order("score DESC, d.id_menu").Scan(&menus).Error
+	return menus, err
+}
+
+// alsoBought sums the co-occurrence scores of the siswa's frequent menus, leaving out
+// menus the siswa already buys. Must be called with s.mu held.
+func (s *RecommendationService) alsoBought(frequent []scoredMenu) []scoredMenu {
+	owned := make(map[uint]bool, len(frequent))
+	for _, menu := range frequent {
+		owned[menu.IDMenu] = true
+	}
+
+	scores := make(map[uint]int64)
+	for _, menu := range frequent {
+		for _, related := range s.related[menu.IDMenu] {
+			if !owned[related.IDMenu] {
+				scores[related.IDMenu] += related.Score * menu.Score
+			}
+		}
+	}
+	return toScored(scores)
+}
+
+// popularAt ranks menus ordered around the given hour of day. Must be called with s.mu held.
+func (s *RecommendationService) popularAt(stanID uint, hour int) []scoredMenu {
+	scores := make(map[uint]int64)
+	for id, byHour := range s.popular {
+		if stanID != 0 && id != stanID {
+			continue
+		}
+		for offset := -popularNowSpread; offset <= popularNowSpread; offset++ {
+			for menuID, total := range byHour[(hour+offset+24)%24] {
+				scores[menuID] += total
+			}
+		}
+	}
+	return toScored(scores)
+}
+
+// loadOrderable loads ranked menus in order, keeping up to limit that can be ordered now
+func (s *RecommendationService) loadOrderable(ranked []scoredMenu, stanID uint, limit int) ([]models.Menu, error) {
+	if len(ranked) == 0 {
+		return []models.Menu{}, nil
+	}
+
+	ids := make([]uint, 0, len(ranked))
+	for _, menu := range ranked {
+		ids = append(ids, menu.IDMenu)
+	}
+
+	var menus []models.Menu
+	query := s.db.Preload("Stan").Preload("Schedules").
+		Where("id IN ? AND is_available = ? AND stock > 0", ids, true)
+	if stanID != 0 {
+		query = query.Where("id_stan = ?", stanID)
+	}
+	if err := query.Find(&menus).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Menu, len(menus))
+	for _, menu := range menus {
+		byID[menu.ID] = menu
+	}
+
+	now := utils.SchoolNow()
+	result := make([]models.Menu, 0, limit)
+	for _, id := range ids {
+		menu, ok := byID[id]
+		if !ok || !menu.IsScheduledAt(now) {
+			continue
+		}
+		result = append(result, menu)
+		if len(result) == limit {
+			break
+		}
+	}
+
+	if err := s.pricing.AttachPromo(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// toScored converts a score map to a slice sorted by score
+func toScored(scores map[uint]int64) []scoredMenu {
+	menus := make([]scoredMenu, 0, len(scores))
+	for menuID, score := range scores {
+		menus = append(menus, scoredMenu{IDMenu: menuID, Score: score})
+	}
+	sortScored(menus)
+	return menus
+}
+
+// sortScored sorts by score (highest first), then by menu ID for a stable order
+func sortScored(menus []scoredMenu) {
+	sort.Slice(menus, func(i, j int) bool {
+		if menus[i].Score != menus[j].Score {
+			return menus[i].Score > menus[j].Score
+		}
+		return menus[i].IDMenu < menus[j].IDMenu
+	})
+}
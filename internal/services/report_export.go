@@ -0,0 +1,225 @@
+package services
+
+import (
+	"swipeup-be/pkg/utils"
+	"time"
+)
+
+// exportBatchSize is how many transactions are loaded at a time while streaming an export
+const exportBatchSize = 500
+
+// reportPeriod describes a report's date range in the school's timezone
+func reportPeriod(startDate, endDate time.Time) string {
+	if startDate.IsZero() || endDate.IsZero() {
+		return "Periode: semua waktu"
+	}
+	loc := utils.SchoolLocation()
+	return "Periode: " + startDate.In(loc).Format("2006-01-02") + " s.d. " + endDate.In(loc).Format("2006-01-02")
+}
+
+// Table renders the revenue report for export
+func (r *RevenueReport) Table() *utils.ReportTable {
+	table := StanRevenueTable(r.StanRevenues, r.StartDate, r.EndDate)
+	table.Title = "Laporan Pendapatan"
+	table.Totals = []interface{}{"TOTAL", "", r.TotalOrders, r.TotalRevenue}
+	return table
+}
+
+// StanRevenueTable renders the revenue per stan for export
+func StanRevenueTable(revenues []StanRevenue, startDate, endDate time.Time) *utils.ReportTable {
+	table := &utils.ReportTable{ReportHeader: utils.ReportHeader{
+		Title:   "Pendapatan per Stan",
+		Period:  reportPeriod(startDate, endDate),
+		Columns: []string{"ID Stan", "Nama Stan", "Orders", "Revenue"},
+	}}
+	var orders int
+	var revenue float64
+	for _, r := range revenues {
+		table.Rows = append(table.Rows, []interface{}{r.StanID, r.NamaStan, r.TotalOrders, r.TotalRevenue})
+		orders += r.TotalOrders
+		revenue += r.TotalRevenue
+	}
+	table.Totals = []interface{}{"TOTAL", "", orders, revenue}
+	return table
+}
+
+// StanStatisticsTable renders stan statistics for export
+func StanStatisticsTable(statistics []StanStatistics, startDate, endDate time.Time) *utils.ReportTable {
+	table := &utils.ReportTable{ReportHeader: utils.ReportHeader{
+		Title:   "Statistik Stan",
+		Period:  reportPeriod(startDate, endDate),
+		Columns: []string{"ID Stan", "Nama Stan", "Total Menu", "Menu Tersedia", "Orders", "Revenue", "Rata-rata Order"},
+	}}
+	var orders int
+	var revenue float64
+	for _, s := range statistics {
+		table.Rows = append(table.Rows, []interface{}{s.StanID, s.NamaStan, s.TotalMenu, s.AvailableMenu, s.TotalOrders, s.TotalRevenue, s.AverageOrder})
+		orders += s.TotalOrders
+		revenue += s.TotalRevenue
+	}
+	if len(statistics) > 1 {
+		table.Totals = []interface{}{"TOTAL", "", "", "", orders, revenue, averageOrder(revenue, orders)}
+	}
+	return table
+}
+
+// DiskonUsageTable renders discount usage for export, one row per discount and stan
+func DiskonUsageTable(usages []DiskonUsage, startDate, endDate time.Time) *utils.ReportTable {
+	table := &utils.ReportTable{ReportHeader: utils.ReportHeader{
+		Title:   "Pemakaian Diskon",
+		Period:  reportPeriod(startDate, endDate),
+		Columns: []string{"Diskon", "Tipe", "Stan", "Redemptions", "Item Diskon", "Subsidi", "Orders", "Perubahan Orders"},
+	}}
+	var redemptions, items, orderChange int
+	var subsidi float64
+	for _, u := range usages {
+		for _, stan := range u.Stans {
+			table.Rows = append(table.Rows, []interface{}{u.NamaDiskon, string(u.TipeDiskon), stan.NamaStan, stan.Redemptions, stan.ItemsDiscounted, stan.TotalSubsidi, stan.TotalOrders, stan.OrderChange})
+		}
+		redemptions += u.Redemptions
+		items += u.ItemsDiscounted
+		subsidi += u.TotalSubsidi
+		orderChange += u.OrderChange
+	}
+	table.Totals = []interface{}{"TOTAL", "", "", redemptions, items, subsidi, "", orderChange}
+	return table
+}
+
+// Table renders the time series for export, with the previous period's bucket on the same row
+func (ts *TimeSeries) Table() *utils.ReportTable {
+	table := &utils.ReportTable{ReportHeader: utils.ReportHeader{
+		Title:   "Penjualan per " + string(ts.Bucket),
+		Period:  reportPeriod(ts.Current.StartDate, ts.Current.EndDate) + " (" + ts.Timezone + ")",
+		Columns: []string{"Periode", "Revenue", "Orders", "Rata-rata Order", "Items", "Revenue Sebelumnya", "Orders Sebelumnya"},
+	}}
+	for i, point := range ts.Current.Points {
+		row := []interface{}{point.Periode, point.Revenue, point.Orders, point.AvgOrder, point.Items, "", ""}
+		if i < len(ts.Previous.Points) {
+			row[5] = ts.Previous.Points[i].Revenue
+			row[6] = ts.Previous.Points[i].Orders
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	totals := ts.Current.Totals
+	table.Totals = []interface{}{"TOTAL", totals.Revenue, totals.Orders, totals.AvgOrder, totals.Items, ts.Previous.Totals.Revenue, ts.Previous.Totals.Orders}
+	return table
+}
+
+// Table renders the menu ranking for export, followed by the menus that did not sell
+func (r *MenuPerformanceReport) Table() *utils.ReportTable {
+	table := &utils.ReportTable{ReportHeader: utils.ReportHeader{
+		Title:   "Performa Menu (urut " + string(r.SortBy) + ")",
+		Period:  reportPeriod(r.StartDate, r.EndDate),
+		Columns: []string{"Rank", "Menu", "Stan", "Qty", "Revenue", "Pembeli", "Orders", "Qty Diskon", "Potongan", "% Diskon", "% Revenue"},
+	}}
+	for _, menus := range [][]MenuPerformance{r.Menus, r.NeverSold} {
+		for _, m := range menus {
+			var rank interface{} = "-"
+			if m.Rank > 0 {
+				rank = m.Rank
+			}
+			table.Rows = append(table.Rows, []interface{}{rank, m.NamaMakanan, m.NamaStan, m.Qty, m.Revenue, m.Buyers, m.Orders, m.QtyDiskon, m.Potongan, m.DiskonShare, m.RevenueShare})
+		}
+	}
+	t := r.Totals
+	table.Totals = []interface{}{"TOTAL", "", "", t.Qty, t.Revenue, t.Buyers, t.Orders, t.QtyDiskon, t.Potongan, t.DiskonShare, 100.0}
+	return table
+}
+
+// Table renders the heatmap for export, one row per weekday and hour
+func (h *PeakHourHeatmap) Table() *utils.ReportTable {
+	table := &utils.ReportTable{ReportHeader: utils.ReportHeader{
+		Title:   "Jam Sibuk",
+		Period:  reportPeriod(h.StartDate, h.EndDate) + " (" + h.Timezone + ")",
+		Columns: []string{"Hari", "Jam", "Orders", "Rata-rata Tunggu (menit)", "Rata-rata Masak (menit)"},
+	}}
+	for hari, cells := range h.Cells {
+		for jam, cell := range cells {
+			table.Rows = append(table.Rows, []interface{}{h.Hari[hari], jam, cell.Orders, cell.AvgWaitMinutes, cell.AvgPrepMinutes})
+		}
+	}
+	table.Totals = []interface{}{"TOTAL", "", h.TotalOrders, h.AvgWaitMinutes, h.AvgPrepMinutes}
+	return table
+}
+
+// Table renders the waste report for export, menus first and then ingredients
+func (r *WasteReport) Table() *utils.ReportTable {
+	table := &utils.ReportTable{ReportHeader: utils.ReportHeader{
+		Title:   "Laporan Waste",
+		Period:  reportPeriod(r.StartDate, r.EndDate),
+		Columns: []string{"Jenis", "Nama", "Qty Waste", "Nilai Waste", "Qty Terjual", "Revenue", "% Waste"},
+	}}
+	for _, m := range r.PerMenu {
+		table.Rows = append(table.Rows, []interface{}{"Menu", m.NamaMakanan, m.QtyWaste, m.NilaiWaste, m.QtyTerjual, m.Revenue, m.RasioWaste})
+	}
+	for _, i := range r.PerIngredient {
+		table.Rows = append(table.Rows, []interface{}{"Bahan", i.Nama + " (" + i.Satuan + ")", i.QtyWaste, i.NilaiWaste, "", "", ""})
+	}
+	table.Totals = []interface{}{"TOTAL", "", "", r.TotalNilaiWaste, "", r.TotalRevenue, r.RasioWaste}
+	return table
+}
+
+// TransactionReportHeader describes the transaction list export
+func TransactionReportHeader(startDate, endDate time.Time) utils.ReportHeader {
+	return utils.ReportHeader{
+		Title:   "Daftar Transaksi",
+		Period:  reportPeriod(startDate, endDate),
+		Columns: []string{"ID", "Tanggal", "Stan", "Siswa", "Status", "Items", "Potongan", "Total"},
+	}
+}
+
+// ExportTransactions writes one row per transaction in the period to w, loading them in
+// batches so long periods are not held in memory, and closes w with the totals.
+// stanID 0 covers all stans.
+func (s *AnalyticsService) ExportTransactions(stanID uint, startDate, endDate time.Time, w utils.ReportWriter) error {
+	var totalItems int
+	var totalPotongan, totalRevenue float64
+	var lastID uint
+	for {
+		var rows []struct {
+			ID       uint
+			Tanggal  time.Time
+			NamaStan string
+			Siswa    string
+			Status   string
+			Items    int
+			Potongan float64
+			Total    float64
+		}
+		query := s.db.Table("transaksis").
+			Select("transaksis.id, transaksis.tanggal, stans.nama_stan, siswas.nama_siswa AS siswa, transaksis.status, "+
+				"COALESCE(SUM(detail_transaksis.qty), 0) AS items, COALESCE(SUM(detail_transaksis.potongan), 0) AS potongan, "+
+				"COALESCE(SUM(detail_transaksis.qty * detail_transaksis.harga_beli), 0) AS total").
+			Joins("JOIN stans ON stans.id = transaksis.id_stan").
+			Joins("JOIN siswas ON siswas.id = transaksis.id_siswa").
+			Joins("LEFT JOIN detail_transaksis ON detail_transaksis.id_transaksi = transaksis.id AND detail_transaksis.deleted_at IS NULL").
+			Where("transaksis.deleted_at IS NULL AND transaksis.id > ?", lastID)
+		if stanID != 0 {
+			query = query.Where("transaksis.id_stan = ?", stanID)
+		}
+		if !startDate.IsZero() && !endDate.IsZero() {
+			query = query.Where("transaksis.tanggal BETWEEN ? AND ?", startDate, endDate)
+		}
+		err := query.Group("transaksis.id, stans.nama_stan, siswas.nama_siswa").
+			Order("transaksis.id").
+			Limit(exportBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := w.WriteRow([]interface{}{row.ID, row.Tanggal, row.NamaStan, row.Siswa, row.Status, row.Items, row.Potongan, row.Total}); err != nil {
+				return err
+			}
+			totalItems += row.Items
+			totalPotongan += row.Potongan
+			totalRevenue += row.Total
+		}
+		if len(rows) < exportBatchSize {
+			break
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+	return w.Close([]interface{}{"TOTAL", "", "", "", "", totalItems, totalPotongan, totalRevenue})
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReviewService struct {
+	db *gorm.DB
+}
+
+func NewReviewService(db *gorm.DB) *ReviewService {
+	return &ReviewService{db: db}
+}
+
+// CreateReview saves a siswa's review of a transaksi. The order must belong to the siswa,
+// have reached status "sampai" and not have been reviewed yet. Item ratings are optional
+// and must refer to lines of the same order.
+func (s *ReviewService) CreateReview(siswaID uint, transaksiID uint, review *models.Review) error {
+	var transaksi models.Transaksi
+	if err := s.db.Preload("DetailTransaksi").First(&transaksi, transaksiID).Error; err != nil {
+		return err
+	}
+	if transaksi.IDSiswa != siswaID {
+		return gorm.ErrRecordNotFound
+	}
+	if transaksi.Status != models.StatusSampai {
+		return fmt.Errorf("%w: order has not arrived yet", ErrReviewNotAllowed)
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.Review{}).Where("id_transaksi = ?", transaksiID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return fmt.Errorf("%w: order has already been reviewed", ErrReviewNotAllowed)
+	}
+
+	if err := validateRating(review.Rating); err != nil {
+		return err
+	}
+	komentar, err := utils.SanitizeReview(review.Komentar)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReview, err)
+	}
+
+	details := make(map[uint]models.DetailTransaksi, len(transaksi.DetailTransaksi))
+	for _, detail := range transaksi.DetailTransaksi {
+		details[detail.ID] = detail
+	}
+	rated := make(map[uint]bool, len(review.Items))
+	menuIDs := make([]uint, 0, len(review.Items))
+	for i := range review.Items {
+		item := &review.Items[i]
+		detail, ok := details[item.IDDetailTransaksi]
+		if !ok {
+			return fmt.Errorf("%w: item %d is not part of this order", ErrInvalidReview, item.IDDetailTransaksi)
+		}
+		if rated[item.IDDetailTransaksi] {
+			return fmt.Errorf("%w: item %d is rated more than once", ErrInvalidReview, item.IDDetailTransaksi)
+		}
+		rated[item.IDDetailTransaksi] = true
+		if err := validateRating(item.Rating); err != nil {
+			return err
+		}
+		if item.Komentar, err = utils.SanitizeReview(item.Komentar); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReview, err)
+		}
+		item.IDMenu = detail.IDMenu
+		menuIDs = append(menuIDs, detail.IDMenu)
+	}
+
+	review.ID = 0
+	review.IDTransaksi = transaksi.ID
+	review.IDSiswa = siswaID
+	review.IDStan = transaksi.IDStan
+	review.Komentar = komentar
+	review.Balasan = ""
+	review.BalasanAt = nil
+	review.IsHidden = false
+	review.AlasanHidden = ""
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			// A concurrent request reviewed the order between the check above and the insert
+			if strings.Contains(err.Error(), "idx_reviews_id_transaksi") {
+				return fmt.Errorf("%w: order has already been reviewed", ErrReviewNotAllowed)
+			}
+			return err
+		}
+		return refreshRatings(tx, review.IDStan, menuIDs)
+	})
+}
+
+// validateRating checks that a rating is within MinRating and MaxRating
+func validateRating(rating int) error {
+	if rating < models.MinRating || rating > models.MaxRating {
+		return fmt.Errorf("%w: rating must be between %d and %d", ErrInvalidReview, models.MinRating, models.MaxRating)
+	}
+	return nil
+}
+
+// refreshRatings recomputes the denormalized rating averages of a stan and the given menus
+// from reviews that are not hidden
+func refreshRatings(tx *gorm.DB, stanID uint, menuIDs []uint) error {
+	err := tx.Exec(`
+		UPDATE stans SET
+			rating_avg = COALESCE((SELECT AVG(r.rating) FROM reviews r
+				WHERE r.id_stan = stans.id AND r.is_hidden = false AND r.deleted_at IS NULL), 0),
+			rating_count = (SELECT COUNT(*) FROM reviews r
+				WHERE r.id_stan = stans.id AND r.is_hidden = false AND r.deleted_at IS NULL)
+		WHERE id = ?`, stanID).Error
+	if err != nil || len(menuIDs) == 0 {
+		return err
+	}
+
+	return tx.Exec(`
+		UPDATE menus SET
+			rating_avg = COALESCE((SELECT AVG(ri.rating) FROM review_items ri
+				JOIN reviews r ON r.id = ri.id_review
+				WHERE ri.id_menu = menus.id AND r.is_hidden = false AND r.deleted_at IS NULL), 0),
+			rating_count = (SELECT COUNT(*) FROM review_items ri
+				JOIN reviews r ON r.id = ri.id_review
+				WHERE ri.id_menu = menus.id AND r.is_hidden = false AND r.deleted_at IS NULL)
+		WHERE id IN ?`, menuIDs).Error
+}
+
+// GetByTransaksiID retrieves the review of a transaksi
+func (s *ReviewService) GetByTransaksiID(transaksiID uint) (*models.Review, error) {
+	var review models.Review
+	if err := s.db.Preload("Items.Menu").Where("id_transaksi = ?", transaksiID).First(&review).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetByID retrieves a review with its item ratings
+func (s *ReviewService) GetByID(id uint) (*models.Review, error) {
+	var review models.Review
+	if err := s.db.Preload("Items.Menu").First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetByStanID retrieves a page of a stan's reviews, newest first.
+// Hidden reviews are only included when includeHidden is set.
+func (s *ReviewService) GetByStanID(stanID uint, includeHidden bool, limit, offset int) ([]models.Review, int64, error) {
+	query := s.db.Model(&models.Review{}).Where("id_stan = ?", stanID)
+	if !includeHidden {
+		query = query.Where("is_hidden = ?", false)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.Review
+	err := query.Preload("Items.Menu").
+		Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, s.attachNamaSiswa(reviews)
+}
+
+// GetByMenuID retrieves a page of visible reviews that rated the menu, newest first
+func (s *ReviewService) GetByMenuID(menuID uint, limit, offset int) ([]models.Review, int64, error) {
+	query := s.db.Model(&models.Review{}).
+		Where("is_hidden = ?", false).
+		Where("id IN (?)", s.db.Model(&models.ReviewItem{}).Select("id_review").Where("id_menu = ?", menuID))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.Review
+	err := query.Preload("Items", "id_menu = ?", menuID).
+		Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, s.attachNamaSiswa(reviews)
+}
+
+// attachNamaSiswa fills in the reviewer names without exposing the rest of the siswa profile
+func (s *ReviewService) attachNamaSiswa(reviews []models.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.IDSiswa)
+	}
+
+	var siswas []models.Siswa
+	if err := s.db.Select("id", "nama_siswa").Where("id IN ?", ids).Find(&siswas).Error; err != nil {
+		return err
+	}
+
+	names := make(map[uint]string, len(siswas))
+	for _, siswa := range siswas {
+		names[siswa.ID] = siswa.NamaSiswa
+	}
+	for i := range reviews {
+		reviews[i].NamaSiswa = names[reviews[i].IDSiswa]
+	}
+	return nil
+}
+
+// Reply sets the stan's reply to a review
+func (s *ReviewService) Reply(reviewID uint, balasan string) error {
+	balasan, err := utils.SanitizeReview(balasan)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReview, err)
+	}
+	if balasan == "" {
+		return fmt.Errorf("%w: reply must not be empty", ErrInvalidReview)
+	}
+	return s.db.Model(&models.Review{}).Where("id = ?", reviewID).Updates(map[string]interface{}{
+		"balasan":    balasan,
+		"balasan_at": time.Now().UTC(),
+	}).Error
+}
+
+// SetHidden hides or unhides a review and refreshes the affected rating averages
+func (s *ReviewService) SetHidden(reviewID uint, hidden bool, alasan string) error {
+	review, err := s.GetByID(reviewID)
+	if err != nil {
+		return err
+	}
+	if !hidden {
+		alasan = ""
+	}
+	if alasan, err = utils.SanitizeReason(alasan); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReview, err)
+	}
+
+	menuIDs := make([]uint, 0, len(review.Items))
+	for _, item := range review.Items {
+		menuIDs = append(menuIDs, item.IDMenu)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Review{}).Where("id = ?", reviewID).Updates(map[string]interface{}{
+			"is_hidden":     hidden,
+			"alasan_hidden": alasan,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return refreshRatings(tx, review.IDStan, menuIDs)
+	})
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// priceChangeInterval is how often due scheduled price changes are applied
+	priceChangeInterval = time.Minute
+	// stockResetInterval is how often due daily stock resets are applied
+	stockResetInterval = time.Minute
+	// recommendationInterval is how often the recommendation tables are recomputed
+	recommendationInterval = time.Hour
+)
+
+// StartSchedulers starts the background jobs, each in its own goroutine, until ctx is
+// cancelled: scheduled price changes, daily stock resets and the recommendation refresh.
+// Call it once at startup with the RecommendationService given to NewRecommendationHandler,
+// so the handler serves the refreshed tables.
+func StartSchedulers(ctx context.Context, db *gorm.DB, recommendations *RecommendationService) {
+	go NewMenuPriceService(db).RunScheduler(ctx, priceChangeInterval)
+	go NewStockResetService(db).RunScheduler(ctx, stockResetInterval)
+	go recommendations.RunScheduler(ctx, recommendationInterval)
+}
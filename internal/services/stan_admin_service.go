@@ -1,7 +1,9 @@
 package services
 
 import (
+	"fmt"
 	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,10 +11,22 @@ import (
 
 // StanAdminService provides stan_admin-specific operations
 type StanAdminService struct {
-	db            *gorm.DB
-	stanService   *StanService
-	menuService   *MenuService
-	diskonService *DiskonService
+	db                *gorm.DB
+	stanService       *StanService
+	menuService       *MenuService
+	diskonService     *DiskonService
+	optionService     *MenuOptionService
+	bundleService     *MenuBundleService
+	importService     *MenuImportService
+	priceService      *MenuPriceService
+	reviewService     *ReviewService
+	stockService      *StockService
+	notifService      *NotificationService
+	resetService      *StockResetService
+	ingredientService *IngredientService
+	opnameService     *StockOpnameService
+	wasteService      *WasteService
+	analyticsService  *AnalyticsService
 }
 
 func NewStanAdminService(
@@ -22,10 +36,22 @@ func NewStanAdminService(
 	diskonService *DiskonService,
 ) *StanAdminService {
 	return &StanAdminService{
-		db:            db,
-		stanService:   stanService,
-		menuService:   menuService,
-		diskonService: diskonService,
+		db:                db,
+		stanService:       stanService,
+		menuService:       menuService,
+		diskonService:     diskonService,
+		optionService:     NewMenuOptionService(db),
+		bundleService:     NewMenuBundleService(db),
+		importService:     NewMenuImportService(db),
+		priceService:      NewMenuPriceService(db),
+		reviewService:     NewReviewService(db),
+		stockService:      NewStockService(db),
+		notifService:      NewNotificationService(db),
+		resetService:      NewStockResetService(db),
+		ingredientService: NewIngredientService(db),
+		opnameService:     NewStockOpnameService(db),
+		wasteService:      NewWasteService(db),
+		analyticsService:  NewAnalyticsService(db),
 	}
 }
 
@@ -70,7 +96,7 @@ func (s *StanAdminService) UpdateMenu(userID uint, menuID uint, updates map[stri
 		return gorm.ErrRecordNotFound
 	}
 
-	return s.menuService.UpdateFields(menuID, updates)
+	return s.menuService.UpdateFieldsBy(menuID, updates, userID)
 }
 
 // DeleteMenu deletes a menu item owned by the stan
@@ -102,6 +128,260 @@ func (s *StanAdminService) GetMenusByStan(userID uint) ([]models.Menu, error) {
 	return s.menuService.GetByStanID(stan.ID)
 }
 
+// getOwnedMenu retrieves a menu and verifies it belongs to the user's stan
+func (s *StanAdminService) getOwnedMenu(userID uint, menuID uint) (*models.Menu, error) {
+	var menu models.Menu
+	if err := s.db.First(&menu, menuID).Error; err != nil {
+		return nil, err
+	}
+
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if menu.IDStan != stan.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &menu, nil
+}
+
+// getOwnedOptionGroup retrieves an option group whose menu belongs to the user's stan
+func (s *StanAdminService) getOwnedOptionGroup(userID uint, groupID uint) (*models.MenuOptionGroup, error) {
+	group, err := s.optionService.FindByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getOwnedMenu(userID, group.IDMenu); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// getOwnedOption retrieves an option whose menu belongs to the user's stan
+func (s *StanAdminService) getOwnedOption(userID uint, optionID uint) (*models.MenuOption, error) {
+	option, err := s.optionService.GetOptionByID(optionID)
+	if err != nil {
+		return nil, err
+	}
+	if option.Group == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if _, err := s.getOwnedMenu(userID, option.Group.IDMenu); err != nil {
+		return nil, err
+	}
+	return option, nil
+}
+
+// GetMenuOptionGroups retrieves the option groups of a menu owned by the stan
+func (s *StanAdminService) GetMenuOptionGroups(userID uint, menuID uint) ([]models.MenuOptionGroup, error) {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return nil, err
+	}
+	return s.optionService.GetGroupsByMenuID(menuID)
+}
+
+// CreateMenuOptionGroup creates an option group (with its options) for a menu owned by the stan
+func (s *StanAdminService) CreateMenuOptionGroup(userID uint, group *models.MenuOptionGroup) error {
+	if _, err := s.getOwnedMenu(userID, group.IDMenu); err != nil {
+		return err
+	}
+	return s.optionService.Create(group)
+}
+
+// UpdateMenuOptionGroup updates an option group owned by the stan
+func (s *StanAdminService) UpdateMenuOptionGroup(userID uint, groupID uint, updates map[string]interface{}) error {
+	if _, err := s.getOwnedOptionGroup(userID, groupID); err != nil {
+		return err
+	}
+	return s.optionService.UpdateGroupFields(groupID, updates)
+}
+
+// DeleteMenuOptionGroup deletes an option group owned by the stan
+func (s *StanAdminService) DeleteMenuOptionGroup(userID uint, groupID uint) error {
+	if _, err := s.getOwnedOptionGroup(userID, groupID); err != nil {
+		return err
+	}
+	return s.optionService.DeleteGroup(groupID)
+}
+
+// CreateMenuOption adds an option to a group owned by the stan
+func (s *StanAdminService) CreateMenuOption(userID uint, option *models.MenuOption) error {
+	if _, err := s.getOwnedOptionGroup(userID, option.IDGroup); err != nil {
+		return err
+	}
+	return s.optionService.CreateOption(option)
+}
+
+// UpdateMenuOption updates an option owned by the stan
+func (s *StanAdminService) UpdateMenuOption(userID uint, optionID uint, updates map[string]interface{}) error {
+	if _, err := s.getOwnedOption(userID, optionID); err != nil {
+		return err
+	}
+	return s.optionService.UpdateOptionFields(optionID, updates)
+}
+
+// DeleteMenuOption deletes an option owned by the stan
+func (s *StanAdminService) DeleteMenuOption(userID uint, optionID uint) error {
+	if _, err := s.getOwnedOption(userID, optionID); err != nil {
+		return err
+	}
+	return s.optionService.DeleteOption(optionID)
+}
+
+// ImportMenus validates and applies spreadsheet rows to the stan's menus
+func (s *StanAdminService) ImportMenus(userID uint, records [][]string, dryRun bool) (*MenuImportResult, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, parseErrors := ParseMenuRows(records)
+	return s.importService.ImportMenus(stan.ID, userID, rows, parseErrors, dryRun)
+}
+
+// ExportMenus returns the stan's menus as spreadsheet records in the import format
+func (s *StanAdminService) ExportMenus(userID uint) ([][]string, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.importService.ExportMenus(stan.ID)
+}
+
+// GetMenuPriceHistory retrieves the price history of a menu owned by the stan
+func (s *StanAdminService) GetMenuPriceHistory(userID uint, menuID uint) ([]models.MenuPriceHistory, error) {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return nil, err
+	}
+	return s.priceService.GetHistory(menuID)
+}
+
+// GetScheduledPriceChanges retrieves the scheduled price changes of a menu owned by the stan
+func (s *StanAdminService) GetScheduledPriceChanges(userID uint, menuID uint) ([]models.MenuPriceChange, error) {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return nil, err
+	}
+	return s.priceService.GetScheduledChanges(menuID)
+}
+
+// SchedulePriceChange schedules a future price change for a menu owned by the stan
+func (s *StanAdminService) SchedulePriceChange(userID uint, change *models.MenuPriceChange) error {
+	if _, err := s.getOwnedMenu(userID, change.IDMenu); err != nil {
+		return err
+	}
+	change.IDUser = userID
+	return s.priceService.SchedulePriceChange(change)
+}
+
+// CancelPriceChange cancels a pending price change of a menu owned by the stan
+func (s *StanAdminService) CancelPriceChange(userID uint, changeID uint) error {
+	change, err := s.priceService.GetScheduledChangeByID(changeID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.getOwnedMenu(userID, change.IDMenu); err != nil {
+		return err
+	}
+	return s.priceService.CancelScheduledChange(changeID)
+}
+
+// GetMenuSchedules retrieves the availability schedules of a menu owned by the stan
+func (s *StanAdminService) GetMenuSchedules(userID uint, menuID uint) ([]models.MenuSchedule, error) {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return nil, err
+	}
+	return s.menuService.GetSchedules(menuID)
+}
+
+// SetMenuSchedules replaces the availability schedules of a menu owned by the stan
+func (s *StanAdminService) SetMenuSchedules(userID uint, menuID uint, schedules []models.MenuSchedule) error {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return err
+	}
+	return s.menuService.ReplaceSchedules(menuID, schedules)
+}
+
+// verifyBundleItems checks that every component menu of a bundle belongs to the stan
+func (s *StanAdminService) verifyBundleItems(userID uint, items []models.MenuBundleItem) error {
+	for _, item := range items {
+		if _, err := s.getOwnedMenu(userID, item.IDMenu); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOwnedBundle retrieves a bundle owned by the user's stan
+func (s *StanAdminService) getOwnedBundle(userID uint, bundleID uint) (*models.MenuBundle, error) {
+	bundle, err := s.bundleService.FindByID(bundleID)
+	if err != nil {
+		return nil, err
+	}
+
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bundle.IDStan != stan.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return bundle, nil
+}
+
+// GetBundlesByStan retrieves all bundles of the stan with derived stock
+func (s *StanAdminService) GetBundlesByStan(userID uint) ([]models.MenuBundle, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.bundleService.GetByStanID(stan.ID)
+}
+
+// CreateBundle creates a bundle of the stan's own menus
+func (s *StanAdminService) CreateBundle(userID uint, bundle *models.MenuBundle) error {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyBundleItems(userID, bundle.Items); err != nil {
+		return err
+	}
+	bundle.IDStan = stan.ID
+	return s.bundleService.Create(bundle)
+}
+
+// UpdateBundle updates a bundle owned by the stan; items replace the components when not nil
+func (s *StanAdminService) UpdateBundle(userID uint, bundleID uint, updates map[string]interface{}, items []models.MenuBundleItem) error {
+	if _, err := s.getOwnedBundle(userID, bundleID); err != nil {
+		return err
+	}
+
+	if items != nil {
+		if err := s.verifyBundleItems(userID, items); err != nil {
+			return err
+		}
+		if err := s.bundleService.ReplaceItems(bundleID, items); err != nil {
+			return err
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.bundleService.UpdateFields(bundleID, updates)
+}
+
+// DeleteBundle deletes a bundle owned by the stan
+func (s *StanAdminService) DeleteBundle(userID uint, bundleID uint) error {
+	if _, err := s.getOwnedBundle(userID, bundleID); err != nil {
+		return err
+	}
+	return s.bundleService.DeleteBundle(bundleID)
+}
+
 // CreateStanDiscount creates a new stan-level discount
 func (s *StanAdminService) CreateStanDiscount(userID uint, diskon *models.Diskon) error {
 	stan, err := s.stanService.GetByUserID(userID)
@@ -147,6 +427,17 @@ func (s *StanAdminService) CreateMenuDiscount(userID uint, diskon *models.Diskon
 	return nil
 }
 
+// CreateCategoryDiscount creates a discount targeting menus of the stan by jenis and/or tag
+func (s *StanAdminService) CreateCategoryDiscount(userID uint, diskon *models.Diskon) error {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	diskon.TipeDiskon = models.DiskonKategori
+	diskon.IDStan = &stan.ID
+	return s.diskonService.Create(diskon)
+}
+
 // UpdateDiscount updates a discount owned by the stan
 func (s *StanAdminService) UpdateDiscount(userID uint, diskonID uint, updates map[string]interface{}) error {
 	// Verify discount belongs to stan
@@ -212,8 +503,188 @@ func (s *StanAdminService) GetTransactionsByStan(userID uint) ([]models.Transaks
 		return nil, err
 	}
 
-	var transaksiService TransaksiService
-	return transaksiService.GetByStanID(stan.ID)
+	var transaksi []models.Transaksi
+	err = s.db.Preload("Siswa").Preload("DetailTransaksi.Menu").Preload("DetailTransaksi.Options").
+		Where("id_stan = ?", stan.ID).
+		Order("tanggal DESC").
+		Find(&transaksi).Error
+	return transaksi, err
+}
+
+// KitchenQueueLine is a single item to prepare, with the student's options and note
+type KitchenQueueLine struct {
+	NamaMakanan string   `json:"nama_makanan"`
+	NamaPaket   string   `json:"nama_paket,omitempty"`
+	Qty         int      `json:"qty"`
+	Opsi        []string `json:"opsi,omitempty"`
+	Catatan     string   `json:"catatan,omitempty"`
+}
+
+// KitchenQueueOrder is an open order in the stan's kitchen queue
+type KitchenQueueOrder struct {
+	IDTransaksi uint                   `json:"id_transaksi"`
+	Tanggal     time.Time              `json:"tanggal"`
+	Status      models.StatusTransaksi `json:"status"`
+	NamaSiswa   string                 `json:"nama_siswa"`
+	Items       []KitchenQueueLine     `json:"items"`
+}
+
+// manualStockChange validates a stock change made by the stan admin
+func manualStockChange(userID uint, reason models.StockReason, delta int, keterangan string) (StockChange, error) {
+	if err := ValidateManualChange(reason, delta); err != nil {
+		return StockChange{}, err
+	}
+	keterangan, err := utils.SanitizeNote(keterangan)
+	if err != nil {
+		return StockChange{}, fmt.Errorf("%w: %v", ErrInvalidStockChange, err)
+	}
+	return StockChange{Reason: reason, IDUser: userID, Keterangan: keterangan}, nil
+}
+
+// checkNoRecipe rejects manual stock changes of a menu whose stock follows its recipe's ingredients
+func (s *StanAdminService) checkNoRecipe(menuID uint) error {
+	hasRecipe, err := s.ingredientService.HasRecipe(menuID)
+	if err != nil {
+		return err
+	}
+	if hasRecipe {
+		return fmt.Errorf("%w: stock of a menu with a recipe follows its ingredient stock", ErrInvalidStockChange)
+	}
+	return nil
+}
+
+// UpdateStock sets the stock of a menu owned by the stan
+func (s *StanAdminService) UpdateStock(userID uint, menuID uint, stock int, reason models.StockReason, keterangan string) error {
+	menu, err := s.getOwnedMenu(userID, menuID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNoRecipe(menuID); err != nil {
+		return err
+	}
+	change, err := manualStockChange(userID, reason, stock-menu.Stock, keterangan)
+	if err != nil {
+		return err
+	}
+	if reason == models.StockWaste {
+		return s.wasteStock(userID, menuID, stock-menu.Stock, change.Keterangan)
+	}
+	return s.menuService.UpdateStock(menuID, stock, change)
+}
+
+// AdjustStock adjusts the stock of a menu owned by the stan by delta
+func (s *StanAdminService) AdjustStock(userID uint, menuID uint, delta int, reason models.StockReason, keterangan string) error {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return err
+	}
+	if err := s.checkNoRecipe(menuID); err != nil {
+		return err
+	}
+	change, err := manualStockChange(userID, reason, delta, keterangan)
+	if err != nil {
+		return err
+	}
+	if reason == models.StockWaste {
+		return s.wasteStock(userID, menuID, delta, change.Keterangan)
+	}
+	return s.menuService.AdjustStock(menuID, delta, change)
+}
+
+// GetStockHistory retrieves a page of the stock ledger of a menu owned by the stan
+func (s *StanAdminService) GetStockHistory(userID uint, menuID uint, limit, offset int) ([]models.StockMovement, int64, error) {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return nil, 0, err
+	}
+	return s.stockService.GetHistory(menuID, limit, offset)
+}
+
+// GetStockDrift lists the stan's menus whose stock differs from their stock ledger
+func (s *StanAdminService) GetStockDrift(userID uint) ([]StockDrift, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.stockService.GetDrift(stan.ID)
+}
+
+// RebuildStock resets the stock of a menu owned by the stan from its stock ledger
+func (s *StanAdminService) RebuildStock(userID uint, menuID uint) (*StockDrift, error) {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return nil, err
+	}
+	return s.stockService.RebuildStock(menuID)
+}
+
+// GetReviews retrieves a page of the stan's reviews, including hidden ones
+func (s *StanAdminService) GetReviews(userID uint, limit, offset int) ([]models.Review, int64, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.reviewService.GetByStanID(stan.ID, true, limit, offset)
+}
+
+// ReplyReview replies to a review of the stan
+func (s *StanAdminService) ReplyReview(userID uint, reviewID uint, balasan string) error {
+	review, err := s.reviewService.GetByID(reviewID)
+	if err != nil {
+		return err
+	}
+
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	if review.IDStan != stan.ID {
+		return gorm.ErrRecordNotFound
+	}
+
+	return s.reviewService.Reply(reviewID, balasan)
+}
+
+// GetKitchenQueue retrieves orders still to be prepared, oldest first
+func (s *StanAdminService) GetKitchenQueue(userID uint) ([]KitchenQueueOrder, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var transaksi []models.Transaksi
+	err = s.db.Preload("Siswa").Preload("DetailTransaksi.Menu").Preload("DetailTransaksi.Options").Preload("DetailTransaksi.Bundle").
+		Where("id_stan = ? AND status IN ?", stan.ID, []models.StatusTransaksi{models.StatusBelumDikonfirm, models.StatusDimasak}).
+		Order("tanggal ASC").
+		Find(&transaksi).Error
+	if err != nil {
+		return nil, err
+	}
+
+	queue := make([]KitchenQueueOrder, 0, len(transaksi))
+	for _, t := range transaksi {
+		order := KitchenQueueOrder{
+			IDTransaksi: t.ID,
+			Tanggal:     t.Tanggal,
+			Status:      t.Status,
+			NamaSiswa:   t.Siswa.NamaSiswa,
+		}
+		for _, detail := range t.DetailTransaksi {
+			line := KitchenQueueLine{
+				NamaMakanan: detail.Menu.NamaMakanan,
+				Qty:         detail.Qty,
+				Catatan:     detail.Catatan,
+			}
+			if detail.Bundle != nil {
+				line.NamaPaket = detail.Bundle.NamaBundle
+			}
+			for _, option := range detail.Options {
+				line.Opsi = append(line.Opsi, option.NamaGroup+": "+option.NamaOpsi)
+			}
+			order.Items = append(order.Items, line)
+		}
+		queue = append(queue, order)
+	}
+
+	return queue, nil
 }
 
 // GetTransactionsByStanAndDateRange retrieves transactions for the stan within a date range
@@ -224,7 +695,7 @@ func (s *StanAdminService) GetTransactionsByStanAndDateRange(userID uint, startD
 	}
 
 	var transaksi []models.Transaksi
-	query := s.db.Preload("Siswa").Preload("DetailTransaksi").Preload("DetailTransaksi.Menu").
+	query := s.db.Preload("Siswa").Preload("DetailTransaksi").Preload("DetailTransaksi.Menu").Preload("DetailTransaksi.Options").
 		Where("id_stan = ?", stan.ID)
 
 	if !startDate.IsZero() && !endDate.IsZero() {
@@ -252,8 +723,7 @@ func (s *StanAdminService) UpdateTransactionStatus(userID uint, transaksiID uint
 		return gorm.ErrRecordNotFound
 	}
 
-	var transaksiService TransaksiService
-	return transaksiService.UpdateStatus(transaksiID, status)
+	return NewTransaksiService(s.db).UpdateStatus(transaksiID, status)
 }
 
 // GetStanRevenue retrieves revenue for the stan
@@ -300,3 +770,330 @@ func (s *StanAdminService) UpdatePaymentSettings(userID uint, acceptCash, accept
 
 	return s.stanService.UpdateFields(stan.ID, updates)
 }
+
+// GetLowStock retrieves the stan's menus at or below their stok_minimum, and those sold out
+func (s *StanAdminService) GetLowStock(userID uint) ([]models.Menu, []models.Menu, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.stockService.GetLowStock(stan.ID)
+}
+
+// GetNotifications retrieves a page of the stan admin's notifications
+func (s *StanAdminService) GetNotifications(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error) {
+	return s.notifService.GetByUserID(userID, unreadOnly, limit, offset)
+}
+
+// MarkNotificationRead marks one of the stan admin's notifications as read
+func (s *StanAdminService) MarkNotificationRead(userID uint, id uint) error {
+	return s.notifService.MarkRead(userID, id)
+}
+
+// MarkAllNotificationsRead marks all of the stan admin's notifications as read
+func (s *StanAdminService) MarkAllNotificationsRead(userID uint) error {
+	return s.notifService.MarkAllRead(userID)
+}
+
+// TriggerStockReset resets the stan's menus to their par levels for today, even when
+// today was skipped
+func (s *StanAdminService) TriggerStockReset(userID uint) (*models.StockReset, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	tanggal, _ := ParseResetDate("")
+	return s.resetService.ResetStan(stan.ID, tanggal, models.StockResetManual, userID)
+}
+
+// SkipStockReset stops the scheduler from resetting the stan's stock on a day (today by default).
+// Past days cannot be skipped.
+func (s *StanAdminService) SkipStockReset(userID uint, tanggal string) (*models.StockReset, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tanggal, err = s.upcomingResetDate(tanggal); err != nil {
+		return nil, err
+	}
+	return s.resetService.SkipDay(stan.ID, tanggal, userID)
+}
+
+// CancelStockResetSkip lets the scheduler reset the stan's stock again on a skipped day
+func (s *StanAdminService) CancelStockResetSkip(userID uint, tanggal string) error {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if tanggal, err = s.upcomingResetDate(tanggal); err != nil {
+		return err
+	}
+	return s.resetService.CancelSkip(stan.ID, tanggal)
+}
+
+// GetStockResets retrieves a page of the stan's daily stock resets and skipped days
+func (s *StanAdminService) GetStockResets(userID uint, limit, offset int) ([]models.StockReset, int64, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.resetService.GetByStanID(stan.ID, limit, offset)
+}
+
+// upcomingResetDate parses a reset date (today by default) and rejects days before today
+func (s *StanAdminService) upcomingResetDate(tanggal string) (string, error) {
+	tanggal, err := ParseResetDate(tanggal)
+	if err != nil {
+		return "", err
+	}
+	if today, _ := ParseResetDate(""); tanggal < today {
+		return "", fmt.Errorf("%w: %s is in the past", ErrInvalidStockReset, tanggal)
+	}
+	return tanggal, nil
+}
+
+// getOwnedIngredient retrieves an ingredient that belongs to the user's stan
+func (s *StanAdminService) getOwnedIngredient(userID uint, ingredientID uint) (*models.Ingredient, error) {
+	ingredient, err := s.ingredientService.GetByID(ingredientID)
+	if err != nil {
+		return nil, err
+	}
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if ingredient.IDStan != stan.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return ingredient, nil
+}
+
+// GetIngredients retrieves the stan's ingredients
+func (s *StanAdminService) GetIngredients(userID uint) ([]models.Ingredient, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ingredientService.GetByStanID(stan.ID)
+}
+
+// CreateIngredient adds an ingredient to the stan
+func (s *StanAdminService) CreateIngredient(userID uint, ingredient *models.Ingredient) error {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	ingredient.IDStan = stan.ID
+	return s.ingredientService.Create(ingredient)
+}
+
+// UpdateIngredient renames an ingredient owned by the stan or changes its unit
+func (s *StanAdminService) UpdateIngredient(userID uint, ingredientID uint, nama, satuan string) (*models.Ingredient, error) {
+	if _, err := s.getOwnedIngredient(userID, ingredientID); err != nil {
+		return nil, err
+	}
+	if err := s.ingredientService.Update(ingredientID, nama, satuan); err != nil {
+		return nil, err
+	}
+	return s.ingredientService.GetByID(ingredientID)
+}
+
+// DeleteIngredient deletes an ingredient owned by the stan that no recipe uses
+func (s *StanAdminService) DeleteIngredient(userID uint, ingredientID uint) error {
+	if _, err := s.getOwnedIngredient(userID, ingredientID); err != nil {
+		return err
+	}
+	return s.ingredientService.Delete(ingredientID)
+}
+
+// PurchaseIngredient records a purchase of an ingredient owned by the stan and adds it to stock
+func (s *StanAdminService) PurchaseIngredient(userID uint, purchase *models.IngredientPurchase) error {
+	if _, err := s.getOwnedIngredient(userID, purchase.IDIngredient); err != nil {
+		return err
+	}
+	keterangan, err := utils.SanitizeNote(purchase.Keterangan)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIngredient, err)
+	}
+	purchase.ID = 0
+	purchase.Keterangan = keterangan
+	purchase.IDUser = userID
+	return s.ingredientService.Purchase(purchase)
+}
+
+// GetIngredientPurchases retrieves a page of the stan's ingredient purchases, optionally of one ingredient
+func (s *StanAdminService) GetIngredientPurchases(userID uint, ingredientID uint, limit, offset int) ([]models.IngredientPurchase, int64, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.ingredientService.GetPurchases(stan.ID, ingredientID, limit, offset)
+}
+
+// GetMenuRecipe retrieves the recipe of a menu owned by the stan
+func (s *StanAdminService) GetMenuRecipe(userID uint, menuID uint) ([]models.RecipeItem, error) {
+	if _, err := s.getOwnedMenu(userID, menuID); err != nil {
+		return nil, err
+	}
+	return s.ingredientService.GetRecipe(menuID)
+}
+
+// SetMenuRecipe replaces the recipe of a menu owned by the stan
+func (s *StanAdminService) SetMenuRecipe(userID uint, menuID uint, items []models.RecipeItem) ([]models.RecipeItem, error) {
+	menu, err := s.getOwnedMenu(userID, menuID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ingredientService.SetRecipe(menu, items); err != nil {
+		return nil, err
+	}
+	return s.ingredientService.GetRecipe(menuID)
+}
+
+// getOwnedOpname retrieves a stock opname of the user's stan
+func (s *StanAdminService) getOwnedOpname(userID uint, opnameID uint) (*models.StockOpname, error) {
+	opname, err := s.opnameService.GetByID(opnameID)
+	if err != nil {
+		return nil, err
+	}
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if opname.IDStan != stan.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return opname, nil
+}
+
+// SubmitStockOpname records a physical count of all the stan's menus and ingredients
+func (s *StanAdminService) SubmitStockOpname(userID uint, counts []OpnameCount, keterangan string) (*models.StockOpname, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	keterangan, err = utils.SanitizeNote(keterangan)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStockOpname, err)
+	}
+	return s.opnameService.Submit(stan.ID, userID, counts, keterangan)
+}
+
+// GetStockOpnames retrieves a page of the stan's stock opnames, optionally by status
+func (s *StanAdminService) GetStockOpnames(userID uint, status string, limit, offset int) ([]models.StockOpname, int64, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.opnameService.GetAll(stan.ID, status, limit, offset)
+}
+
+// GetStockOpname retrieves a stock opname of the stan with its variance per item
+func (s *StanAdminService) GetStockOpname(userID uint, opnameID uint) (*models.StockOpname, error) {
+	return s.getOwnedOpname(userID, opnameID)
+}
+
+// ApproveStockOpname applies the variances of a pending stock opname of the stan
+func (s *StanAdminService) ApproveStockOpname(userID uint, opnameID uint) (*models.StockOpname, error) {
+	if _, err := s.getOwnedOpname(userID, opnameID); err != nil {
+		return nil, err
+	}
+	return s.opnameService.Approve(opnameID, userID)
+}
+
+// RejectStockOpname closes a pending stock opname of the stan without changing stock
+func (s *StanAdminService) RejectStockOpname(userID uint, opnameID uint) (*models.StockOpname, error) {
+	if _, err := s.getOwnedOpname(userID, opnameID); err != nil {
+		return nil, err
+	}
+	return s.opnameService.Reject(opnameID, userID)
+}
+
+// wasteStock records a stock reduction made with the waste reason as a waste entry,
+// so it is valued and shows up in the waste report
+func (s *StanAdminService) wasteStock(userID uint, menuID uint, delta int, keterangan string) error {
+	if delta == 0 {
+		return nil
+	}
+	_, err := s.RecordWaste(userID, &models.WasteEntry{
+		IDMenu:     &menuID,
+		Qty:        float64(-delta),
+		Alasan:     models.WasteLainnya,
+		Keterangan: keterangan,
+	})
+	return err
+}
+
+// RecordWaste records food or ingredients of the stan that were thrown away
+func (s *StanAdminService) RecordWaste(userID uint, entry *models.WasteEntry) (*models.WasteEntry, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	keterangan, err := utils.SanitizeNote(entry.Keterangan)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWaste, err)
+	}
+	entry.ID = 0
+	entry.IDStan = stan.ID
+	entry.IDUser = userID
+	entry.Keterangan = keterangan
+	if err := s.wasteService.Record(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetWasteEntries retrieves a page of the stan's waste entries in the period
+func (s *StanAdminService) GetWasteEntries(userID uint, startDate, endDate time.Time, limit, offset int) ([]models.WasteEntry, int64, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.wasteService.GetByStanID(stan.ID, startDate, endDate, limit, offset)
+}
+
+// GetWasteReport totals the stan's waste in the period per reason, menu and ingredient alongside revenue
+func (s *StanAdminService) GetWasteReport(userID uint, startDate, endDate time.Time) (*WasteReport, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.wasteService.GetReport(stan.ID, startDate, endDate)
+}
+
+// GetSalesTimeSeries buckets the stan's sales in the period and compares them with the previous period
+func (s *StanAdminService) GetSalesTimeSeries(userID uint, bucket Bucket, startDate, endDate time.Time) (*TimeSeries, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.analyticsService.GetTimeSeries(stan.ID, bucket, startDate, endDate)
+}
+
+// GetMenuPerformance ranks the stan's menus by sales in the period and lists the menus that did not sell
+func (s *StanAdminService) GetMenuPerformance(userID uint, sortBy MenuSort, startDate, endDate time.Time) (*MenuPerformanceReport, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.analyticsService.GetMenuPerformance(stan.ID, sortBy, startDate, endDate)
+}
+
+// GetPeakHours builds the stan's weekday × hour matrix of orders and preparation times in the period
+func (s *StanAdminService) GetPeakHours(userID uint, startDate, endDate time.Time) (*PeakHourHeatmap, error) {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.analyticsService.GetPeakHours(stan.ID, startDate, endDate)
+}
+
+// ExportTransactions streams the stan's transactions in the period to w and closes it with the totals
+func (s *StanAdminService) ExportTransactions(userID uint, startDate, endDate time.Time, w utils.ReportWriter) error {
+	stan, err := s.stanService.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	return s.analyticsService.ExportTransactions(stan.ID, startDate, endDate, w)
+}
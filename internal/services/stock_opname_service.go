@@ -0,0 +1,260 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"swipeup-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OpnameCount is the physical count of a menu or an ingredient; exactly one ID is set
+type OpnameCount struct {
+	IDMenu       uint    `json:"id_menu"`
+	IDIngredient uint    `json:"id_ingredient"`
+	StokFisik    float64 `json:"stok_fisik"`
+}
+
+type StockOpnameService struct {
+	db *gorm.DB
+}
+
+func NewStockOpnameService(db *gorm.DB) *StockOpnameService {
+	return &StockOpnameService{db: db}
+}
+
+// Submit records a stocktake of the stan and its variances against current system stock.
+// Every menu whose stock is managed by hand (no recipe) and every ingredient must be counted.
+// A stan can only have one pending opname at a time.
+func (s *StockOpnameService) Submit(stanID uint, userID uint, counts []OpnameCount, keterangan string) (*models.StockOpname, error) {
+	var pending int64
+	err := s.db.Model(&models.StockOpname{}).
+		Where("id_stan = ? AND status = ?", stanID, models.OpnamePending).Count(&pending).Error
+	if err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, fmt.Errorf("%w: approve or reject the pending opname first", ErrInvalidStockOpname)
+	}
+
+	var menus []models.Menu
+	err = s.db.Where("id_stan = ?", stanID).
+		Where("NOT EXISTS (SELECT 1 FROM recipe_items r WHERE r.id_menu = menus.id)").
+		Order("id").Find(&menus).Error
+	if err != nil {
+		return nil, err
+	}
+	var ingredients []models.Ingredient
+	if err := s.db.Where("id_stan = ?", stanID).Order("id").Find(&ingredients).Error; err != nil {
+		return nil, err
+	}
+
+	menuCounts := make(map[uint]float64, len(counts))
+	ingredientCounts := make(map[uint]float64, len(counts))
+	for _, count := range counts {
+		if (count.IDMenu == 0) == (count.IDIngredient == 0) {
+			return nil, fmt.Errorf("%w: each count needs either id_menu or id_ingredient", ErrInvalidStockOpname)
+		}
+		if count.StokFisik < 0 {
+			return nil, fmt.Errorf("%w: stok_fisik must not be negative", ErrInvalidStockOpname)
+		}
+		if count.IDMenu != 0 {
+			if _, ok := menuCounts[count.IDMenu]; ok {
+				return nil, fmt.Errorf("%w: menu %d is counted more than once", ErrInvalidStockOpname, count.IDMenu)
+			}
+			if count.StokFisik != math.Trunc(count.StokFisik) {
+				return nil, fmt.Errorf("%w: stok_fisik of menu %d must be a whole number", ErrInvalidStockOpname, count.IDMenu)
+			}
+			menuCounts[count.IDMenu] = count.StokFisik
+			continue
+		}
+		if _, ok := ingredientCounts[count.IDIngredient]; ok {
+			return nil, fmt.Errorf("%w: ingredient %d is counted more than once", ErrInvalidStockOpname, count.IDIngredient)
+		}
+		ingredientCounts[count.IDIngredient] = count.StokFisik
+	}
+	if len(menuCounts) != len(menus) || len(ingredientCounts) != len(ingredients) {
+		for _, menu := range menus {
+			if _, ok := menuCounts[menu.ID]; !ok {
+				return nil, fmt.Errorf("%w: missing count for menu %q", ErrInvalidStockOpname, menu.NamaMakanan)
+			}
+		}
+		for _, ingredient := range ingredients {
+			if _, ok := ingredientCounts[ingredient.ID]; !ok {
+				return nil, fmt.Errorf("%w: missing count for ingredient %q", ErrInvalidStockOpname, ingredient.Nama)
+			}
+		}
+		return nil, fmt.Errorf("%w: counts include menus or ingredients not stocked by hand in this stan", ErrInvalidStockOpname)
+	}
+
+	ingredientIDs := make([]uint, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		ingredientIDs = append(ingredientIDs, ingredient.ID)
+	}
+	costs, err := ingredientUnitCosts(s.db, ingredientIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	opname := models.StockOpname{
+		IDStan:     stanID,
+		Status:     models.OpnamePending,
+		Keterangan: keterangan,
+		IDUser:     userID,
+	}
+	for _, menu := range menus {
+		menuID := menu.ID
+		opname.Items = append(opname.Items, newOpnameItem(models.StockOpnameItem{
+			IDMenu:      &menuID,
+			Nama:        menu.NamaMakanan,
+			StokSistem:  float64(menu.Stock),
+			StokFisik:   menuCounts[menu.ID],
+			HargaSatuan: menu.Harga,
+		}))
+	}
+	for _, ingredient := range ingredients {
+		ingredientID := ingredient.ID
+		opname.Items = append(opname.Items, newOpnameItem(models.StockOpnameItem{
+			IDIngredient: &ingredientID,
+			Nama:         ingredient.Nama,
+			Satuan:       ingredient.Satuan,
+			StokSistem:   ingredient.Stock,
+			StokFisik:    ingredientCounts[ingredient.ID],
+			HargaSatuan:  costs[ingredient.ID],
+		}))
+	}
+
+	opname.TotalItems = len(opname.Items)
+	for _, item := range opname.Items {
+		if item.Selisih != 0 {
+			opname.VarianceItems++
+			opname.NilaiSelisih += item.NilaiSelisih
+		}
+	}
+
+	if err := s.db.Create(&opname).Error; err != nil {
+		return nil, err
+	}
+	return &opname, nil
+}
+
+// newOpnameItem fills in the variance of a counted item
+func newOpnameItem(item models.StockOpnameItem) models.StockOpnameItem {
+	item.Selisih = item.StokFisik - item.StokSistem
+	item.NilaiSelisih = item.Selisih * item.HargaSatuan
+	return item
+}
+
+// GetByID retrieves an opname with its counted items
+func (s *StockOpnameService) GetByID(id uint) (*models.StockOpname, error) {
+	var opname models.StockOpname
+	err := s.db.Preload("Stan").Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&opname, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &opname, nil
+}
+
+// GetAll retrieves a page of opnames without their items, newest first.
+// stanID and status filter the list when set.
+func (s *StockOpnameService) GetAll(stanID uint, status string, limit, offset int) ([]models.StockOpname, int64, error) {
+	query := s.db.Model(&models.StockOpname{})
+	if stanID != 0 {
+		query = query.Where("id_stan = ?", stanID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var opnames []models.StockOpname
+	err := query.Preload("Stan").Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&opnames).Error
+	return opnames, total, err
+}
+
+// Approve applies the variances of a pending opname as stock corrections. The variance
+// measured at count time is applied, so sales made after counting are kept.
+func (s *StockOpnameService) Approve(id uint, userID uint) (*models.StockOpname, error) {
+	var alerts []*StockAlert
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimOpname(tx, id, userID, models.OpnameApproved); err != nil {
+			return err
+		}
+
+		var items []models.StockOpnameItem
+		if err := tx.Where("id_opname = ? AND selisih <> 0", id).Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+
+		change := StockChange{
+			Reason:     models.StockCorrection,
+			IDUser:     userID,
+			Keterangan: fmt.Sprintf("stock opname #%d", id),
+		}
+		var ingredientIDs []uint
+		for _, item := range items {
+			if item.IDMenu != nil {
+				alert, err := adjustStock(tx, *item.IDMenu, int(item.Selisih), change)
+				if err != nil {
+					return err
+				}
+				alerts = append(alerts, alert)
+				continue
+			}
+			if err := correctIngredientStock(tx, *item.IDIngredient, item.Selisih, change); err != nil {
+				return err
+			}
+			ingredientIDs = append(ingredientIDs, *item.IDIngredient)
+		}
+
+		recipeAlerts, err := syncRecipeStock(tx, ingredientIDs, recipeCorrection)
+		alerts = append(alerts, recipeAlerts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dispatchStockAlerts(alerts...)
+	return s.GetByID(id)
+}
+
+// Reject closes a pending opname without changing stock
+func (s *StockOpnameService) Reject(id uint, userID uint) (*models.StockOpname, error) {
+	if err := claimOpname(s.db, id, userID, models.OpnameRejected); err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+// claimOpname moves a pending opname to status, so it is reviewed only once
+func claimOpname(tx *gorm.DB, id uint, userID uint, status string) error {
+	result := tx.Model(&models.StockOpname{}).
+		Where("id = ? AND status = ?", id, models.OpnamePending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": userID,
+			"reviewed_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var exists int64
+		if err := tx.Model(&models.StockOpname{}).Where("id = ?", id).Count(&exists).Error; err != nil {
+			return err
+		}
+		if exists == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return fmt.Errorf("%w: opname was already reviewed", ErrInvalidStockOpname)
+	}
+	return nil
+}
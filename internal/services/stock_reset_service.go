@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stockResetDateLayout is the format of StockReset.Tanggal
+const stockResetDateLayout = "2006-01-02"
+
+// StockResetService resets menu stock back to its par level (stok_par) once per school day
+type StockResetService struct {
+	db *gorm.DB
+}
+
+func NewStockResetService(db *gorm.DB) *StockResetService {
+	return &StockResetService{db: db}
+}
+
+// ParseResetDate parses a YYYY-MM-DD date; an empty value means today in the school's timezone
+func ParseResetDate(tanggal string) (string, error) {
+	if tanggal == "" {
+		return utils.SchoolNow().Format(stockResetDateLayout), nil
+	}
+	if _, err := time.Parse(stockResetDateLayout, tanggal); err != nil {
+		return "", fmt.Errorf("%w: invalid tanggal %q, use YYYY-MM-DD", ErrInvalidStockReset, tanggal)
+	}
+	return tanggal, nil
+}
+
+// ResetStan sets the stock of every menu of the stan with a par level back to stok_par and
+// records the day as reset. A scheduled reset leaves skipped days alone; a manual reset
+// also applies to a skipped day. A day that was already reset is rejected.
+func (s *StockResetService) ResetStan(stanID uint, tanggal string, pemicu string, userID uint) (*models.StockReset, error) {
+	var reset models.StockReset
+	var alerts []*StockAlert
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id_stan = ? AND tanggal = ?", stanID, tanggal).First(&reset).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			reset = models.StockReset{IDStan: stanID, Tanggal: tanggal}
+		case err != nil:
+			return err
+		case reset.Status == models.StockResetApplied:
+			return fmt.Errorf("%w: stock was already reset on %s", ErrInvalidStockReset, tanggal)
+		case pemicu == models.StockResetScheduled:
+			return nil
+		}
+
+		var menus []models.Menu
+		if err := tx.Select("id", "stok_par").Where("id_stan = ? AND stok_par > 0", stanID).
+			// Stock of menus with a recipe follows their ingredients
+			Where("NOT EXISTS (SELECT 1 FROM recipe_items r WHERE r.id_menu = menus.id)").Order("id").Find(&menus).Error; err != nil {
+			return err
+		}
+
+		change := StockChange{Reason: models.StockParReset, Keterangan: "reset harian " + tanggal}
+		reset.IDUser = nil
+		if userID != 0 {
+			change.IDUser = userID
+			reset.IDUser = &userID
+		}
+		for _, menu := range menus {
+			alert, err := setStock(tx, menu.ID, menu.StokPar, change)
+			if err != nil {
+				return err
+			}
+			alerts = append(alerts, alert)
+		}
+
+		reset.Status = models.StockResetApplied
+		reset.Pemicu = pemicu
+		reset.MenuCount = len(menus)
+		if reset.ID != 0 {
+			return tx.Save(&reset).Error
+		}
+		// Another request may have reset or skipped the day since the lookup
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reset)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %s was already reset or skipped", ErrInvalidStockReset, tanggal)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dispatchStockAlerts(alerts...)
+	return &reset, nil
+}
+
+// SkipDay marks a day so the scheduler does not reset the stan's stock on it
+func (s *StockResetService) SkipDay(stanID uint, tanggal string, userID uint) (*models.StockReset, error) {
+	reset := models.StockReset{
+		IDStan:  stanID,
+		Tanggal: tanggal,
+		Status:  models.StockResetSkipped,
+		IDUser:  &userID,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reset)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s was already reset or skipped", ErrInvalidStockReset, tanggal)
+	}
+	return &reset, nil
+}
+
+// CancelSkip lets the scheduler reset the stan's stock again on a skipped day
+func (s *StockResetService) CancelSkip(stanID uint, tanggal string) error {
+	result := s.db.Where("id_stan = ? AND tanggal = ? AND status = ?", stanID, tanggal, models.StockResetSkipped).
+		Delete(&models.StockReset{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s is not skipped", ErrInvalidStockReset, tanggal)
+	}
+	return nil
+}
+
+// GetByStanID retrieves a page of the stan's resets and skipped days, newest first
+func (s *StockResetService) GetByStanID(stanID uint, limit, offset int) ([]models.StockReset, int64, error) {
+	query := s.db.Model(&models.StockReset{}).Where("id_stan = ?", stanID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var resets []models.StockReset
+	err := query.Order("tanggal DESC").Limit(limit).Offset(offset).Find(&resets).Error
+	return resets, total, err
+}
+
+// ApplyDueResets resets every stan whose jam_reset_stok has passed today (in the school's
+// timezone) and that has not been reset or skipped today. It returns how many stans were reset.
+func (s *StockResetService) ApplyDueResets(now time.Time) (int, error) {
+	now = now.In(utils.SchoolLocation())
+	tanggal := now.Format(stockResetDateLayout)
+
+	var stanIDs []uint
+	err := s.db.Model(&models.Stan{}).
+		Where("jam_reset_stok <> '' AND jam_reset_stok <= ?", now.Format("15:04")).
+		Where("EXISTS (SELECT 1 FROM menus m WHERE m.id_stan = stans.id AND m.stok_par > 0 AND m.deleted_at IS NULL)").
+		Where("NOT EXISTS (SELECT 1 FROM stock_resets r WHERE r.id_stan = stans.id AND r.tanggal = ?)", tanggal).
+		Order("id").Pluck("id", &stanIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	var errs []error
+	for _, stanID := range stanIDs {
+		reset, err := s.ResetStan(stanID, tanggal, models.StockResetScheduled, 0)
+		if errors.Is(err, ErrInvalidStockReset) {
+			// Reset or skipped by hand since the stans were listed
+			continue
+		}
+		if err != nil {
+			// Keep going so one stan does not hold back the others
+			errs = append(errs, fmt.Errorf("stan %d: %w", stanID, err))
+			continue
+		}
+		if reset.Status == models.StockResetApplied {
+			applied++
+		}
+	}
+	return applied, errors.Join(errs...)
+}
+
+// RunScheduler applies due daily stock resets every interval until ctx is cancelled
+func (s *StockResetService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if applied, err := s.ApplyDueResets(time.Now()); err != nil {
+			log.Printf("Failed to apply daily stock resets: %v", err)
+		} else if applied > 0 {
+			log.Printf("Reset stock to par for %d stan(s)", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
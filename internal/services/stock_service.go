@@ -0,0 +1,296 @@
+package services
+
+import (
+	"fmt"
+	"swipeup-be/internal/models"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockChange describes why a menu's stock changes
+type StockChange struct {
+	Reason      models.StockReason
+	IDTransaksi *uint
+	IDUser      uint // 0 untuk perubahan oleh sistem
+	Keterangan  string
+}
+
+// StockDrift compares a menu's stock with the stock rebuilt from its ledger
+type StockDrift struct {
+	IDMenu      uint   `json:"id_menu"`
+	NamaMakanan string `json:"nama_makanan"`
+	Stock       int    `json:"stock"`
+	LedgerStock int    `json:"ledger_stock"`
+	Drift       int    `json:"drift"` // stock - ledger_stock
+}
+
+type StockService struct {
+	db *gorm.DB
+}
+
+func NewStockService(db *gorm.DB) *StockService {
+	return &StockService{db: db}
+}
+
+// recordStockMovement writes a ledger entry; zero deltas are not recorded
+func recordStockMovement(tx *gorm.DB, menuID uint, delta, stockAfter int, change StockChange) error {
+	if delta == 0 {
+		return nil
+	}
+	movement := models.StockMovement{
+		IDMenu:      menuID,
+		Delta:       delta,
+		StockAfter:  stockAfter,
+		Reason:      change.Reason,
+		IDTransaksi: change.IDTransaksi,
+		Keterangan:  change.Keterangan,
+	}
+	if change.IDUser != 0 {
+		movement.IDUser = &change.IDUser
+	}
+	return tx.Create(&movement).Error
+}
+
+// setStock sets a menu's stock and records the difference in the ledger
+func setStock(tx *gorm.DB, menuID uint, stock int, change StockChange) (*StockAlert, error) {
+	return updateStock(tx, menuID, func(int) int { return stock }, change)
+}
+
+// adjustStock changes a menu's stock by delta and records the applied difference in the ledger
+func adjustStock(tx *gorm.DB, menuID uint, delta int, change StockChange) (*StockAlert, error) {
+	return updateStock(tx, menuID, func(current int) int { return current + delta }, change)
+}
+
+// updateStock locks the menu, sets its stock to next(current) clamped at zero,
+// keeps is_available in sync and records the applied delta. It returns the stock
+// alert raised by the change, if any, to be dispatched once the transaction commits.
+func updateStock(tx *gorm.DB, menuID uint, next func(current int) int, change StockChange) (*StockAlert, error) {
+	var menu models.Menu
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&menu, menuID).Error; err != nil {
+		return nil, err
+	}
+
+	// Updates writes the new values back into menu, so keep the stock before the change
+	before := menu.Stock
+	stock := next(before)
+	if stock < 0 {
+		stock = 0
+	}
+
+	err := tx.Model(&menu).Updates(map[string]interface{}{
+		"stock":        stock,
+		"is_available": stock > 0,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := recordStockMovement(tx, menu.ID, stock-before, stock, change); err != nil {
+		return nil, err
+	}
+	return raiseStockAlert(tx, menu, before, stock)
+}
+
+// StockAlert is raised when a menu's stock drops to or below its stok_minimum, or sells out
+type StockAlert struct {
+	Tipe           string `json:"tipe"` // low_stock atau sold_out
+	IDStan         uint   `json:"id_stan"`
+	IDMenu         uint   `json:"id_menu"`
+	NamaMakanan    string `json:"nama_makanan"`
+	Stock          int    `json:"stock"`
+	StokMinimum    int    `json:"stok_minimum"`
+	IDNotification uint   `json:"id_notification"`
+}
+
+// StockAlertListener receives stock alerts, e.g. to push them over SSE or a webhook
+type StockAlertListener func(alert StockAlert)
+
+var (
+	stockAlertMu        sync.RWMutex
+	stockAlertListeners []StockAlertListener
+)
+
+// OnStockAlert registers a listener for stock alerts. Alerts are stored as notifications
+// for the stan admin first; listeners are called in their own goroutine after the stock
+// change has been committed.
+func OnStockAlert(listener StockAlertListener) {
+	stockAlertMu.Lock()
+	stockAlertListeners = append(stockAlertListeners, listener)
+	stockAlertMu.Unlock()
+}
+
+// dispatchStockAlerts hands committed stock alerts to the registered listeners
+func dispatchStockAlerts(alerts ...*StockAlert) {
+	stockAlertMu.RLock()
+	listeners := stockAlertListeners
+	stockAlertMu.RUnlock()
+
+	for _, alert := range alerts {
+		if alert == nil {
+			continue
+		}
+		for _, listener := range listeners {
+			go listener(*alert)
+		}
+	}
+}
+
+// raiseStockAlert stores a notification for the stan admin when stock crosses the menu's
+// stok_minimum or runs out. Changes that stay below the threshold do not raise again.
+func raiseStockAlert(tx *gorm.DB, menu models.Menu, before, after int) (*StockAlert, error) {
+	alert := StockAlert{
+		IDStan:      menu.IDStan,
+		IDMenu:      menu.ID,
+		NamaMakanan: menu.NamaMakanan,
+		Stock:       after,
+		StokMinimum: menu.StokMinimum,
+	}
+
+	var judul, pesan string
+	switch alert.Tipe = stockAlertType(before, after, menu.StokMinimum); alert.Tipe {
+	case models.NotifSoldOut:
+		judul = fmt.Sprintf("%s habis", menu.NamaMakanan)
+		pesan = fmt.Sprintf("Stok %s sudah habis dan menu tidak tersedia untuk dipesan.", menu.NamaMakanan)
+	case models.NotifLowStock:
+		judul = fmt.Sprintf("Stok %s menipis", menu.NamaMakanan)
+		pesan = fmt.Sprintf("Stok %s tinggal %d (batas minimum %d).", menu.NamaMakanan, after, menu.StokMinimum)
+	default:
+		return nil, nil
+	}
+
+	var stan models.Stan
+	if err := tx.Select("id", "id_user").First(&stan, menu.IDStan).Error; err != nil {
+		return nil, err
+	}
+
+	menuID := menu.ID
+	notification := models.Notification{
+		IDUser: stan.IDUser,
+		Tipe:   alert.Tipe,
+		Judul:  judul,
+		Pesan:  pesan,
+		IDMenu: &menuID,
+	}
+	if err := tx.Create(&notification).Error; err != nil {
+		return nil, err
+	}
+	alert.IDNotification = notification.ID
+	return &alert, nil
+}
+
+// stockAlertType returns the alert raised when stock goes from before to after, or "" when
+// the change does not cross the sold-out or stok_minimum threshold
+func stockAlertType(before, after, stokMinimum int) string {
+	switch {
+	case after <= 0 && before > 0:
+		return models.NotifSoldOut
+	case after > 0 && after <= stokMinimum && before > stokMinimum:
+		return models.NotifLowStock
+	}
+	return ""
+}
+
+// GetLowStock retrieves the stan's menus at or below their stok_minimum and those sold out
+func (s *StockService) GetLowStock(stanID uint) (lowStock []models.Menu, soldOut []models.Menu, err error) {
+	lowStock = []models.Menu{}
+	err = s.db.Where("id_stan = ? AND stock > 0 AND stock <= stok_minimum", stanID).
+		Order("stock, id").Find(&lowStock).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	soldOut = []models.Menu{}
+	err = s.db.Where("id_stan = ? AND stock <= 0", stanID).Order("id").Find(&soldOut).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return lowStock, soldOut, nil
+}
+
+// ValidateManualChange checks a stock change made by a user. Sales and refunds are only
+// recorded by checkout; restock must add stock and waste must remove it.
+func ValidateManualChange(reason models.StockReason, delta int) error {
+	switch reason {
+	case models.StockRestock:
+		if delta < 0 {
+			return fmt.Errorf("%w: restock must not reduce stock", ErrInvalidStockChange)
+		}
+	case models.StockWaste:
+		if delta > 0 {
+			return fmt.Errorf("%w: waste must not add stock", ErrInvalidStockChange)
+		}
+	case models.StockCorrection:
+	default:
+		return fmt.Errorf("%w: reason must be restock, waste or correction", ErrInvalidStockChange)
+	}
+	return nil
+}
+
+// GetHistory retrieves a page of a menu's stock movements, newest first
+func (s *StockService) GetHistory(menuID uint, limit, offset int) ([]models.StockMovement, int64, error) {
+	query := s.db.Model(&models.StockMovement{}).Where("id_menu = ?", menuID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var movements []models.StockMovement
+	err := query.Preload("User").Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&movements).Error
+	return movements, total, err
+}
+
+// GetDrift rebuilds every menu's stock of a stan from the ledger and returns the menus
+// whose stored stock differs
+func (s *StockService) GetDrift(stanID uint) ([]StockDrift, error) {
+	drifts := []StockDrift{}
+	err := s.db.Raw(`
+		SELECT m.id AS id_menu, m.nama_makanan, m.stock, COALESCE(SUM(sm.delta), 0) AS ledger_stock,
+			m.stock - COALESCE(SUM(sm.delta), 0) AS drift
+		FROM menus m
+		LEFT JOIN stock_movements sm ON sm.id_menu = m.id
+		WHERE m.id_stan = ? AND m.deleted_at IS NULL
+		GROUP BY m.id, m.nama_makanan, m.stock
+		HAVING m.stock <> COALESCE(SUM(sm.delta), 0)
+		ORDER BY m.id`, stanID).Scan(&drifts).Error
+	return drifts, err
+}
+
+// RebuildStock resets a menu's stock to the value rebuilt from its ledger
+func (s *StockService) RebuildStock(menuID uint) (*StockDrift, error) {
+	var drift StockDrift
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var menu models.Menu
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&menu, menuID).Error; err != nil {
+			return err
+		}
+
+		var ledgerStock int
+		err := tx.Model(&models.StockMovement{}).Where("id_menu = ?", menuID).
+			Select("COALESCE(SUM(delta), 0)").Scan(&ledgerStock).Error
+		if err != nil {
+			return err
+		}
+
+		drift = StockDrift{
+			IDMenu:      menu.ID,
+			NamaMakanan: menu.NamaMakanan,
+			Stock:       menu.Stock,
+			LedgerStock: ledgerStock,
+			Drift:       menu.Stock - ledgerStock,
+		}
+		if drift.Drift == 0 {
+			return nil
+		}
+
+		return tx.Model(&menu).Updates(map[string]interface{}{
+			"stock":        ledgerStock,
+			"is_available": ledgerStock > 0,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &drift, nil
+}
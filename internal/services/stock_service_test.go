@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"swipeup-be/internal/models"
+	"testing"
+)
+
+func TestValidateManualChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		reason  models.StockReason
+		delta   int
+		wantErr bool
+	}{
+		{"restock adds", models.StockRestock, 5, false},
+		{"restock without change", models.StockRestock, 0, false},
+		{"restock removes", models.StockRestock, -1, true},
+		{"waste removes", models.StockWaste, -3, false},
+		{"waste adds", models.StockWaste, 1, true},
+		{"correction adds", models.StockCorrection, 4, false},
+		{"correction removes", models.StockCorrection, -4, false},
+		{"sale is checkout only", models.StockSale, -1, true},
+		{"refund is checkout only", models.StockRefund, 1, true},
+		{"reset is scheduler only", models.StockParReset, 2, true},
+		{"unknown reason", models.StockReason("hilang"), -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateManualChange(tt.reason, tt.delta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateManualChange(%q, %d) error = %v, want error %v", tt.reason, tt.delta, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidStockChange) {
+				t.Fatalf("error %v is not ErrInvalidStockChange", err)
+			}
+		})
+	}
+}
+
+func TestStockAlertType(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      int
+		after       int
+		stokMinimum int
+		want        string
+	}{
+		{"sells out", 3, 0, 0, models.NotifSoldOut},
+		{"sells out past the minimum", 10, 0, 5, models.NotifSoldOut},
+		{"already sold out", 0, 0, 5, ""},
+		{"crosses the minimum", 6, 5, 5, models.NotifLowStock},
+		{"drops well below the minimum", 20, 1, 5, models.NotifLowStock},
+		{"stays above the minimum", 10, 6, 5, ""},
+		{"already below the minimum", 4, 3, 5, ""},
+		{"restocked below the minimum", 2, 4, 5, ""},
+		{"restocked after selling out", 0, 10, 5, ""},
+		{"no minimum set", 5, 1, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stockAlertType(tt.before, tt.after, tt.stokMinimum); got != tt.want {
+				t.Fatalf("stockAlertType(%d, %d, %d) = %q, want %q", tt.before, tt.after, tt.stokMinimum, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustStockRecordsDelta(t *testing.T) {
+	db := openTestDB(t, &models.Stan{}, &models.Menu{}, &models.StockMovement{}, &models.Notification{})
+
+	stan := models.Stan{NamaStan: "Stan", NamaPemilik: "Pemilik", IDUser: 1}
+	if err := db.Create(&stan).Error; err != nil {
+		t.Fatal(err)
+	}
+	menu := models.Menu{NamaMakanan: "Nasi Goreng", Harga: 10000, Jenis: models.JenisMakanan, Stock: 10, IDStan: stan.ID}
+	if err := db.Create(&menu).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := adjustStock(db, menu.ID, -7, StockChange{Reason: models.StockWaste}); err != nil {
+		t.Fatalf("adjustStock: %v", err)
+	}
+	if _, err := setStock(db, menu.ID, 5, StockChange{Reason: models.StockRestock}); err != nil {
+		t.Fatalf("setStock: %v", err)
+	}
+
+	var movements []models.StockMovement
+	if err := db.Where("id_menu = ?", menu.ID).Order("id").Find(&movements).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ delta, after int }{{-7, 3}, {2, 5}}
+	if len(movements) != len(want) {
+		t.Fatalf("recorded %d movements, want %d", len(movements), len(want))
+	}
+	for i, w := range want {
+		if movements[i].Delta != w.delta || movements[i].StockAfter != w.after {
+			t.Errorf("movement %d = delta %d, stock_after %d; want delta %d, stock_after %d",
+				i, movements[i].Delta, movements[i].StockAfter, w.delta, w.after)
+		}
+	}
+}
+
+func TestAdjustStockRaisesAlert(t *testing.T) {
+	db := openTestDB(t, &models.Stan{}, &models.Menu{}, &models.StockMovement{}, &models.Notification{})
+
+	stan := models.Stan{NamaStan: "Stan", NamaPemilik: "Pemilik", IDUser: 7}
+	if err := db.Create(&stan).Error; err != nil {
+		t.Fatal(err)
+	}
+	menu := models.Menu{NamaMakanan: "Es Teh", Harga: 3000, Jenis: models.JenisMinuman, Stock: 10, StokMinimum: 5, IDStan: stan.ID}
+	if err := db.Create(&menu).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		delta int
+		want  string
+	}{
+		{"crosses the minimum", -6, models.NotifLowStock},
+		{"stays below the minimum", -1, ""},
+		{"sells out", -3, models.NotifSoldOut},
+	}
+	for _, tt := range tests {
+		alert, err := adjustStock(db, menu.ID, tt.delta, StockChange{Reason: models.StockWaste})
+		if err != nil {
+			t.Fatalf("%s: adjustStock: %v", tt.name, err)
+		}
+		got := ""
+		if alert != nil {
+			got = alert.Tipe
+		}
+		if got != tt.want {
+			t.Errorf("%s: alert = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	var notifications int64
+	if err := db.Model(&models.Notification{}).Where("id_user = ? AND id_menu = ?", stan.IDUser, menu.ID).Count(&notifications).Error; err != nil {
+		t.Fatal(err)
+	}
+	if notifications != 2 {
+		t.Errorf("stored %d notifications, want 2", notifications)
+	}
+}
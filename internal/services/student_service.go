@@ -1,6 +1,8 @@
 package services
 
 import (
+	"fmt"
+	"strings"
 	"swipeup-be/internal/models"
 
 	"gorm.io/gorm"
@@ -12,6 +14,8 @@ type StudentService struct {
 	siswaService  *SiswaService
 	cartService   *CartService
 	transaksiService *TransaksiService
+	reviewService *ReviewService
+	favoriteService *FavoriteService
 }
 
 func NewStudentService(
@@ -25,6 +29,8 @@ func NewStudentService(
 		siswaService:  siswaService,
 		cartService:   cartService,
 		transaksiService: transaksiService,
+		reviewService: NewReviewService(db),
+		favoriteService: NewFavoriteService(db),
 	}
 }
 
@@ -63,9 +69,9 @@ func (s *StudentService) GetCart(siswaID uint) ([]models.Cart, int, float64, err
 	return carts, totalItems, totalPrice, nil
 }
 
-// UpdateCartItem updates a cart item
-func (s *StudentService) UpdateCartItem(cartID uint, qty int) error {
-	return s.cartService.UpdateCartItem(cartID, qty)
+// UpdateCartItem updates the quantity and, when given, the note of a cart item
+func (s *StudentService) UpdateCartItem(cartID uint, qty int, catatan *string) error {
+	return s.cartService.UpdateCartItem(cartID, qty, catatan)
 }
 
 // RemoveFromCart removes an item from the cart
@@ -78,16 +84,87 @@ func (s *StudentService) ClearCart(siswaID uint) error {
 	return s.cartService.ClearCart(siswaID)
 }
 
-// CheckoutCart converts cart items to a transaction
-func (s *StudentService) CheckoutCart(siswaID uint, stanID uint) (*models.Transaksi, []models.DetailTransaksi, error) {
-	// Get cart details
-	details, err := s.cartService.CheckoutCart(siswaID, stanID)
+// AlergenWarning flags a cart item containing allergens from the student's allergy profile
+type AlergenWarning struct {
+	IDMenu      uint     `json:"id_menu"`
+	NamaMakanan string   `json:"nama_makanan"`
+	Alergen     []string `json:"alergen"`
+}
+
+// CheckCartAlergen compares the student's cart, including bundle components, with their allergy profile
+func (s *StudentService) CheckCartAlergen(siswaID uint) ([]AlergenWarning, error) {
+	siswa, err := s.siswaService.FindByID(siswaID)
+	if err != nil {
+		return nil, err
+	}
+	if siswa.Alergi == "" {
+		return nil, nil
+	}
+
+	carts, err := s.cartService.loadCartForPricing(siswaID)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []AlergenWarning
+	seen := make(map[uint]bool)
+	check := func(menu *models.Menu) {
+		if seen[menu.ID] {
+			return
+		}
+		seen[menu.ID] = true
+		if conflicts := menu.ConflictingAlergen(siswa.Alergi); len(conflicts) > 0 {
+			warnings = append(warnings, AlergenWarning{
+				IDMenu:      menu.ID,
+				NamaMakanan: menu.NamaMakanan,
+				Alergen:     conflicts,
+			})
+		}
+	}
+	for _, cart := range carts {
+		if cart.Menu != nil {
+			check(cart.Menu)
+		}
+		if cart.Bundle != nil {
+			for i := range cart.Bundle.Items {
+				check(&cart.Bundle.Items[i].Menu)
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+// CheckoutCart converts cart items to a transaction.
+// Items conflicting with the student's allergies are returned as warnings, or block
+// the checkout with ErrAlergenConflict when the student enabled blokir_alergen.
+func (s *StudentService) CheckoutCart(siswaID uint, stanID uint) (*models.Transaksi, []models.DetailTransaksi, []AlergenWarning, error) {
+	warnings, err := s.CheckCartAlergen(siswaID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(warnings) > 0 {
+		siswa, err := s.siswaService.FindByID(siswaID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if siswa.BlokirAlergen {
+			names := make([]string, 0, len(warnings))
+			for _, w := range warnings {
+				names = append(names, fmt.Sprintf("%s (%s)", w.NamaMakanan, strings.Join(w.Alergen, ", ")))
+			}
+			return nil, nil, warnings, fmt.Errorf("%w: %s", ErrAlergenConflict, strings.Join(names, "; "))
+		}
+	}
+
+	// Get cart details; the cart is only cleared once the transaction is created
+	details, err := s.cartService.PrepareCheckout(siswaID)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	if len(details) == 0 {
-		return nil, nil, gorm.ErrRecordNotFound
+		return nil, nil, nil, gorm.ErrRecordNotFound
 	}
 
 	// Create transaction with cart details
@@ -98,16 +175,20 @@ func (s *StudentService) CheckoutCart(siswaID uint, stanID uint) (*models.Transa
 	}
 
 	if err := s.transaksiService.CreateWithDetails(transaksi, details); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	if err := s.cartService.ClearCart(siswaID); err != nil {
+		return nil, nil, nil, err
 	}
 
 	// Get full transaction details
 	fullTransaksi, err := s.transaksiService.GetWithFullDetails(transaksi.ID)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	return fullTransaksi, details, nil
+	return fullTransaksi, details, warnings, nil
 }
 
 // GetTransactions retrieves all transactions for the student
@@ -129,3 +210,55 @@ func (s *StudentService) GetTransactionByID(siswaID uint, transaksiID uint) (*mo
 
 	return s.transaksiService.GetWithFullDetails(transaksiID)
 }
+
+// GetFavorites retrieves the student's favorite menus
+func (s *StudentService) GetFavorites(siswaID uint) ([]models.Favorite, error) {
+	return s.favoriteService.GetBySiswaID(siswaID)
+}
+
+// AddFavorite adds a menu to the student's favorites
+func (s *StudentService) AddFavorite(siswaID uint, menuID uint) (*models.Favorite, error) {
+	return s.favoriteService.Add(siswaID, menuID)
+}
+
+// RemoveFavorite removes a menu from the student's favorites
+func (s *StudentService) RemoveFavorite(siswaID uint, menuID uint) error {
+	return s.favoriteService.Remove(siswaID, menuID)
+}
+
+// Reorder adds the lines of one of the student's past transactions back to the cart
+func (s *StudentService) Reorder(siswaID uint, transaksiID uint) (*ReorderResult, error) {
+	var transaksi models.Transaksi
+	// Deleted menus and bundles are still loaded so they can be reported by name
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	err := s.db.Preload("DetailTransaksi.Menu", unscoped).
+		Preload("DetailTransaksi.Bundle", unscoped).
+		Preload("DetailTransaksi.Options").
+		First(&transaksi, transaksiID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if transaksi.IDSiswa != siswaID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return s.cartService.Reorder(siswaID, &transaksi)
+}
+
+// CreateReview reviews a completed transaction of the student
+func (s *StudentService) CreateReview(siswaID uint, transaksiID uint, review *models.Review) error {
+	return s.reviewService.CreateReview(siswaID, transaksiID, review)
+}
+
+// GetReview retrieves the student's review of a transaction
+func (s *StudentService) GetReview(siswaID uint, transaksiID uint) (*models.Review, error) {
+	review, err := s.reviewService.GetByTransaksiID(transaksiID)
+	if err != nil {
+		return nil, err
+	}
+	if review.IDSiswa != siswaID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return review, nil
+}
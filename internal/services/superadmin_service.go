@@ -1,7 +1,9 @@
 package services
 
 import (
+	"strings"
 	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,11 +11,19 @@ import (
 
 // SuperadminService provides superadmin-specific operations
 type SuperadminService struct {
-	db *gorm.DB
+	db               *gorm.DB
+	reviewService    *ReviewService
+	opnameService    *StockOpnameService
+	analyticsService *AnalyticsService
 }
 
 func NewSuperadminService(db *gorm.DB) *SuperadminService {
-	return &SuperadminService{db: db}
+	return &SuperadminService{
+		db:               db,
+		reviewService:    NewReviewService(db),
+		opnameService:    NewStockOpnameService(db),
+		analyticsService: NewAnalyticsService(db),
+	}
 }
 
 // StanRevenue represents revenue data for a stan
@@ -46,15 +56,18 @@ func (s *SuperadminService) GetRevenueByStanID(stanID uint, startDate, endDate t
 	}
 
 	query := s.db.Model(&models.DetailTransaksi{}).
-		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id").
+		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id AND transaksis.deleted_at IS NULL").
 		Where("transaksis.id_stan = ?", stanID)
 
 	if !startDate.IsZero() && !endDate.IsZero() {
 		query = query.Where("transaksis.tanggal BETWEEN ? AND ?", startDate, endDate)
 	}
 
-	query.Select("COALESCE(SUM(detail_transaksis.qty * detail_transaksis.harga_beli), 0) as total_revenue, COUNT(DISTINCT transaksis.id) as total_orders").
-		Scan(&result)
+	err := query.Select("COALESCE(SUM(detail_transaksis.qty * detail_transaksis.harga_beli), 0) as total_revenue, COUNT(DISTINCT transaksis.id) as total_orders").
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
 
 	return &StanRevenue{
 		StanID:       stanID,
@@ -64,20 +77,30 @@ func (s *SuperadminService) GetRevenueByStanID(stanID uint, startDate, endDate t
 	}, nil
 }
 
-// GetAllStanRevenue calculates revenue for all stans
-func (s *SuperadminService) GetAllStanRevenue(startDate, endDate time.Time) ([]StanRevenue, error) {
-	var stans []models.Stan
-	if err := s.db.Find(&stans).Error; err != nil {
-		return nil, err
+// revenueByStan returns a subquery of revenue and order count per id_stan, limited to the
+// date range when both ends are set
+func (s *SuperadminService) revenueByStan(startDate, endDate time.Time) *gorm.DB {
+	query := s.db.Model(&models.DetailTransaksi{}).
+		Select("transaksis.id_stan, SUM(detail_transaksis.qty * detail_transaksis.harga_beli) AS total_revenue, COUNT(DISTINCT transaksis.id) AS total_orders").
+		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id AND transaksis.deleted_at IS NULL")
+
+	if !startDate.IsZero() && !endDate.IsZero() {
+		query = query.Where("transaksis.tanggal BETWEEN ? AND ?", startDate, endDate)
 	}
 
+	return query.Group("transaksis.id_stan")
+}
+
+// GetAllStanRevenue calculates revenue for all stans in a single grouped query
+func (s *SuperadminService) GetAllStanRevenue(startDate, endDate time.Time) ([]StanRevenue, error) {
 	var revenues []StanRevenue
-	for _, stan := range stans {
-		revenue, err := s.GetRevenueByStanID(stan.ID, startDate, endDate)
-		if err != nil {
-			continue
-		}
-		revenues = append(revenues, *revenue)
+	err := s.db.Model(&models.Stan{}).
+		Select("stans.id AS stan_id, stans.nama_stan, COALESCE(revenue.total_revenue, 0) AS total_revenue, COALESCE(revenue.total_orders, 0) AS total_orders").
+		Joins("LEFT JOIN (?) AS revenue ON revenue.id_stan = stans.id", s.revenueByStan(startDate, endDate)).
+		Order("stans.id").
+		Scan(&revenues).Error
+	if err != nil {
+		return nil, err
 	}
 
 	return revenues, nil
@@ -183,15 +206,18 @@ func (s *SuperadminService) GetStanStatistics(stanID uint, startDate, endDate ti
 	}
 
 	query := s.db.Model(&models.DetailTransaksi{}).
-		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id").
+		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id AND transaksis.deleted_at IS NULL").
 		Where("transaksis.id_stan = ?", stanID)
 
 	if !startDate.IsZero() && !endDate.IsZero() {
 		query = query.Where("transaksis.tanggal BETWEEN ? AND ?", startDate, endDate)
 	}
 
-	query.Select("COALESCE(SUM(detail_transaksis.qty * detail_transaksis.harga_beli), 0) as total_revenue, COUNT(DISTINCT transaksis.id) as total_orders").
-		Scan(&result)
+	err := query.Select("COALESCE(SUM(detail_transaksis.qty * detail_transaksis.harga_beli), 0) as total_revenue, COUNT(DISTINCT transaksis.id) as total_orders").
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
 
 	averageOrder := 0.0
 	if result.TotalOrders > 0 {
@@ -209,21 +235,446 @@ func (s *SuperadminService) GetStanStatistics(stanID uint, startDate, endDate ti
 	}, nil
 }
 
-// GetAllStanStatistics returns statistics for all stans
+// GetAllStanStatistics returns statistics for all stans in a single grouped query
 func (s *SuperadminService) GetAllStanStatistics(startDate, endDate time.Time) ([]StanStatistics, error) {
-	var stans []models.Stan
-	if err := s.db.Find(&stans).Error; err != nil {
+	menuCounts := s.db.Model(&models.Menu{}).
+		Select("id_stan, COUNT(*) AS total_menu, COUNT(*) FILTER (WHERE is_available AND stock > 0) AS available_menu").
+		Group("id_stan")
+
+	var statistics []StanStatistics
+	err := s.db.Model(&models.Stan{}).
+		Select(`stans.id AS stan_id, stans.nama_stan,
+			COALESCE(menu_counts.total_menu, 0) AS total_menu,
+			COALESCE(menu_counts.available_menu, 0) AS available_menu,
+			COALESCE(revenue.total_orders, 0) AS total_orders,
+			COALESCE(revenue.total_revenue, 0) AS total_revenue,
+			COALESCE(revenue.total_revenue / NULLIF(revenue.total_orders, 0), 0) AS average_order`).
+		Joins("LEFT JOIN (?) AS menu_counts ON menu_counts.id_stan = stans.id", menuCounts).
+		Joins("LEFT JOIN (?) AS revenue ON revenue.id_stan = stans.id", s.revenueByStan(startDate, endDate)).
+		Order("stans.id").
+		Scan(&statistics).Error
+	if err != nil {
 		return nil, err
 	}
 
-	var statistics []StanStatistics
-	for _, stan := range stans {
-		stat, err := s.GetStanStatistics(stan.ID, startDate, endDate)
-		if err != nil {
+	return statistics, nil
+}
+
+// DiskonStanUsage represents how a discount performed within one stan
+type DiskonStanUsage struct {
+	StanID            uint    `json:"stan_id"`
+	NamaStan          string  `json:"nama_stan"`
+	Redemptions       int     `json:"redemptions"`
+	ItemsDiscounted   int     `json:"items_discounted"`
+	TotalSubsidi      float64 `json:"total_subsidi"`
+	TotalOrders       int     `json:"total_orders"`
+	BaselineOrders    int     `json:"baseline_orders"`
+	OrderChange       int     `json:"order_change"`
+}
+
+// DiskonUsage represents redemption and subsidy analytics for a single discount
+type DiskonUsage struct {
+	IDDiskon          uint              `json:"id_diskon"`
+	NamaDiskon        string            `json:"nama_diskon"`
+	TipeDiskon        models.TipeDiskon `json:"tipe_diskon"`
+	IsActive          bool              `json:"is_active"`
+	AnggaranSubsidi   *float64          `json:"anggaran_subsidi"`
+	SisaAnggaran      *float64          `json:"sisa_anggaran"`
+	Redemptions       int               `json:"redemptions"`
+	ItemsDiscounted   int               `json:"items_discounted"`
+	TotalSubsidi      float64           `json:"total_subsidi"`
+	OrderChange       int               `json:"order_change"`
+	Stans             []DiskonStanUsage `json:"stans"`
+}
+
+// GetDiskonUsageReport reports redemptions, subsidy and the order change per discount and per stan.
+// The order change compares all of the stan's orders while the discount ran against the equally long
+// period before it, so it is not attributed to the discount alone.
+func (s *SuperadminService) GetDiskonUsageReport(startDate, endDate time.Time) ([]DiskonUsage, error) {
+	var rows []struct {
+		IDDiskon        uint
+		StanID          uint
+		NamaStan        string
+		Redemptions     int
+		ItemsDiscounted int
+		TotalSubsidi    float64
+	}
+
+	query := s.db.Model(&models.DetailTransaksi{}).
+		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id AND transaksis.deleted_at IS NULL").
+		Joins("JOIN stans ON transaksis.id_stan = stans.id").
+		Where("detail_transaksis.id_diskon IS NOT NULL")
+
+	if !startDate.IsZero() && !endDate.IsZero() {
+		query = query.Where("transaksis.tanggal BETWEEN ? AND ?", startDate, endDate)
+	}
+
+	err := query.Select("detail_transaksis.id_diskon, transaksis.id_stan AS stan_id, stans.nama_stan, " +
+		"COUNT(DISTINCT transaksis.id) AS redemptions, COALESCE(SUM(detail_transaksis.qty), 0) AS items_discounted, " +
+		"COALESCE(SUM(detail_transaksis.potongan), 0) AS total_subsidi").
+		Group("detail_transaksis.id_diskon, transaksis.id_stan, stans.nama_stan").
+		Order("detail_transaksis.id_diskon, transaksis.id_stan").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var diskonIDs []uint
+	seen := make(map[uint]bool)
+	for _, row := range rows {
+		if !seen[row.IDDiskon] {
+			seen[row.IDDiskon] = true
+			diskonIDs = append(diskonIDs, row.IDDiskon)
+		}
+	}
+
+	var diskon []models.Diskon
+	if len(diskonIDs) > 0 {
+		if err := s.db.Unscoped().Where("id IN ?", diskonIDs).Find(&diskon).Error; err != nil {
+			return nil, err
+		}
+	}
+	diskonByID := make(map[uint]models.Diskon, len(diskon))
+	for _, d := range diskon {
+		diskonByID[d.ID] = d
+	}
+
+	windows := make([]diskonStanWindow, len(rows))
+	for i, row := range rows {
+		windows[i].IDDiskon, windows[i].StanID = row.IDDiskon, row.StanID
+		windows[i].Start, windows[i].End = diskonWindow(diskonByID[row.IDDiskon], startDate, endDate)
+	}
+	orders, err := s.countWindowOrders(windows)
+	if err != nil {
+		return nil, err
+	}
+
+	var report []DiskonUsage
+	index := make(map[uint]int)
+	for _, row := range rows {
+		d := diskonByID[row.IDDiskon]
+
+		i, ok := index[row.IDDiskon]
+		if !ok {
+			usage := DiskonUsage{
+				IDDiskon:        d.ID,
+				NamaDiskon:      d.NamaDiskon,
+				TipeDiskon:      d.TipeDiskon,
+				IsActive:        d.IsActive,
+				AnggaranSubsidi: d.AnggaranSubsidi,
+			}
+			if d.AnggaranSubsidi != nil {
+				sisa := *d.AnggaranSubsidi - d.TotalSubsidi
+				if sisa < 0 {
+					sisa = 0
+				}
+				usage.SisaAnggaran = &sisa
+			}
+			report = append(report, usage)
+			i = len(report) - 1
+			index[row.IDDiskon] = i
+		}
+
+		count := orders[[2]uint{row.IDDiskon, row.StanID}]
+		stanUsage := DiskonStanUsage{
+			StanID:          row.StanID,
+			NamaStan:        row.NamaStan,
+			Redemptions:     row.Redemptions,
+			ItemsDiscounted: row.ItemsDiscounted,
+			TotalSubsidi:    row.TotalSubsidi,
+			TotalOrders:     count.TotalOrders,
+			BaselineOrders:  count.BaselineOrders,
+			OrderChange:     count.TotalOrders - count.BaselineOrders,
+		}
+
+		report[i].Redemptions += stanUsage.Redemptions
+		report[i].ItemsDiscounted += stanUsage.ItemsDiscounted
+		report[i].TotalSubsidi += stanUsage.TotalSubsidi
+		report[i].OrderChange += stanUsage.OrderChange
+		report[i].Stans = append(report[i].Stans, stanUsage)
+	}
+
+	return report, nil
+}
+
+// diskonWindow clips the discount period to the requested date range and to the current time
+func diskonWindow(diskon models.Diskon, startDate, endDate time.Time) (time.Time, time.Time) {
+	windowStart, windowEnd := diskon.TanggalAwal, diskon.TanggalAkhir
+	if !startDate.IsZero() && startDate.After(windowStart) {
+		windowStart = startDate
+	}
+	if !endDate.IsZero() && endDate.Before(windowEnd) {
+		windowEnd = endDate
+	}
+	if now := time.Now().UTC(); now.Before(windowEnd) {
+		windowEnd = now
+	}
+	if windowEnd.Before(windowStart) {
+		windowEnd = windowStart
+	}
+	return windowStart, windowEnd
+}
+
+// diskonStanWindow is the period a discount ran within one stan
+type diskonStanWindow struct {
+	IDDiskon, StanID uint
+	Start, End       time.Time
+}
+
+// windowOrders counts a stan's orders within a discount window and within the equally long period before it
+type windowOrders struct {
+	IDDiskon       uint
+	StanID         uint
+	TotalOrders    int
+	BaselineOrders int
+}
+
+// countWindowOrders counts the orders of every window in one query, keyed by discount and stan ID
+func (s *SuperadminService) countWindowOrders(windows []diskonStanWindow) (map[[2]uint]windowOrders, error) {
+	counts := make(map[[2]uint]windowOrders, len(windows))
+	if len(windows) == 0 {
+		return counts, nil
+	}
+
+	values := make([]string, len(windows))
+	args := make([]interface{}, 0, 5*len(windows))
+	for i, w := range windows {
+		values[i] = "(?::bigint, ?::bigint, ?::timestamp, ?::timestamp, ?::timestamp)"
+		args = append(args, w.IDDiskon, w.StanID, w.Start.Add(-w.End.Sub(w.Start)), w.Start, w.End)
+	}
+
+	var rows []windowOrders
+	err := s.db.Raw(`SELECT w.id_diskon, w.stan_id,
+		COUNT(transaksis.id) FILTER (WHERE transaksis.tanggal >= w.window_start) AS total_orders,
+		COUNT(transaksis.id) FILTER (WHERE transaksis.tanggal < w.window_start) AS baseline_orders
+		FROM (VALUES `+strings.Join(values, ", ")+`) AS w(id_diskon, stan_id, baseline_start, window_start, window_end)
+		LEFT JOIN transaksis ON transaksis.id_stan = w.stan_id AND transaksis.deleted_at IS NULL
+			AND transaksis.tanggal >= w.baseline_start AND transaksis.tanggal < w.window_end
+		GROUP BY w.id_diskon, w.stan_id`, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[[2]uint{row.IDDiskon, row.StanID}] = row
+	}
+	return counts, nil
+}
+
+// SimulationStan represents the projected impact of a proposed discount on one stan
+type SimulationStan struct {
+	StanID            uint    `json:"stan_id"`
+	NamaStan          string  `json:"nama_stan"`
+	LinesAffected     int     `json:"lines_affected"`
+	CurrentRevenue    float64 `json:"current_revenue"`
+	CurrentSubsidi    float64 `json:"current_subsidi"`
+	ProjectedSubsidi  float64 `json:"projected_subsidi"`
+	AdditionalSubsidi float64 `json:"additional_subsidi"`
+	ProjectedRevenue  float64 `json:"projected_revenue"`
+}
+
+// SimulationMenu represents the projected impact of a proposed discount on one menu
+type SimulationMenu struct {
+	MenuID            uint    `json:"menu_id"`
+	NamaMakanan       string  `json:"nama_makanan"`
+	StanID            uint    `json:"stan_id"`
+	QtyAffected       int     `json:"qty_affected"`
+	CurrentSubsidi    float64 `json:"current_subsidi"`
+	ProjectedSubsidi  float64 `json:"projected_subsidi"`
+	AdditionalSubsidi float64 `json:"additional_subsidi"`
+}
+
+// DiskonSimulation is the result of replaying historical transactions with a proposed discount
+type DiskonSimulation struct {
+	StartDate         time.Time        `json:"start_date"`
+	EndDate           time.Time        `json:"end_date"`
+	LinesReplayed     int              `json:"lines_replayed"`
+	LinesAffected     int              `json:"lines_affected"`
+	CurrentSubsidi    float64          `json:"current_subsidi"`
+	ProjectedSubsidi  float64          `json:"projected_subsidi"`
+	AdditionalSubsidi float64          `json:"additional_subsidi"`
+	PerStan           []SimulationStan `json:"per_stan"`
+	PerMenu           []SimulationMenu `json:"per_menu"`
+}
+
+// SimulateDiskon replays the transactions in the date range through the pricing engine with the
+// proposed discount added to the discounts that were active at the time, and projects the subsidy.
+// The proposed discount is treated as active for the whole range and is never persisted.
+// Competing discounts that are switched off or have spent their budget are left out, since
+// checkout would not apply them either.
+func (s *SuperadminService) SimulateDiskon(proposed models.Diskon, startDate, endDate time.Time) (*DiskonSimulation, error) {
+	if proposed.IDStan != nil {
+		if err := s.db.Select("id").First(&models.Stan{}, *proposed.IDStan).Error; err != nil {
+			return nil, err
+		}
+	}
+	proposed.TanggalAwal = startDate
+	proposed.TanggalAkhir = endDate
+	proposed.IsActive = true
+
+	var lines []struct {
+		IDMenu    uint
+		Qty       int
+		HargaBeli float64
+		HargaAsli float64
+		Potongan  float64
+		Tanggal   time.Time
+		IDStan    uint
+	}
+	err := s.db.Model(&models.DetailTransaksi{}).
+		Select("detail_transaksis.id_menu, detail_transaksis.qty, detail_transaksis.harga_beli, "+
+			"detail_transaksis.harga_asli, detail_transaksis.potongan, transaksis.tanggal, transaksis.id_stan").
+		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id AND transaksis.deleted_at IS NULL").
+		Where("transaksis.tanggal BETWEEN ? AND ?", startDate, endDate).
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Existing discounts that overlap the range, evaluated per line at its transaction time
+	var existing []models.Diskon
+	err = s.db.Preload("MenuDiskon").
+		Where("tanggal_awal <= ? AND tanggal_akhir >= ? AND is_active = ?", endDate, startDate, true).
+		Where(diskonBudgetLeft).
+		Find(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+
+	menuIDs := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		menuIDs = append(menuIDs, line.IDMenu)
+	}
+	var menus []models.Menu
+	if len(menuIDs) > 0 {
+		if err := s.db.Unscoped().Where("id IN ?", menuIDs).Find(&menus).Error; err != nil {
+			return nil, err
+		}
+	}
+	menuByID := make(map[uint]*models.Menu, len(menus))
+	for i := range menus {
+		menuByID[menus[i].ID] = &menus[i]
+	}
+
+	revenues, err := s.GetAllStanRevenue(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiskonSimulation{StartDate: startDate, EndDate: endDate}
+	stanIndex := make(map[uint]int)
+	for _, revenue := range revenues {
+		stanIndex[revenue.StanID] = len(result.PerStan)
+		result.PerStan = append(result.PerStan, SimulationStan{
+			StanID:         revenue.StanID,
+			NamaStan:       revenue.NamaStan,
+			CurrentRevenue: revenue.TotalRevenue,
+		})
+	}
+	menuIndex := make(map[uint]int)
+
+	for _, line := range lines {
+		menu, ok := menuByID[line.IDMenu]
+		if !ok {
+			continue
+		}
+		result.LinesReplayed++
+
+		hargaDasar := line.HargaAsli
+		if hargaDasar == 0 {
+			hargaDasar = line.HargaBeli
+		}
+
+		candidates := []models.Diskon{proposed}
+		for _, d := range existing {
+			if !d.TanggalAwal.After(line.Tanggal) && !d.TanggalAkhir.Before(line.Tanggal) {
+				candidates = append(candidates, d)
+			}
+		}
+
+		quote := QuotePrice(menu, hargaDasar, candidates)
+		if quote.Diskon == nil || quote.Diskon.ID != 0 {
+			// The proposed discount does not win for this line
 			continue
 		}
-		statistics = append(statistics, *stat)
+
+		projected := quote.Potongan * float64(line.Qty)
+		additional := projected - line.Potongan
+
+		result.LinesAffected++
+		result.CurrentSubsidi += line.Potongan
+		result.ProjectedSubsidi += projected
+		result.AdditionalSubsidi += additional
+
+		i, ok := stanIndex[line.IDStan]
+		if !ok {
+			stanIndex[line.IDStan] = len(result.PerStan)
+			result.PerStan = append(result.PerStan, SimulationStan{StanID: line.IDStan})
+			i = len(result.PerStan) - 1
+		}
+		result.PerStan[i].LinesAffected++
+		result.PerStan[i].CurrentSubsidi += line.Potongan
+		result.PerStan[i].ProjectedSubsidi += projected
+		result.PerStan[i].AdditionalSubsidi += additional
+
+		j, ok := menuIndex[line.IDMenu]
+		if !ok {
+			menuIndex[line.IDMenu] = len(result.PerMenu)
+			result.PerMenu = append(result.PerMenu, SimulationMenu{
+				MenuID:      menu.ID,
+				NamaMakanan: menu.NamaMakanan,
+				StanID:      menu.IDStan,
+			})
+			j = len(result.PerMenu) - 1
+		}
+		result.PerMenu[j].QtyAffected += line.Qty
+		result.PerMenu[j].CurrentSubsidi += line.Potongan
+		result.PerMenu[j].ProjectedSubsidi += projected
+		result.PerMenu[j].AdditionalSubsidi += additional
 	}
 
-	return statistics, nil
+	for i := range result.PerStan {
+		result.PerStan[i].ProjectedRevenue = result.PerStan[i].CurrentRevenue - result.PerStan[i].AdditionalSubsidi
+	}
+
+	return result, nil
+}
+
+// SetReviewHidden hides or unhides a review, e.g. when it is abusive
+func (s *SuperadminService) SetReviewHidden(reviewID uint, hidden bool, alasan string) error {
+	return s.reviewService.SetHidden(reviewID, hidden, alasan)
+}
+
+// GetStockOpnames retrieves a page of stock opnames of all stans for audit.
+// stanID and status filter the list when set.
+func (s *SuperadminService) GetStockOpnames(stanID uint, status string, limit, offset int) ([]models.StockOpname, int64, error) {
+	return s.opnameService.GetAll(stanID, status, limit, offset)
+}
+
+// GetStockOpname retrieves a stock opname with its counted items
+func (s *SuperadminService) GetStockOpname(id uint) (*models.StockOpname, error) {
+	return s.opnameService.GetByID(id)
+}
+
+// GetSalesTimeSeries buckets sales in the period and compares them with the previous period.
+// stanID 0 covers all stans.
+func (s *SuperadminService) GetSalesTimeSeries(stanID uint, bucket Bucket, startDate, endDate time.Time) (*TimeSeries, error) {
+	return s.analyticsService.GetTimeSeries(stanID, bucket, startDate, endDate)
+}
+
+// GetMenuPerformance ranks menus by sales in the period and lists the menus that did not sell.
+// stanID 0 covers all stans.
+func (s *SuperadminService) GetMenuPerformance(stanID uint, sortBy MenuSort, startDate, endDate time.Time) (*MenuPerformanceReport, error) {
+	return s.analyticsService.GetMenuPerformance(stanID, sortBy, startDate, endDate)
+}
+
+// GetPeakHours builds the weekday × hour matrix of orders and preparation times in the period.
+// stanID 0 covers all stans.
+func (s *SuperadminService) GetPeakHours(stanID uint, startDate, endDate time.Time) (*PeakHourHeatmap, error) {
+	return s.analyticsService.GetPeakHours(stanID, startDate, endDate)
+}
+
+// ExportTransactions streams the transactions in the period to w and closes it with the totals.
+// stanID 0 covers all stans.
+func (s *SuperadminService) ExportTransactions(stanID uint, startDate, endDate time.Time, w utils.ReportWriter) error {
+	return s.analyticsService.ExportTransactions(stanID, startDate, endDate, w)
 }
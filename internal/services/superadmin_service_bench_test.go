@@ -0,0 +1,200 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Benchmarks the grouped revenue and statistics queries against the previous per-stan
+// loop on a seeded dataset. Set BENCH_DATABASE_DSN to a PostgreSQL database to run them:
+//
+//	BENCH_DATABASE_DSN="host=localhost user=postgres dbname=swipeup_bench sslmode=disable" \
+//		go test ./internal/services -run '^$' -bench StanRevenue -bench StanStatistics
+//
+// The data is seeded into a temporary schema that is dropped afterwards.
+
+const (
+	benchSchema       = "swipeup_bench"
+	benchStans        = 50
+	benchMenusPerStan = 20
+	benchOrders       = 20000
+)
+
+// openBenchDB connects to BENCH_DATABASE_DSN and seeds the tables the reports read
+func openBenchDB(b *testing.B) *gorm.DB {
+	b.Helper()
+
+	dsn := os.Getenv("BENCH_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	// One connection so the search_path below applies to every query
+	sqlDB.SetMaxOpenConns(1)
+
+	statements := []string{
+		fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", benchSchema),
+		fmt.Sprintf("CREATE SCHEMA %s", benchSchema),
+		fmt.Sprintf("SET search_path TO %s", benchSchema),
+		`CREATE TABLE stans (
+			id SERIAL PRIMARY KEY, nama_stan VARCHAR(100) NOT NULL, deleted_at TIMESTAMP)`,
+		`CREATE TABLE menus (
+			id SERIAL PRIMARY KEY, id_stan INTEGER NOT NULL, nama_makanan VARCHAR(100) NOT NULL,
+			harga DOUBLE PRECISION NOT NULL, stock INTEGER DEFAULT 0, is_available BOOLEAN DEFAULT TRUE, deleted_at TIMESTAMP)`,
+		`CREATE TABLE transaksis (
+			id SERIAL PRIMARY KEY, tanggal TIMESTAMP NOT NULL, id_stan INTEGER NOT NULL, id_siswa INTEGER NOT NULL, deleted_at TIMESTAMP)`,
+		`CREATE TABLE detail_transaksis (
+			id SERIAL PRIMARY KEY, id_transaksi INTEGER NOT NULL, id_menu INTEGER NOT NULL,
+			qty INTEGER NOT NULL, harga_beli DOUBLE PRECISION NOT NULL, deleted_at TIMESTAMP)`,
+		`CREATE INDEX ON transaksis(id_stan)`,
+		`CREATE INDEX ON detail_transaksis(id_transaksi)`,
+		`CREATE INDEX ON menus(id_stan)`,
+		fmt.Sprintf(`INSERT INTO stans (nama_stan) SELECT 'Stan ' || i FROM generate_series(1, %d) i`, benchStans),
+		fmt.Sprintf(`INSERT INTO menus (id_stan, nama_makanan, harga, stock, is_available)
+			SELECT s.id, 'Menu ' || m, 5000 + m * 500, m %% 5, m %% 7 <> 0
+			FROM stans s, generate_series(1, %d) m`, benchMenusPerStan),
+		fmt.Sprintf(`INSERT INTO transaksis (tanggal, id_stan, id_siswa)
+			SELECT NOW() - (i %% 60) * INTERVAL '1 day', 1 + i %% %d, 1 + i %% 500
+			FROM generate_series(1, %d) i`, benchStans, benchOrders),
+		fmt.Sprintf(`INSERT INTO detail_transaksis (id_transaksi, id_menu, qty, harga_beli)
+			SELECT t.id, (t.id_stan - 1) * %[1]d + 1 + (t.id + k) %% %[1]d, 1 + k, 5000
+			FROM transaksis t, generate_series(0, 2) k`, benchMenusPerStan),
+		`ANALYZE`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			b.Fatalf("seed: %v", err)
+		}
+	}
+
+	b.Cleanup(func() {
+		db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", benchSchema))
+		sqlDB.Close()
+	})
+	return db
+}
+
+// allStanRevenuePerStan is the previous implementation: one revenue query per stan
+func allStanRevenuePerStan(s *SuperadminService, startDate, endDate time.Time) ([]StanRevenue, error) {
+	var stanIDs []uint
+	if err := s.db.Table("stans").Where("deleted_at IS NULL").Pluck("id", &stanIDs).Error; err != nil {
+		return nil, err
+	}
+	revenues := make([]StanRevenue, 0, len(stanIDs))
+	for _, stanID := range stanIDs {
+		revenue, err := s.GetRevenueByStanID(stanID, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		revenues = append(revenues, *revenue)
+	}
+	return revenues, nil
+}
+
+// allStanStatisticsPerStan is the previous implementation: menus and revenue queried per stan
+func allStanStatisticsPerStan(s *SuperadminService, startDate, endDate time.Time) ([]StanStatistics, error) {
+	var stanIDs []uint
+	if err := s.db.Table("stans").Where("deleted_at IS NULL").Pluck("id", &stanIDs).Error; err != nil {
+		return nil, err
+	}
+	statistics := make([]StanStatistics, 0, len(stanIDs))
+	for _, stanID := range stanIDs {
+		stat, err := s.GetStanStatistics(stanID, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		statistics = append(statistics, *stat)
+	}
+	return statistics, nil
+}
+
+func BenchmarkAllStanRevenue(b *testing.B) {
+	service := &SuperadminService{db: openBenchDB(b)}
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -30)
+
+	grouped, err := service.GetAllStanRevenue(startDate, endDate)
+	if err != nil {
+		b.Fatal(err)
+	}
+	perStan, err := allStanRevenuePerStan(service, startDate, endDate)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(grouped) != len(perStan) {
+		b.Fatalf("grouped returned %d stans, per-stan %d", len(grouped), len(perStan))
+	}
+	for i := range grouped {
+		if grouped[i].TotalOrders != perStan[i].TotalOrders || grouped[i].TotalRevenue != perStan[i].TotalRevenue {
+			b.Fatalf("stan %d: grouped %+v, per-stan %+v", grouped[i].StanID, grouped[i], perStan[i])
+		}
+	}
+
+	b.Run("grouped", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := service.GetAllStanRevenue(startDate, endDate); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("per_stan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := allStanRevenuePerStan(service, startDate, endDate); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkAllStanStatistics(b *testing.B) {
+	service := &SuperadminService{db: openBenchDB(b)}
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -30)
+
+	grouped, err := service.GetAllStanStatistics(startDate, endDate)
+	if err != nil {
+		b.Fatal(err)
+	}
+	perStan, err := allStanStatisticsPerStan(service, startDate, endDate)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(grouped) != len(perStan) {
+		b.Fatalf("grouped returned %d stans, per-stan %d", len(grouped), len(perStan))
+	}
+	for i := range grouped {
+		if grouped[i].TotalMenu != perStan[i].TotalMenu || grouped[i].AvailableMenu != perStan[i].AvailableMenu ||
+			grouped[i].TotalOrders != perStan[i].TotalOrders {
+			b.Fatalf("stan %d: grouped %+v, per-stan %+v", grouped[i].StanID, grouped[i], perStan[i])
+		}
+	}
+
+	b.Run("grouped", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := service.GetAllStanStatistics(startDate, endDate); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("per_stan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := allStanStatisticsPerStan(service, startDate, endDate); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"swipeup-be/internal/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestGetAllStanRevenueSkipsDeletedOrders(t *testing.T) {
+	db := openTestDB(t, &models.Stan{}, &models.Transaksi{}, &models.DetailTransaksi{})
+
+	stan := models.Stan{NamaStan: "Stan Bu Ani", NamaPemilik: "Ani"}
+	if err := db.Create(&stan).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	kept := models.Transaksi{Tanggal: now, IDStan: stan.ID, IDSiswa: 1}
+	deleted := models.Transaksi{Tanggal: now, IDStan: stan.ID, IDSiswa: 1}
+	if err := db.Create(&[]*models.Transaksi{&kept, &deleted}).Error; err != nil {
+		t.Fatal(err)
+	}
+	details := []models.DetailTransaksi{
+		{IDTransaksi: kept.ID, IDMenu: 1, Qty: 2, HargaBeli: 10000},
+		{IDTransaksi: deleted.ID, IDMenu: 1, Qty: 1, HargaBeli: 15000},
+	}
+	if err := db.Create(&details).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	revenues, err := NewSuperadminService(db).GetAllStanRevenue(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("GetAllStanRevenue: %v", err)
+	}
+	if len(revenues) != 1 || revenues[0].TotalRevenue != 20000 || revenues[0].TotalOrders != 1 {
+		t.Fatalf("revenues = %+v, want 20000 from 1 order", revenues)
+	}
+}
+
+func TestSimulateDiskonIgnoresInactiveAndSpentDiscounts(t *testing.T) {
+	db := openTestDB(t, &models.Stan{}, &models.Menu{}, &models.Transaksi{}, &models.DetailTransaksi{}, &models.Diskon{}, &models.MenuDiskon{})
+
+	stan := models.Stan{NamaStan: "Stan Bu Ani", NamaPemilik: "Ani"}
+	if err := db.Create(&stan).Error; err != nil {
+		t.Fatal(err)
+	}
+	menu := models.Menu{NamaMakanan: "Mie Ayam", Harga: 10000, Jenis: models.JenisMakanan, Stock: 5, IDStan: stan.ID}
+	if err := db.Create(&menu).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	order := models.Transaksi{Tanggal: now.Add(-time.Hour), IDStan: stan.ID, IDSiswa: 1}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	detail := models.DetailTransaksi{IDTransaksi: order.ID, IDMenu: menu.ID, Qty: 1, HargaBeli: 10000, HargaAsli: 10000}
+	if err := db.Create(&detail).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Both would beat the proposed discount if checkout still applied them
+	anggaran := 5000.0
+	inactive := models.Diskon{NamaDiskon: "Nonaktif", PersentaseDiskon: 50, TipeDiskon: models.DiskonGlobal,
+		TanggalAwal: now.Add(-2 * time.Hour), TanggalAkhir: now, IsActive: true}
+	spent := models.Diskon{NamaDiskon: "Habis", PersentaseDiskon: 50, TipeDiskon: models.DiskonGlobal,
+		TanggalAwal: now.Add(-2 * time.Hour), TanggalAkhir: now, AnggaranSubsidi: &anggaran, TotalSubsidi: anggaran, IsActive: true}
+	if err := db.Create(&[]*models.Diskon{&inactive, &spent}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&inactive).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewSuperadminService(db)
+	proposed := models.Diskon{PersentaseDiskon: 10, TipeDiskon: models.DiskonGlobal}
+	simulation, err := service.SimulateDiskon(proposed, now.Add(-2*time.Hour), now)
+	if err != nil {
+		t.Fatalf("SimulateDiskon: %v", err)
+	}
+	if simulation.LinesAffected != 1 || simulation.ProjectedSubsidi != 1000 {
+		t.Fatalf("simulation affected %d lines with subsidy %v, want 1 line with 1000", simulation.LinesAffected, simulation.ProjectedSubsidi)
+	}
+
+	unknown := uint(999)
+	proposed = models.Diskon{PersentaseDiskon: 10, TipeDiskon: models.DiskonStan, IDStan: &unknown}
+	if _, err := service.SimulateDiskon(proposed, now.Add(-2*time.Hour), now); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("unknown stan: err = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
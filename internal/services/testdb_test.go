@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Tests that need a database run against TEST_DATABASE_DSN and are skipped without it:
+//
+//	TEST_DATABASE_DSN="host=localhost user=postgres dbname=swipeup_test sslmode=disable" \
+//		go test ./internal/services
+//
+// Each test gets the tables of the given models in a temporary schema that is dropped afterwards.
+
+const testSchema = "swipeup_test"
+
+// openTestDB connects to TEST_DATABASE_DSN and creates the tables of the given models
+func openTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	// One connection so the search_path below applies to every query
+	sqlDB.SetMaxOpenConns(1)
+
+	statements := []string{
+		fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", testSchema),
+		fmt.Sprintf("CREATE SCHEMA %s", testSchema),
+		fmt.Sprintf("SET search_path TO %s", testSchema),
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("schema: %v", err)
+		}
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", testSchema))
+		sqlDB.Close()
+	})
+	return db
+}
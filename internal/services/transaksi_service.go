@@ -1,10 +1,15 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"swipeup-be/internal/models"
+	"swipeup-be/pkg/utils"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransaksiService struct {
@@ -18,11 +23,15 @@ func NewTransaksiService(db *gorm.DB) *TransaksiService {
 }
 
 func (s *TransaksiService) CreateWithDetails(transaksi *models.Transaksi, details []models.DetailTransaksi) error {
-	return s.GetDB().Transaction(func(tx *gorm.DB) error {
-		transaksi.Tanggal = time.Now()
+	var alerts []*StockAlert
+	err := s.GetDB().Transaction(func(tx *gorm.DB) error {
+		transaksi.Tanggal = time.Now().UTC()
 		if err := tx.Create(transaksi).Error; err != nil {
 			return err
 		}
+		if err := recordStatusChange(tx, transaksi.ID, transaksi.Status, transaksi.Tanggal); err != nil {
+			return err
+		}
 
 		for i := range details {
 			details[i].IDTransaksi = transaksi.ID
@@ -31,16 +40,186 @@ func (s *TransaksiService) CreateWithDetails(transaksi *models.Transaksi, detail
 			}
 		}
 
-		return nil
+		var err error
+		if alerts, err = deductStock(tx, details); err != nil {
+			return err
+		}
+
+		return recordDiskonUsage(tx, details)
 	})
+	if err != nil {
+		return err
+	}
+
+	dispatchStockAlerts(alerts...)
+	return nil
+}
+
+// PriceDetails prices order lines that were not made from a cart the way checkout prices
+// the cart: at the menu price plus the chosen option deltas, with the best active discount
+// of the stan. Prices, discounts and option snapshots sent by the client are replaced, so
+// only id_menu, qty, catatan and the chosen option IDs are taken from details.
+func (s *TransaksiService) PriceDetails(stanID uint, details []models.DetailTransaksi) ([]models.DetailTransaksi, error) {
+	menuIDs := make([]uint, 0, len(details))
+	for _, detail := range details {
+		if detail.Qty <= 0 {
+			return nil, fmt.Errorf("%w: qty must be greater than zero", ErrInvalidOrderItem)
+		}
+		menuIDs = append(menuIDs, detail.IDMenu)
+	}
+
+	var menus []models.Menu
+	if err := s.GetDB().Where("id IN ? AND id_stan = ?", menuIDs, stanID).Find(&menus).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Menu, len(menus))
+	for i := range menus {
+		byID[menus[i].ID] = &menus[i]
+	}
+
+	diskon, err := NewPricingService(s.GetDB()).GetActiveDiskonForStan(stanID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	optionService := NewMenuOptionService(s.GetDB())
+
+	priced := make([]models.DetailTransaksi, 0, len(details))
+	for _, detail := range details {
+		menu := byID[detail.IDMenu]
+		if menu == nil {
+			return nil, fmt.Errorf("%w: menu %d is not sold by this stan", ErrInvalidOrderItem, detail.IDMenu)
+		}
+		catatan, err := utils.SanitizeNote(detail.Catatan)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNote, err)
+		}
+
+		optionIDs := make([]uint, 0, len(detail.Options))
+		for _, option := range detail.Options {
+			optionIDs = append(optionIDs, option.IDOption)
+		}
+		options, err := optionService.ResolveSelection(menu.ID, optionIDs)
+		if err != nil {
+			return nil, err
+		}
+		hargaDasar := menu.Harga
+		for _, option := range options {
+			hargaDasar += option.HargaTambahan
+		}
+		priced = append(priced, pricedDetail(menu.ID, detail.Qty, catatan, options, QuotePrice(menu, hargaDasar, diskon)))
+	}
+	return priced, nil
+}
+
+// orderQuantities sums the ordered quantity per menu and per option. The IDs are returned
+// sorted, so rows are always locked in the same order and concurrent orders cannot deadlock.
+func orderQuantities(details []models.DetailTransaksi) (menuQty, optionQty map[uint]int, menuIDs, optionIDs []uint) {
+	menuQty = make(map[uint]int)
+	optionQty = make(map[uint]int)
+	for _, detail := range details {
+		if _, ok := menuQty[detail.IDMenu]; !ok {
+			menuIDs = append(menuIDs, detail.IDMenu)
+		}
+		menuQty[detail.IDMenu] += detail.Qty
+		for _, option := range detail.Options {
+			if _, ok := optionQty[option.IDOption]; !ok {
+				optionIDs = append(optionIDs, option.IDOption)
+			}
+			optionQty[option.IDOption] += detail.Qty
+		}
+	}
+	sort.Slice(menuIDs, func(i, j int) bool { return menuIDs[i] < menuIDs[j] })
+	sort.Slice(optionIDs, func(i, j int) bool { return optionIDs[i] < optionIDs[j] })
+	return menuQty, optionQty, menuIDs, optionIDs
+}
+
+// deductStock subtracts ordered quantities from menu stock and from tracked option stock.
+// Each update is conditional so concurrent checkouts cannot drive stock below zero.
+// Menu stock changes are recorded in the stock ledger as sales of the transaksi, and the
+// stock alerts they raise are returned for dispatch after commit. Menus with a recipe use
+// up their ingredients instead, and their stock is re-derived from what is left.
+func deductStock(tx *gorm.DB, details []models.DetailTransaksi) ([]*StockAlert, error) {
+	menuQty, optionQty, menuIDs, optionIDs := orderQuantities(details)
+	recipeMenus, err := recipeMenuIDs(tx, menuIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []*StockAlert
+	for _, menuID := range menuIDs {
+		if recipeMenus[menuID] {
+			continue
+		}
+		qty := menuQty[menuID]
+		result := tx.Model(&models.Menu{}).
+			Where("id = ? AND stock >= ?", menuID, qty).
+			Updates(map[string]interface{}{
+				"stock":        gorm.Expr("stock - ?", qty),
+				"is_available": gorm.Expr("stock - ? > 0", qty),
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, fmt.Errorf("%w: menu %d", ErrInsufficientStock, menuID)
+		}
+
+		var menu models.Menu
+		if err := tx.Select("id", "id_stan", "nama_makanan", "stock", "stok_minimum").First(&menu, menuID).Error; err != nil {
+			return nil, err
+		}
+		if err := recordStockMovement(tx, menuID, -qty, menu.Stock, StockChange{
+			Reason:      models.StockSale,
+			IDTransaksi: &details[0].IDTransaksi,
+		}); err != nil {
+			return nil, err
+		}
+		alert, err := raiseStockAlert(tx, menu, menu.Stock+qty, menu.Stock)
+		if err != nil {
+			return nil, err
+		}
+		if alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	// Options without tracked stock keep a NULL stock; stock - qty stays NULL for them
+	for _, optionID := range optionIDs {
+		qty := optionQty[optionID]
+		result := tx.Model(&models.MenuOption{}).
+			Where("id = ? AND (stock IS NULL OR stock >= ?)", optionID, qty).
+			Update("stock", gorm.Expr("stock - ?", qty))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, fmt.Errorf("%w: option %d", ErrInsufficientStock, optionID)
+		}
+	}
+
+	// Menus with a recipe use up ingredients, which changes the stock of every menu sharing them
+	ingredientIDs, err := deductIngredients(tx, menuQty)
+	if err != nil {
+		return nil, err
+	}
+	recipeAlerts, err := syncRecipeStock(tx, ingredientIDs, StockChange{
+		Reason:      models.StockSale,
+		IDTransaksi: &details[0].IDTransaksi,
+		Keterangan:  recipeStockNote,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(alerts, recipeAlerts...), nil
 }
 
 func (s *TransaksiService) GetBySiswaID(siswaID uint) ([]models.Transaksi, error) {
-	return s.FindWithCondition(map[string]interface{}{"id_siswa": siswaID}, "Stan", "Siswa", "DetailTransaksi", "DetailTransaksi.Menu")
+	return s.FindWithCondition(map[string]interface{}{"id_siswa": siswaID}, "Stan", "Siswa", "DetailTransaksi", "DetailTransaksi.Menu", "DetailTransaksi.Options")
 }
 
 func (s *TransaksiService) GetByStanID(stanID uint) ([]models.Transaksi, error) {
-	return s.FindWithCondition(map[string]interface{}{"id_stan": stanID}, "Stan", "Siswa", "DetailTransaksi", "DetailTransaksi.Menu")
+	return s.FindWithCondition(map[string]interface{}{"id_stan": stanID}, "Stan", "Siswa", "DetailTransaksi", "DetailTransaksi.Menu", "DetailTransaksi.Options")
 }
 
 func (s *TransaksiService) GetByStatus(status models.StatusTransaksi) ([]models.Transaksi, error) {
@@ -48,11 +227,34 @@ func (s *TransaksiService) GetByStatus(status models.StatusTransaksi) ([]models.
 }
 
 func (s *TransaksiService) UpdateStatus(id uint, status models.StatusTransaksi) error {
-	return s.GetDB().Model(&models.Transaksi{}).Where("id = ?", id).Update("status", status).Error
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		return updateStatus(tx, id, status)
+	})
+}
+
+// updateStatus changes a transaction's status and records it in the status history
+// when it actually changes
+func updateStatus(tx *gorm.DB, id uint, status models.StatusTransaksi) error {
+	result := tx.Model(&models.Transaksi{}).Where("id = ? AND status <> ?", id, status).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return recordStatusChange(tx, id, status, time.Now().UTC())
+}
+
+// recordStatusChange writes the time a transaction entered a status
+func recordStatusChange(tx *gorm.DB, id uint, status models.StatusTransaksi, at time.Time) error {
+	if status == "" {
+		status = models.StatusBelumDikonfirm
+	}
+	return tx.Create(&models.TransaksiStatusLog{IDTransaksi: id, Status: status, CreatedAt: at}).Error
 }
 
 func (s *TransaksiService) GetWithFullDetails(id uint) (*models.Transaksi, error) {
-	return s.FindByID(id, "Stan", "Siswa", "DetailTransaksi", "DetailTransaksi.Menu")
+	return s.FindByID(id, "Stan", "Siswa", "DetailTransaksi", "DetailTransaksi.Menu", "DetailTransaksi.Options")
 }
 
 func (s *TransaksiService) GetByDateRange(startDate, endDate time.Time) ([]models.Transaksi, error) {
@@ -63,9 +265,26 @@ func (s *TransaksiService) GetByDateRange(startDate, endDate time.Time) ([]model
 	return transaksi, err
 }
 
-// DeleteTransaksi soft deletes a transaction and its details (admin only)
+// DeleteTransaksi soft deletes a transaction and its details (admin only) and gives the
+// ordered stock back, recorded in the stock ledger as refunds of the transaksi
 func (s *TransaksiService) DeleteTransaksi(id uint) error {
-	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+	var alerts []*StockAlert
+	err := s.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Lock the transaksi so a concurrent delete cannot give the stock back twice
+		var transaksi models.Transaksi
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transaksi, id).Error; err != nil {
+			return err
+		}
+		var details []models.DetailTransaksi
+		if err := tx.Preload("Options").Where("id_transaksi = ?", id).Find(&details).Error; err != nil {
+			return err
+		}
+
+		var err error
+		if alerts, err = restoreStock(tx, id, details); err != nil {
+			return err
+		}
+
 		// Delete detail transaksi first
 		if err := tx.Where("id_transaksi = ?", id).Delete(&models.DetailTransaksi{}).Error; err != nil {
 			return err
@@ -73,9 +292,73 @@ func (s *TransaksiService) DeleteTransaksi(id uint) error {
 		// Delete transaksi
 		return tx.Delete(&models.Transaksi{}, id).Error
 	})
+	if err != nil {
+		return err
+	}
+
+	dispatchStockAlerts(alerts...)
+	return nil
+}
+
+// restoreStock reverses deductStock for the details of a transaksi: menu, option and
+// ingredient stock are given back and menu stock changes are recorded as refunds
+func restoreStock(tx *gorm.DB, transaksiID uint, details []models.DetailTransaksi) ([]*StockAlert, error) {
+	menuQty, optionQty, menuIDs, optionIDs := orderQuantities(details)
+	recipeMenus, err := recipeMenuIDs(tx, menuIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := StockChange{Reason: models.StockRefund, IDTransaksi: &transaksiID}
+	var alerts []*StockAlert
+	for _, menuID := range menuIDs {
+		if recipeMenus[menuID] {
+			continue
+		}
+		alert, err := adjustStock(tx, menuID, menuQty[menuID], refund)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue // The menu was deleted since the order
+		}
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+
+	// Options without tracked stock keep a NULL stock
+	for _, optionID := range optionIDs {
+		qty := optionQty[optionID]
+		err := tx.Model(&models.MenuOption{}).Where("id = ? AND stock IS NOT NULL", optionID).
+			Update("stock", gorm.Expr("stock + ?", qty)).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ingredientIDs, err := restoreIngredients(tx, menuQty)
+	if err != nil {
+		return nil, err
+	}
+	refund.Keterangan = recipeStockNote
+	recipeAlerts, err := syncRecipeStock(tx, ingredientIDs, refund)
+	if err != nil {
+		return nil, err
+	}
+	return append(alerts, recipeAlerts...), nil
 }
 
 // UpdateTransaksi updates transaction fields (admin only)
 func (s *TransaksiService) UpdateTransaksi(id uint, updates map[string]interface{}) error {
-	return s.GetDB().Model(&models.Transaksi{}).Where("id = ?", id).Updates(updates).Error
+	return s.GetDB().Transaction(func(tx *gorm.DB) error {
+		if status, ok := updates["status"].(string); ok {
+			delete(updates, "status")
+			if err := updateStatus(tx, id, models.StatusTransaksi(status)); err != nil {
+				return err
+			}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Transaksi{}).Where("id = ?", id).Updates(updates).Error
+	})
 }
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"swipeup-be/internal/models"
+	"testing"
+	"time"
+)
+
+func TestPriceDetailsIgnoresClientPrices(t *testing.T) {
+	db := openTestDB(t, &models.Menu{}, &models.Diskon{}, &models.MenuDiskon{}, &models.MenuOptionGroup{}, &models.MenuOption{})
+
+	stanID := uint(1)
+	menu := models.Menu{NamaMakanan: "Soto", Harga: 10000, Jenis: models.JenisMakanan, Stock: 5, IDStan: stanID}
+	other := models.Menu{NamaMakanan: "Bakso", Harga: 12000, Jenis: models.JenisMakanan, Stock: 5, IDStan: 2}
+	if err := db.Create(&[]*models.Menu{&menu, &other}).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	diskon := models.Diskon{NamaDiskon: "Hemat", PersentaseDiskon: 10, TipeDiskon: models.DiskonStan, IDStan: &stanID,
+		TanggalAwal: now.Add(-time.Hour), TanggalAkhir: now.Add(time.Hour), IsActive: true}
+	if err := db.Create(&diskon).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	foreignDiskon := uint(99)
+	details, err := NewTransaksiService(db).PriceDetails(stanID, []models.DetailTransaksi{
+		{IDMenu: menu.ID, Qty: 2, HargaBeli: 1, HargaAsli: 1, IDDiskon: &foreignDiskon, Potongan: 50000},
+	})
+	if err != nil {
+		t.Fatalf("PriceDetails: %v", err)
+	}
+	d := details[0]
+	if d.HargaAsli != 10000 || d.HargaBeli != 9000 || d.Potongan != 2000 {
+		t.Errorf("priced at harga_asli %v, harga_beli %v, potongan %v; want 10000, 9000, 2000", d.HargaAsli, d.HargaBeli, d.Potongan)
+	}
+	if d.IDDiskon == nil || *d.IDDiskon != diskon.ID {
+		t.Errorf("id_diskon = %v, want %d", d.IDDiskon, diskon.ID)
+	}
+
+	_, err = NewTransaksiService(db).PriceDetails(stanID, []models.DetailTransaksi{{IDMenu: other.ID, Qty: 1}})
+	if !errors.Is(err, ErrInvalidOrderItem) {
+		t.Errorf("menu of another stan: error = %v, want ErrInvalidOrderItem", err)
+	}
+}
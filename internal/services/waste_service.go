@@ -0,0 +1,324 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"swipeup-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WasteReasonSummary totals waste of one reason
+type WasteReasonSummary struct {
+	Alasan     models.WasteReason `json:"alasan"`
+	Entries    int                `json:"entries"`
+	NilaiWaste float64            `json:"nilai_waste"`
+}
+
+// WasteMenuSummary puts the waste of a menu next to its sales
+type WasteMenuSummary struct {
+	IDMenu      uint    `json:"id_menu"`
+	NamaMakanan string  `json:"nama_makanan"`
+	QtyWaste    float64 `json:"qty_waste"`
+	NilaiWaste  float64 `json:"nilai_waste"`
+	QtyTerjual  int     `json:"qty_terjual"`
+	Revenue     float64 `json:"revenue"`
+	RasioWaste  float64 `json:"rasio_waste"` // Nilai waste dibanding revenue, dalam persen
+}
+
+// WasteIngredientSummary totals the waste of an ingredient thrown away directly
+type WasteIngredientSummary struct {
+	IDIngredient uint    `json:"id_ingredient"`
+	Nama         string  `json:"nama"`
+	Satuan       string  `json:"satuan"`
+	QtyWaste     float64 `json:"qty_waste"`
+	NilaiWaste   float64 `json:"nilai_waste"`
+}
+
+// WasteReport is a stan's waste over a period alongside its revenue
+type WasteReport struct {
+	StartDate       time.Time                `json:"start_date"`
+	EndDate         time.Time                `json:"end_date"`
+	TotalNilaiWaste float64                  `json:"total_nilai_waste"`
+	TotalRevenue    float64                  `json:"total_revenue"`
+	RasioWaste      float64                  `json:"rasio_waste"` // Nilai waste dibanding revenue, dalam persen
+	PerAlasan       []WasteReasonSummary     `json:"per_alasan"`
+	PerMenu         []WasteMenuSummary       `json:"per_menu"`
+	PerIngredient   []WasteIngredientSummary `json:"per_ingredient"`
+}
+
+type WasteService struct {
+	db *gorm.DB
+}
+
+func NewWasteService(db *gorm.DB) *WasteService {
+	return &WasteService{db: db}
+}
+
+// Record saves a waste entry of the stan and takes the wasted quantity out of stock.
+// Menu waste is valued at the menu price; for a menu with a recipe its ingredients are
+// taken out instead and the waste is valued at ingredient cost. Ingredient waste is
+// valued at the average purchase price.
+func (s *WasteService) Record(entry *models.WasteEntry) error {
+	if (entry.IDMenu == nil) == (entry.IDIngredient == nil) {
+		return fmt.Errorf("%w: entry needs either id_menu or id_ingredient", ErrInvalidWaste)
+	}
+	if entry.Qty <= 0 {
+		return fmt.Errorf("%w: qty must be greater than zero", ErrInvalidWaste)
+	}
+	if !entry.Alasan.IsValid() {
+		return fmt.Errorf("%w: unknown alasan %q", ErrInvalidWaste, entry.Alasan)
+	}
+
+	var alerts []*StockAlert
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if entry.IDMenu != nil {
+			alerts, err = s.wasteMenu(tx, entry)
+		} else {
+			alerts, err = s.wasteIngredient(tx, entry)
+		}
+		if err != nil {
+			return err
+		}
+		entry.NilaiWaste = entry.Qty * entry.HargaSatuan
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	dispatchStockAlerts(alerts...)
+	return nil
+}
+
+// wasteMenu takes wasted portions of a menu out of stock and sets the entry's unit value
+func (s *WasteService) wasteMenu(tx *gorm.DB, entry *models.WasteEntry) ([]*StockAlert, error) {
+	if entry.Qty != math.Trunc(entry.Qty) {
+		return nil, fmt.Errorf("%w: qty of a menu must be a whole number", ErrInvalidWaste)
+	}
+	portions := int(entry.Qty)
+
+	var menu models.Menu
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND id_stan = ?", *entry.IDMenu, entry.IDStan).First(&menu).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var recipe []models.RecipeItem
+	if err := tx.Where("id_menu = ?", menu.ID).Find(&recipe).Error; err != nil {
+		return nil, err
+	}
+
+	if len(recipe) == 0 {
+		if portions > menu.Stock {
+			return nil, fmt.Errorf("%w: only %d in stock", ErrInvalidWaste, menu.Stock)
+		}
+		entry.HargaSatuan = menu.Harga
+		alert, err := adjustStock(tx, menu.ID, -portions, StockChange{
+			Reason:     models.StockWaste,
+			IDUser:     entry.IDUser,
+			Keterangan: wasteNote(entry),
+		})
+		return []*StockAlert{alert}, err
+	}
+
+	// Cooked portions of a recipe menu are not in stock, so their ingredients are wasted
+	ingredientIDs := make([]uint, 0, len(recipe))
+	for _, item := range recipe {
+		ingredientIDs = append(ingredientIDs, item.IDIngredient)
+	}
+	costs, err := ingredientUnitCosts(tx, ingredientIDs)
+	if err != nil {
+		return nil, err
+	}
+	entry.HargaSatuan = 0
+	for _, item := range recipe {
+		entry.HargaSatuan += item.Qty * costs[item.IDIngredient]
+	}
+
+	used, err := deductIngredients(tx, map[uint]int{menu.ID: portions})
+	if errors.Is(err, ErrInsufficientStock) {
+		return nil, fmt.Errorf("%w: not enough ingredients in stock", ErrInvalidWaste)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return syncRecipeStock(tx, used, recipeCorrection)
+}
+
+// wasteIngredient takes a wasted quantity of an ingredient out of stock and sets the entry's unit value
+func (s *WasteService) wasteIngredient(tx *gorm.DB, entry *models.WasteEntry) ([]*StockAlert, error) {
+	result := tx.Model(&models.Ingredient{}).
+		Where("id = ? AND id_stan = ?", *entry.IDIngredient, entry.IDStan).
+		Where("stock >= ?", entry.Qty).
+		Update("stock", gorm.Expr("stock - ?", entry.Qty))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var ingredient models.Ingredient
+		err := tx.Where("id = ? AND id_stan = ?", *entry.IDIngredient, entry.IDStan).First(&ingredient).Error
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: only %g %s in stock", ErrInvalidWaste, ingredient.Stock, ingredient.Satuan)
+	}
+
+	costs, err := ingredientUnitCosts(tx, []uint{*entry.IDIngredient})
+	if err != nil {
+		return nil, err
+	}
+	entry.HargaSatuan = costs[*entry.IDIngredient]
+	return syncRecipeStock(tx, []uint{*entry.IDIngredient}, recipeCorrection)
+}
+
+// wasteNote is the stock ledger note of a waste entry
+func wasteNote(entry *models.WasteEntry) string {
+	if entry.Keterangan == "" {
+		return string(entry.Alasan)
+	}
+	return string(entry.Alasan) + ": " + entry.Keterangan
+}
+
+// wastePeriod limits a query on the given created_at column to the period when both ends are set
+func wastePeriod(query *gorm.DB, column string, startDate, endDate time.Time) *gorm.DB {
+	if !startDate.IsZero() && !endDate.IsZero() {
+		return query.Where(column+" BETWEEN ? AND ?", startDate, endDate)
+	}
+	return query
+}
+
+// GetByStanID retrieves a page of the stan's waste entries in the period, newest first
+func (s *WasteService) GetByStanID(stanID uint, startDate, endDate time.Time, limit, offset int) ([]models.WasteEntry, int64, error) {
+	query := wastePeriod(s.db.Model(&models.WasteEntry{}).Where("id_stan = ?", stanID), "created_at", startDate, endDate)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.WasteEntry
+	err := query.
+		Preload("Menu", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Ingredient", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, total, err
+}
+
+// GetReport totals the stan's waste in the period by reason, by menu (next to the menu's
+// sales) and by ingredient. Without a period all waste and sales are included.
+func (s *WasteService) GetReport(stanID uint, startDate, endDate time.Time) (*WasteReport, error) {
+	report := &WasteReport{
+		StartDate:     startDate,
+		EndDate:       endDate,
+		PerAlasan:     []WasteReasonSummary{},
+		PerMenu:       []WasteMenuSummary{},
+		PerIngredient: []WasteIngredientSummary{},
+	}
+
+	err := wastePeriod(s.db.Model(&models.WasteEntry{}).Where("id_stan = ?", stanID), "created_at", startDate, endDate).
+		Select("alasan, COUNT(*) AS entries, COALESCE(SUM(nilai_waste), 0) AS nilai_waste").
+		Group("alasan").Order("nilai_waste DESC").Scan(&report.PerAlasan).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var menuWaste []struct {
+		IDMenu     uint
+		QtyWaste   float64
+		NilaiWaste float64
+	}
+	err = wastePeriod(s.db.Model(&models.WasteEntry{}).Where("id_stan = ? AND id_menu IS NOT NULL", stanID), "created_at", startDate, endDate).
+		Select("id_menu, SUM(qty) AS qty_waste, SUM(nilai_waste) AS nilai_waste").
+		Group("id_menu").Scan(&menuWaste).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var menuSales []struct {
+		IDMenu     uint
+		QtyTerjual int
+		Revenue    float64
+	}
+	err = wastePeriod(s.db.Model(&models.DetailTransaksi{}).
+		Joins("JOIN transaksis ON detail_transaksis.id_transaksi = transaksis.id AND transaksis.deleted_at IS NULL").
+		Where("transaksis.id_stan = ?", stanID), "transaksis.tanggal", startDate, endDate).
+		Select("detail_transaksis.id_menu, SUM(detail_transaksis.qty) AS qty_terjual, SUM(detail_transaksis.qty * detail_transaksis.harga_beli) AS revenue").
+		Group("detail_transaksis.id_menu").Scan(&menuSales).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byMenu := make(map[uint]*WasteMenuSummary)
+	summary := func(menuID uint) *WasteMenuSummary {
+		if byMenu[menuID] == nil {
+			byMenu[menuID] = &WasteMenuSummary{IDMenu: menuID}
+		}
+		return byMenu[menuID]
+	}
+	for _, row := range menuWaste {
+		menu := summary(row.IDMenu)
+		menu.QtyWaste = row.QtyWaste
+		menu.NilaiWaste = row.NilaiWaste
+	}
+	for _, row := range menuSales {
+		menu := summary(row.IDMenu)
+		menu.QtyTerjual = row.QtyTerjual
+		menu.Revenue = row.Revenue
+	}
+
+	menuIDs := make([]uint, 0, len(byMenu))
+	for menuID := range byMenu {
+		menuIDs = append(menuIDs, menuID)
+	}
+	if len(menuIDs) > 0 {
+		var menus []models.Menu
+		if err := s.db.Unscoped().Select("id", "nama_makanan").Where("id IN ?", menuIDs).Find(&menus).Error; err != nil {
+			return nil, err
+		}
+		for _, menu := range menus {
+			byMenu[menu.ID].NamaMakanan = menu.NamaMakanan
+		}
+	}
+	for _, menu := range byMenu {
+		menu.RasioWaste = wasteRatio(menu.NilaiWaste, menu.Revenue)
+		report.PerMenu = append(report.PerMenu, *menu)
+		report.TotalRevenue += menu.Revenue
+	}
+	sort.Slice(report.PerMenu, func(i, j int) bool {
+		if report.PerMenu[i].NilaiWaste != report.PerMenu[j].NilaiWaste {
+			return report.PerMenu[i].NilaiWaste > report.PerMenu[j].NilaiWaste
+		}
+		return report.PerMenu[i].IDMenu < report.PerMenu[j].IDMenu
+	})
+
+	err = wastePeriod(s.db.Model(&models.WasteEntry{}).
+		Joins("JOIN ingredients ON ingredients.id = waste_entries.id_ingredient").
+		Where("waste_entries.id_stan = ?", stanID), "waste_entries.created_at", startDate, endDate).
+		Select("waste_entries.id_ingredient, ingredients.nama, ingredients.satuan, SUM(waste_entries.qty) AS qty_waste, SUM(waste_entries.nilai_waste) AS nilai_waste").
+		Group("waste_entries.id_ingredient, ingredients.nama, ingredients.satuan").
+		Order("nilai_waste DESC").Scan(&report.PerIngredient).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reason := range report.PerAlasan {
+		report.TotalNilaiWaste += reason.NilaiWaste
+	}
+	report.RasioWaste = wasteRatio(report.TotalNilaiWaste, report.TotalRevenue)
+	return report, nil
+}
+
+// wasteRatio returns waste as a percentage of revenue, 0 without revenue
+func wasteRatio(nilaiWaste, revenue float64) float64 {
+	if revenue <= 0 {
+		return 0
+	}
+	return nilaiWaste / revenue * 100
+}
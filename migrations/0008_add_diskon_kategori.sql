@@ -0,0 +1,16 @@
+-- Migration: Add category-targeted discounts and menu tags
+-- Date: 2026-10-19
+
+-- Menu tags used for dynamic discount targeting (comma separated, lowercase)
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS tags VARCHAR(255);
+
+-- Category discount targets, membership is resolved at pricing time
+ALTER TABLE diskons ADD COLUMN IF NOT EXISTS target_jenis VARCHAR(20);
+ALTER TABLE diskons ADD COLUMN IF NOT EXISTS target_tag VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_diskons_target_jenis ON diskons(target_jenis);
+
+COMMENT ON COLUMN menus.tags IS 'Comma separated menu tags, e.g. pedas,favorit';
+COMMENT ON COLUMN diskons.tipe_diskon IS 'Type of discount: global, stan, menu, or kategori';
+COMMENT ON COLUMN diskons.target_jenis IS 'For kategori discount: jenis menu targeted (makanan/minuman), NULL for any';
+COMMENT ON COLUMN diskons.target_tag IS 'For kategori discount: menu tag targeted, empty for any';
@@ -0,0 +1,24 @@
+-- Migration: Record applied discounts per order line and add discount budget caps
+-- Date: 2026-10-19
+
+-- Applied discount per detail transaksi (not just nama_diskon text)
+ALTER TABLE detail_transaksis ADD COLUMN IF NOT EXISTS id_diskon INTEGER REFERENCES diskons(id) ON DELETE SET NULL;
+ALTER TABLE detail_transaksis ADD COLUMN IF NOT EXISTS harga_asli DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE detail_transaksis ADD COLUMN IF NOT EXISTS potongan DOUBLE PRECISION DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_detail_transaksis_id_diskon ON detail_transaksis(id_diskon);
+
+-- Budget caps, a discount is deactivated once its total subsidy reaches the cap
+ALTER TABLE diskons ADD COLUMN IF NOT EXISTS anggaran_subsidi DOUBLE PRECISION;
+ALTER TABLE diskons ADD COLUMN IF NOT EXISTS total_subsidi DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE diskons ADD COLUMN IF NOT EXISTS is_active BOOLEAN DEFAULT TRUE;
+
+UPDATE diskons SET is_active = TRUE WHERE is_active IS NULL;
+UPDATE diskons SET total_subsidi = 0 WHERE total_subsidi IS NULL;
+
+COMMENT ON COLUMN detail_transaksis.id_diskon IS 'Discount applied to this line at checkout';
+COMMENT ON COLUMN detail_transaksis.harga_asli IS 'Unit price before discount';
+COMMENT ON COLUMN detail_transaksis.potongan IS 'Total discount amount for this line (qty x unit discount)';
+COMMENT ON COLUMN diskons.anggaran_subsidi IS 'Optional subsidy budget cap, NULL for unlimited';
+COMMENT ON COLUMN diskons.total_subsidi IS 'Total subsidy spent so far';
+COMMENT ON COLUMN diskons.is_active IS 'Set to false automatically when the budget cap is spent';
@@ -0,0 +1,68 @@
+-- Migration: Add menu option groups (ukuran, level pedas, topping) with price modifiers
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS menu_option_groups (
+    id SERIAL PRIMARY KEY,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    nama_group VARCHAR(100) NOT NULL,
+    is_required BOOLEAN DEFAULT FALSE,
+    min_pilihan INTEGER DEFAULT 0,
+    max_pilihan INTEGER DEFAULT 1,
+    urutan INTEGER DEFAULT 0,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS menu_options (
+    id SERIAL PRIMARY KEY,
+    id_group INTEGER NOT NULL REFERENCES menu_option_groups(id) ON DELETE CASCADE,
+    nama_opsi VARCHAR(100) NOT NULL,
+    harga_tambahan DOUBLE PRECISION DEFAULT 0,
+    stock INTEGER,
+    is_available BOOLEAN DEFAULT TRUE,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+-- Options chosen for a cart item
+CREATE TABLE IF NOT EXISTS cart_options (
+    id SERIAL PRIMARY KEY,
+    id_cart INTEGER NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    id_option INTEGER NOT NULL REFERENCES menu_options(id) ON DELETE CASCADE
+);
+
+-- Snapshot of options chosen for an order line
+CREATE TABLE IF NOT EXISTS detail_transaksi_options (
+    id SERIAL PRIMARY KEY,
+    id_detail_transaksi INTEGER NOT NULL REFERENCES detail_transaksis(id) ON DELETE CASCADE,
+    id_option INTEGER NOT NULL,
+    nama_group VARCHAR(100),
+    nama_opsi VARCHAR(100),
+    harga_tambahan DOUBLE PRECISION DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_menu_option_groups_id_menu ON menu_option_groups(id_menu);
+CREATE INDEX IF NOT EXISTS idx_menu_option_groups_deleted_at ON menu_option_groups(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_menu_options_id_group ON menu_options(id_group);
+CREATE INDEX IF NOT EXISTS idx_menu_options_deleted_at ON menu_options(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_cart_options_id_cart ON cart_options(id_cart);
+CREATE INDEX IF NOT EXISTS idx_detail_transaksi_options_id_detail_transaksi ON detail_transaksi_options(id_detail_transaksi);
+
+-- The same menu may now be in the cart several times with different options
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS opsi_key VARCHAR(255) DEFAULT '';
+UPDATE carts SET opsi_key = '' WHERE opsi_key IS NULL;
+ALTER TABLE carts DROP CONSTRAINT IF EXISTS carts_id_siswa_id_menu_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_siswa_menu_opsi ON carts(id_siswa, id_menu, opsi_key);
+
+COMMENT ON COLUMN menu_option_groups.min_pilihan IS 'Minimum number of options to choose, at least 1 when is_required';
+COMMENT ON COLUMN menu_option_groups.max_pilihan IS 'Maximum number of options to choose';
+COMMENT ON COLUMN menu_options.harga_tambahan IS 'Price delta added to menu harga when chosen';
+COMMENT ON COLUMN menu_options.stock IS 'Optional stock for the option, NULL when not tracked';
+COMMENT ON COLUMN carts.opsi_key IS 'Sorted chosen option IDs, e.g. "3,7,12"';
@@ -0,0 +1,15 @@
+-- Migration: Add per-item notes to cart items and order lines
+-- Date: 2026-10-19
+
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS catatan VARCHAR(200) DEFAULT '';
+ALTER TABLE detail_transaksis ADD COLUMN IF NOT EXISTS catatan VARCHAR(200) DEFAULT '';
+
+UPDATE carts SET catatan = '' WHERE catatan IS NULL;
+UPDATE detail_transaksis SET catatan = '' WHERE catatan IS NULL;
+
+-- The same menu with the same options but a different note is a separate cart item
+DROP INDEX IF EXISTS idx_carts_siswa_menu_opsi;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_siswa_menu_opsi_catatan ON carts(id_siswa, id_menu, opsi_key, catatan);
+
+COMMENT ON COLUMN carts.catatan IS 'Student note for the stall, e.g. "tidak pedas"';
+COMMENT ON COLUMN detail_transaksis.catatan IS 'Student note carried over from the cart at checkout';
@@ -0,0 +1,43 @@
+-- Migration: Add menu bundles (paket hemat) composed of existing menus
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS menu_bundles (
+    id SERIAL PRIMARY KEY,
+    id_stan INTEGER NOT NULL REFERENCES stans(id) ON DELETE CASCADE,
+    nama_bundle VARCHAR(100) NOT NULL,
+    harga DOUBLE PRECISION NOT NULL,
+    foto VARCHAR(255),
+    deskripsi TEXT,
+    is_available BOOLEAN DEFAULT TRUE,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS menu_bundle_items (
+    id SERIAL PRIMARY KEY,
+    id_bundle INTEGER NOT NULL REFERENCES menu_bundles(id) ON DELETE CASCADE,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    qty INTEGER NOT NULL DEFAULT 1 CHECK (qty > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_menu_bundles_id_stan ON menu_bundles(id_stan);
+CREATE INDEX IF NOT EXISTS idx_menu_bundles_deleted_at ON menu_bundles(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_menu_bundle_items_id_bundle ON menu_bundle_items(id_bundle);
+
+-- A cart item is either a menu or a bundle
+ALTER TABLE carts ALTER COLUMN id_menu DROP NOT NULL;
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS id_bundle INTEGER REFERENCES menu_bundles(id) ON DELETE CASCADE;
+ALTER TABLE carts DROP CONSTRAINT IF EXISTS carts_menu_or_bundle;
+ALTER TABLE carts ADD CONSTRAINT carts_menu_or_bundle CHECK ((id_menu IS NULL) <> (id_bundle IS NULL));
+CREATE INDEX IF NOT EXISTS idx_carts_id_bundle ON carts(id_bundle);
+
+-- Bundle lines are expanded into component lines at checkout
+ALTER TABLE detail_transaksis ADD COLUMN IF NOT EXISTS id_bundle INTEGER REFERENCES menu_bundles(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_detail_transaksis_id_bundle ON detail_transaksis(id_bundle);
+
+COMMENT ON COLUMN menu_bundle_items.qty IS 'Quantity of the component menu per bundle';
+COMMENT ON COLUMN carts.id_bundle IS 'Bundle in cart, set instead of id_menu';
+COMMENT ON COLUMN detail_transaksis.id_bundle IS 'Bundle this component line was sold as part of; harga_beli is the prorated bundle price';
@@ -0,0 +1,20 @@
+-- Migration: Full-text and trigram indexes for menu search
+-- Date: 2026-10-19
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 'simple' config: menu names are mostly Indonesian, no stemming
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(nama_makanan, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(deskripsi, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_menus_search_vector ON menus USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_menus_nama_makanan_trgm ON menus USING GIN (nama_makanan gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_menus_deskripsi_trgm ON menus USING GIN (deskripsi gin_trgm_ops);
+
+-- Popularity sort aggregates sold quantity per menu
+CREATE INDEX IF NOT EXISTS idx_detail_transaksis_id_menu ON detail_transaksis(id_menu);
+
+COMMENT ON COLUMN menus.search_vector IS 'Generated full-text vector of nama_makanan (A) and deskripsi (B)';
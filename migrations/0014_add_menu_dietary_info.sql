@@ -0,0 +1,19 @@
+-- Migration: Add allergen and dietary information to menus and allergy profile to siswa
+-- Date: 2026-10-19
+
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS alergen VARCHAR(100) DEFAULT '';
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS is_vegetarian BOOLEAN DEFAULT FALSE;
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS is_halal BOOLEAN DEFAULT FALSE;
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS level_pedas INTEGER DEFAULT 0;
+
+ALTER TABLE menus DROP CONSTRAINT IF EXISTS menus_level_pedas_check;
+ALTER TABLE menus ADD CONSTRAINT menus_level_pedas_check CHECK (level_pedas BETWEEN 0 AND 5);
+
+ALTER TABLE siswas ADD COLUMN IF NOT EXISTS alergi VARCHAR(100) DEFAULT '';
+ALTER TABLE siswas ADD COLUMN IF NOT EXISTS blokir_alergen BOOLEAN DEFAULT FALSE;
+
+COMMENT ON COLUMN menus.alergen IS 'Comma separated allergens: kacang, susu, seafood, gluten';
+COMMENT ON COLUMN menus.is_halal IS 'Menu is halal-certified';
+COMMENT ON COLUMN menus.level_pedas IS 'Spice level from 0 (not spicy) to 5';
+COMMENT ON COLUMN siswas.alergi IS 'Comma separated allergens the student must avoid';
+COMMENT ON COLUMN siswas.blokir_alergen IS 'Block checkout instead of warning when the cart conflicts with alergi';
@@ -0,0 +1,25 @@
+-- Migration: Add per-menu availability schedules (days of week, time windows, date ranges)
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS menu_schedules (
+    id SERIAL PRIMARY KEY,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    hari VARCHAR(20) DEFAULT '',
+    jam_mulai VARCHAR(5) DEFAULT '',
+    jam_selesai VARCHAR(5) DEFAULT '',
+    tanggal_mulai TIMESTAMP,
+    tanggal_selesai TIMESTAMP,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_menu_schedules_id_menu ON menu_schedules(id_menu);
+CREATE INDEX IF NOT EXISTS idx_menu_schedules_deleted_at ON menu_schedules(deleted_at);
+
+COMMENT ON TABLE menu_schedules IS 'A menu with schedules is only available while one of them is active; without schedules it is always available';
+COMMENT ON COLUMN menu_schedules.hari IS 'Comma separated ISO weekdays, 1 = Senin ... 7 = Minggu; empty for every day';
+COMMENT ON COLUMN menu_schedules.jam_mulai IS 'Start of the time window (HH:MM, school timezone); empty for start of day';
+COMMENT ON COLUMN menu_schedules.jam_selesai IS 'End of the time window (HH:MM, school timezone, exclusive); empty for end of day';
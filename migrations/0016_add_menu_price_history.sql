@@ -0,0 +1,38 @@
+-- Migration: Add menu price history and scheduled price changes
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS menu_price_history (
+    id SERIAL PRIMARY KEY,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    harga_lama DOUBLE PRECISION NOT NULL,
+    harga_baru DOUBLE PRECISION NOT NULL,
+    sumber VARCHAR(20) NOT NULL,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_menu_price_history_id_menu ON menu_price_history(id_menu, created_at);
+
+COMMENT ON TABLE menu_price_history IS 'One row per change of menus.harga';
+COMMENT ON COLUMN menu_price_history.sumber IS 'manual, import or jadwal (applied scheduled change)';
+COMMENT ON COLUMN menu_price_history.changed_by IS 'User who made (or scheduled) the change; NULL for system changes';
+
+CREATE TABLE IF NOT EXISTS menu_price_changes (
+    id SERIAL PRIMARY KEY,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    harga_baru DOUBLE PRECISION NOT NULL,
+    berlaku_mulai TIMESTAMP NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    applied_at TIMESTAMP,
+    id_user INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_menu_price_changes_id_menu ON menu_price_changes(id_menu);
+CREATE INDEX IF NOT EXISTS idx_menu_price_changes_due ON menu_price_changes(status, berlaku_mulai);
+CREATE INDEX IF NOT EXISTS idx_menu_price_changes_deleted_at ON menu_price_changes(deleted_at);
+
+COMMENT ON COLUMN menu_price_changes.status IS 'pending, applied or cancelled';
+COMMENT ON COLUMN menu_price_changes.berlaku_mulai IS 'Time the new price takes effect; applied by the price scheduler';
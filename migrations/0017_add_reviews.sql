@@ -0,0 +1,46 @@
+-- Migration: Add student reviews of completed orders with denormalized ratings
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id SERIAL PRIMARY KEY,
+    id_transaksi INTEGER NOT NULL REFERENCES transaksis(id) ON DELETE CASCADE,
+    id_siswa INTEGER NOT NULL REFERENCES siswas(id) ON DELETE CASCADE,
+    id_stan INTEGER NOT NULL REFERENCES stans(id) ON DELETE CASCADE,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    komentar TEXT,
+    balasan TEXT,
+    balasan_at TIMESTAMP,
+    is_hidden BOOLEAN DEFAULT FALSE,
+    alasan_hidden VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_id_transaksi ON reviews(id_transaksi);
+CREATE INDEX IF NOT EXISTS idx_reviews_id_siswa ON reviews(id_siswa);
+CREATE INDEX IF NOT EXISTS idx_reviews_id_stan ON reviews(id_stan, created_at);
+CREATE INDEX IF NOT EXISTS idx_reviews_deleted_at ON reviews(deleted_at);
+
+CREATE TABLE IF NOT EXISTS review_items (
+    id SERIAL PRIMARY KEY,
+    id_review INTEGER NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    id_detail_transaksi INTEGER NOT NULL REFERENCES detail_transaksis(id) ON DELETE CASCADE,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    komentar TEXT,
+    UNIQUE (id_review, id_detail_transaksi)
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_items_id_review ON review_items(id_review);
+CREATE INDEX IF NOT EXISTS idx_review_items_id_menu ON review_items(id_menu);
+
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS rating_avg DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS rating_count INTEGER DEFAULT 0;
+ALTER TABLE stans ADD COLUMN IF NOT EXISTS rating_avg DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE stans ADD COLUMN IF NOT EXISTS rating_count INTEGER DEFAULT 0;
+
+COMMENT ON TABLE reviews IS 'One review per transaksi, allowed once the order status is sampai';
+COMMENT ON COLUMN reviews.is_hidden IS 'Hidden by a superadmin; excluded from listings and rating averages';
+COMMENT ON COLUMN menus.rating_avg IS 'Average item rating from visible reviews, refreshed when reviews change';
+COMMENT ON COLUMN stans.rating_avg IS 'Average order rating from visible reviews, refreshed when reviews change';
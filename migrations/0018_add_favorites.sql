@@ -0,0 +1,11 @@
+-- Migration: Add favorite menus per siswa
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS favorites (
+    id SERIAL PRIMARY KEY,
+    id_siswa INTEGER NOT NULL REFERENCES siswas(id) ON DELETE CASCADE,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorites_siswa_menu ON favorites(id_siswa, id_menu);
@@ -0,0 +1,6 @@
+-- Migration: Add indexes used by menu recommendations
+-- Date: 2026-10-19
+
+CREATE INDEX IF NOT EXISTS idx_detail_transaksis_id_transaksi ON detail_transaksis(id_transaksi);
+CREATE INDEX IF NOT EXISTS idx_transaksis_tanggal ON transaksis(tanggal);
+CREATE INDEX IF NOT EXISTS idx_transaksis_id_siswa_tanggal ON transaksis(id_siswa, tanggal);
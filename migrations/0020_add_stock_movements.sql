@@ -0,0 +1,28 @@
+-- Migration: Add stock movement ledger for menu stock changes
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    delta INTEGER NOT NULL,
+    stock_after INTEGER NOT NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('restock', 'sale', 'refund', 'waste', 'correction')),
+    id_transaksi INTEGER REFERENCES transaksis(id) ON DELETE SET NULL,
+    id_user INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    keterangan VARCHAR(255) DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_id_menu ON stock_movements(id_menu, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_id_transaksi ON stock_movements(id_transaksi);
+
+-- Opening balance so the ledger of existing menus sums to their current stock
+INSERT INTO stock_movements (id_menu, delta, stock_after, reason, keterangan)
+SELECT m.id, m.stock, m.stock, 'correction', 'saldo awal'
+FROM menus m
+WHERE m.stock <> 0
+  AND NOT EXISTS (SELECT 1 FROM stock_movements sm WHERE sm.id_menu = m.id);
+
+COMMENT ON TABLE stock_movements IS 'Ledger of menu stock changes; SUM(delta) per menu equals menus.stock';
+COMMENT ON COLUMN stock_movements.delta IS 'Applied change in stock, negative for reductions';
+COMMENT ON COLUMN stock_movements.id_user IS 'User who made the change; NULL for system changes';
@@ -0,0 +1,21 @@
+-- Migration: Add low-stock thresholds and stored notifications
+-- Date: 2026-10-19
+
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS stok_minimum INTEGER DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    id_user INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tipe VARCHAR(30) NOT NULL,
+    judul VARCHAR(150) NOT NULL,
+    pesan TEXT,
+    id_menu INTEGER REFERENCES menus(id) ON DELETE SET NULL,
+    is_read BOOLEAN DEFAULT FALSE,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_id_user ON notifications(id_user, is_read, created_at);
+
+COMMENT ON COLUMN menus.stok_minimum IS 'Low-stock threshold; an alert is raised when stock drops to or below it (0 disables)';
+COMMENT ON TABLE notifications IS 'Stored notifications per user, e.g. low_stock and sold_out alerts for stan admins';
@@ -0,0 +1,28 @@
+-- Migration: Add par levels and the daily stock reset
+-- Date: 2026-10-19
+
+ALTER TABLE menus ADD COLUMN IF NOT EXISTS stok_par INTEGER DEFAULT 0;
+ALTER TABLE stans ADD COLUMN IF NOT EXISTS jam_reset_stok VARCHAR(5) DEFAULT '05:00';
+
+CREATE TABLE IF NOT EXISTS stock_resets (
+    id SERIAL PRIMARY KEY,
+    id_stan INTEGER NOT NULL REFERENCES stans(id) ON DELETE CASCADE,
+    tanggal VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('applied', 'skipped')),
+    pemicu VARCHAR(20) DEFAULT '',
+    menu_count INTEGER DEFAULT 0,
+    id_user INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_resets_stan_tanggal ON stock_resets(id_stan, tanggal);
+
+-- Allow the daily reset as a stock ledger reason
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_reason_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_reason_check
+    CHECK (reason IN ('restock', 'sale', 'refund', 'waste', 'correction', 'reset'));
+
+COMMENT ON COLUMN menus.stok_par IS 'Stock the daily reset sets the menu back to; 0 means the menu is not reset';
+COMMENT ON COLUMN stans.jam_reset_stok IS 'Time of day (HH:MM, school timezone) of the automatic stock reset; empty disables it';
+COMMENT ON TABLE stock_resets IS 'One row per stan and school day that was reset to par or skipped';
@@ -0,0 +1,43 @@
+-- Migration: Add ingredients, menu recipes and ingredient purchases
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS ingredients (
+    id SERIAL PRIMARY KEY,
+    id_stan INTEGER NOT NULL REFERENCES stans(id) ON DELETE CASCADE,
+    nama VARCHAR(100) NOT NULL,
+    satuan VARCHAR(20) NOT NULL,
+    stock DOUBLE PRECISION DEFAULT 0 CHECK (stock >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredients_id_stan ON ingredients(id_stan);
+CREATE INDEX IF NOT EXISTS idx_ingredients_deleted_at ON ingredients(deleted_at);
+
+CREATE TABLE IF NOT EXISTS recipe_items (
+    id SERIAL PRIMARY KEY,
+    id_menu INTEGER NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    id_ingredient INTEGER NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    qty DOUBLE PRECISION NOT NULL CHECK (qty > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_items_menu_ingredient ON recipe_items(id_menu, id_ingredient);
+CREATE INDEX IF NOT EXISTS idx_recipe_items_id_ingredient ON recipe_items(id_ingredient);
+
+CREATE TABLE IF NOT EXISTS ingredient_purchases (
+    id SERIAL PRIMARY KEY,
+    id_ingredient INTEGER NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    qty DOUBLE PRECISION NOT NULL CHECK (qty > 0),
+    harga_total DOUBLE PRECISION DEFAULT 0,
+    supplier VARCHAR(100) DEFAULT '',
+    keterangan VARCHAR(255) DEFAULT '',
+    id_user INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_purchases_id_ingredient ON ingredient_purchases(id_ingredient, created_at);
+
+COMMENT ON TABLE ingredients IS 'Raw ingredients a stan keeps in stock, in their own unit (satuan)';
+COMMENT ON TABLE recipe_items IS 'Ingredient quantity per portion of a menu; menus with a recipe derive their stock from ingredient stock';
+COMMENT ON TABLE ingredient_purchases IS 'Purchases (restocks) of ingredients';
@@ -0,0 +1,39 @@
+-- Migration: Add stock opname (physical stocktake) sessions
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS stock_opnames (
+    id SERIAL PRIMARY KEY,
+    id_stan INTEGER NOT NULL REFERENCES stans(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    keterangan VARCHAR(255) DEFAULT '',
+    total_items INTEGER DEFAULT 0,
+    variance_items INTEGER DEFAULT 0,
+    nilai_selisih DOUBLE PRECISION DEFAULT 0,
+    id_user INTEGER NOT NULL REFERENCES users(id),
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_opnames_id_stan ON stock_opnames(id_stan, created_at);
+
+CREATE TABLE IF NOT EXISTS stock_opname_items (
+    id SERIAL PRIMARY KEY,
+    id_opname INTEGER NOT NULL REFERENCES stock_opnames(id) ON DELETE CASCADE,
+    id_menu INTEGER REFERENCES menus(id) ON DELETE SET NULL,
+    id_ingredient INTEGER REFERENCES ingredients(id) ON DELETE SET NULL,
+    nama VARCHAR(100) NOT NULL,
+    satuan VARCHAR(20) DEFAULT '',
+    stok_sistem DOUBLE PRECISION NOT NULL,
+    stok_fisik DOUBLE PRECISION NOT NULL,
+    selisih DOUBLE PRECISION NOT NULL,
+    harga_satuan DOUBLE PRECISION DEFAULT 0,
+    nilai_selisih DOUBLE PRECISION DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_opname_items_id_opname ON stock_opname_items(id_opname);
+
+COMMENT ON TABLE stock_opnames IS 'Physical stocktake sessions; kept after review for audit';
+COMMENT ON COLUMN stock_opname_items.selisih IS 'stok_fisik - stok_sistem at the time of counting';
+COMMENT ON COLUMN stock_opname_items.harga_satuan IS 'Menu price, or average purchase price per unit for ingredients';
@@ -0,0 +1,22 @@
+-- Migration: Add waste and spoilage entries
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS waste_entries (
+    id SERIAL PRIMARY KEY,
+    id_stan INTEGER NOT NULL REFERENCES stans(id) ON DELETE CASCADE,
+    id_menu INTEGER REFERENCES menus(id) ON DELETE SET NULL,
+    id_ingredient INTEGER REFERENCES ingredients(id) ON DELETE SET NULL,
+    qty DOUBLE PRECISION NOT NULL CHECK (qty > 0),
+    alasan VARCHAR(20) NOT NULL CHECK (alasan IN ('sisa_harian', 'kadaluarsa', 'rusak', 'salah_masak', 'lainnya')),
+    harga_satuan DOUBLE PRECISION DEFAULT 0,
+    nilai_waste DOUBLE PRECISION DEFAULT 0,
+    keterangan VARCHAR(255) DEFAULT '',
+    id_user INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_waste_entries_id_stan ON waste_entries(id_stan, created_at);
+CREATE INDEX IF NOT EXISTS idx_waste_entries_id_menu ON waste_entries(id_menu);
+
+COMMENT ON TABLE waste_entries IS 'Food and ingredients thrown away, with a reason code and value';
+COMMENT ON COLUMN waste_entries.harga_satuan IS 'Menu price, or ingredient cost per unit (per portion for menus with a recipe)';
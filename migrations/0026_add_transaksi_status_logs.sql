@@ -0,0 +1,19 @@
+-- Migration: Add transaction status history
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS transaksi_status_logs (
+    id SERIAL PRIMARY KEY,
+    id_transaksi INTEGER NOT NULL REFERENCES transaksis(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaksi_status_logs_id_transaksi ON transaksi_status_logs(id_transaksi, status);
+
+-- Existing orders only have their creation time; later status changes were not recorded
+INSERT INTO transaksi_status_logs (id_transaksi, status, created_at)
+SELECT t.id, 'belum dikonfirm', t.tanggal
+FROM transaksis t
+WHERE NOT EXISTS (SELECT 1 FROM transaksi_status_logs l WHERE l.id_transaksi = t.id);
+
+COMMENT ON TABLE transaksi_status_logs IS 'When each transaction entered a status, used for waiting and preparation time analytics';
@@ -0,0 +1,19 @@
+-- Migration: Add ingredient stock corrections
+-- Date: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS ingredient_movements (
+    id SERIAL PRIMARY KEY,
+    id_ingredient INTEGER NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    delta DOUBLE PRECISION NOT NULL,
+    stock_after DOUBLE PRECISION NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    id_user INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    keterangan VARCHAR(255) DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_movements_id_ingredient ON ingredient_movements(id_ingredient, created_at);
+
+COMMENT ON TABLE ingredient_movements IS 'Manual corrections of ingredient stock, e.g. from approved stock opnames';
+COMMENT ON COLUMN ingredient_movements.delta IS 'Applied change in stock, negative for reductions';
+COMMENT ON COLUMN ingredient_movements.id_user IS 'User who made the change; NULL for system changes';
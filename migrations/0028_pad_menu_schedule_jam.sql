@@ -0,0 +1,6 @@
+-- Migration: Pad menu schedule times to HH:MM
+-- Date: 2026-10-19
+
+-- Times like 9:30 were accepted before and do not compare correctly as strings
+UPDATE menu_schedules SET jam_mulai = '0' || jam_mulai WHERE jam_mulai ~ '^[0-9]:[0-9]{2}$';
+UPDATE menu_schedules SET jam_selesai = '0' || jam_selesai WHERE jam_selesai ~ '^[0-9]:[0-9]{2}$';
@@ -0,0 +1,42 @@
+-- Migration: Convert stored timestamps to UTC
+-- Date: 2026-10-19
+
+-- The timestamp columns have no timezone. Until this release the API wrote them in the
+-- server's local time; it now opens its sessions with TimeZone=UTC and writes UTC, so
+-- older rows would read several hours off. This shifts every existing timestamp from the
+-- old server timezone to UTC.
+--
+-- Set source_tz below to the timezone the API server ran in before the upgrade (the TZ of
+-- the host or container, usually the school's TIMEZONE). If it already ran in UTC, set
+-- 'UTC' and nothing changes. Run it once, before starting the new version: rows written
+-- by the new version are already UTC. The utc_timestamp_conversion table records the run,
+-- so running all migrations again does not shift the rows twice.
+DO $$
+DECLARE
+    source_tz TEXT := 'Asia/Jakarta';
+    col RECORD;
+BEGIN
+    IF to_regclass('utc_timestamp_conversion') IS NOT NULL THEN
+        RETURN;
+    END IF;
+
+    FOR col IN
+        SELECT c.table_name, c.column_name
+        FROM information_schema.columns c
+        JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+        WHERE c.table_schema = current_schema()
+          AND t.table_type = 'BASE TABLE'
+          AND c.data_type = 'timestamp without time zone'
+    LOOP
+        EXECUTE format(
+            'UPDATE %I SET %I = (%I AT TIME ZONE %L) AT TIME ZONE ''UTC'' WHERE %I IS NOT NULL',
+            col.table_name, col.column_name, col.column_name, source_tz, col.column_name
+        );
+    END LOOP;
+
+    CREATE TABLE utc_timestamp_conversion (
+        source_tz TEXT NOT NULL,
+        converted_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+    );
+    INSERT INTO utc_timestamp_conversion (source_tz) VALUES (source_tz);
+END $$;
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxNoteLength is the maximum number of characters in an order note
+const MaxNoteLength = 200
+
+// MaxReviewLength is the maximum number of characters in a review comment or reply
+const MaxReviewLength = 1000
+
+// MaxReasonLength is the maximum number of characters in a moderation reason
+const MaxReasonLength = 255
+
+var (
+	ErrNoteTooLong = errors.New("note is too long")
+	ErrNoteBlocked = errors.New("note contains a blocked word")
+)
+
+var (
+	blockedWordsMu sync.RWMutex
+	blockedWords   [][]string // Each entry is a word or a phrase split into words
+)
+
+// SetBlockedWords sets the words and phrases rejected in order notes (case-insensitive)
+func SetBlockedWords(words []string) {
+	normalized := make([][]string, 0, len(words))
+	for _, word := range words {
+		if phrase := noteWords(word); len(phrase) > 0 {
+			normalized = append(normalized, phrase)
+		}
+	}
+
+	blockedWordsMu.Lock()
+	blockedWords = normalized
+	blockedWordsMu.Unlock()
+}
+
+// SanitizeNote cleans an order note such as "tidak pedas" or "no ice".
+// Control characters are removed, whitespace is collapsed, and the note is
+// rejected when it is longer than MaxNoteLength or contains a blocked word.
+func SanitizeNote(note string) (string, error) {
+	return sanitizeText(note, MaxNoteLength, true)
+}
+
+// SanitizeReview cleans a review comment or reply the same way as SanitizeNote,
+// allowing up to MaxReviewLength characters
+func SanitizeReview(text string) (string, error) {
+	return sanitizeText(text, MaxReviewLength, true)
+}
+
+// SanitizeReason cleans a moderation reason the same way, allowing up to MaxReasonLength
+// characters. Blocked words are allowed since a reason may have to quote them.
+func SanitizeReason(text string) (string, error) {
+	return sanitizeText(text, MaxReasonLength, false)
+}
+
+func sanitizeText(note string, maxLength int, checkBlocked bool) (string, error) {
+	note = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, note)
+	note = SanitizeString(strings.Join(strings.Fields(note), " "))
+
+	if utf8.RuneCountInString(note) > maxLength {
+		return "", ErrNoteTooLong
+	}
+	if checkBlocked && containsBlockedWord(note) {
+		return "", ErrNoteBlocked
+	}
+	return note, nil
+}
+
+// noteWords splits text into lowercase words, dropping punctuation
+func noteWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsBlockedWord checks the note word by word so "assalamualaikum" does not match "ass".
+// A blocked phrase matches when its words appear one after another in the note.
+func containsBlockedWord(note string) bool {
+	blockedWordsMu.RLock()
+	defer blockedWordsMu.RUnlock()
+
+	if len(blockedWords) == 0 {
+		return false
+	}
+
+	words := noteWords(note)
+	for i := range words {
+		for _, blocked := range blockedWords {
+			if i+len(blocked) <= len(words) && slices.Equal(words[i:i+len(blocked)], blocked) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSanitizeNoteBlockedWords(t *testing.T) {
+	SetBlockedWords([]string{"ass", " Bodoh Sekali "})
+	defer SetBlockedWords(nil)
+
+	tests := []struct {
+		note    string
+		blocked bool
+	}{
+		{"tidak pedas", false},
+		{"assalamualaikum, tanpa es", false},
+		{"ASS!", true},
+		{"kamu bodoh sekali ya", true},
+		{"bodoh,  sekali", true},
+		{"sekali bodoh", false},
+		{"bodoh", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.note, func(t *testing.T) {
+			_, err := SanitizeNote(tt.note)
+			if blocked := errors.Is(err, ErrNoteBlocked); blocked != tt.blocked {
+				t.Fatalf("SanitizeNote(%q) error = %v, want blocked %v", tt.note, err, tt.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// FormatPDF is the PDF report format, next to FormatCSV and FormatXLSX
+const FormatPDF = "pdf"
+
+// ReportContentTypes maps each export format to its Content-Type
+var ReportContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatPDF:  "application/pdf",
+}
+
+// ReportHeader describes a report table. Period is shown under the title when set.
+type ReportHeader struct {
+	Title   string
+	Period  string
+	Columns []string
+}
+
+// ReportTable is a report that is already fully loaded
+type ReportTable struct {
+	ReportHeader
+	Rows   [][]interface{}
+	Totals []interface{} // Written after the rows when set
+}
+
+// ReportWriter writes a report row by row, so large reports need not be held in memory.
+// Cells may be string, int, int64, uint, float64 or time.Time.
+type ReportWriter interface {
+	WriteRow(row []interface{}) error
+	// Close writes the totals row when set and finishes the file
+	Close(totals []interface{}) error
+}
+
+// NewReportWriter starts a report in the given format. CSV rows are written to w as they
+// come. XLSX and PDF files are built in memory and only written to w on Close, so their
+// memory use grows with the number of rows; long periods are best exported as CSV.
+func NewReportWriter(w io.Writer, format string, header ReportHeader) (ReportWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReportWriter(w, header)
+	case FormatXLSX:
+		return newXLSXReportWriter(w, header)
+	case FormatPDF:
+		return newPDFReportWriter(w, header), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// WriteReport writes a loaded report in the given format
+func WriteReport(w io.Writer, format string, table *ReportTable) error {
+	writer, err := NewReportWriter(w, format, table.ReportHeader)
+	if err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		if err := writer.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return writer.Close(table.Totals)
+}
+
+// EscapeFormula prefixes text that starts like a formula (=, +, -, @, tab or carriage return)
+// with ' so spreadsheet apps show it instead of running it. Numbers are left as they are.
+func EscapeFormula(text string) string {
+	if text == "" || !strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return text
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return text
+	}
+	return "'" + text
+}
+
+// UnescapeFormula undoes EscapeFormula, so exported files can be imported again
+func UnescapeFormula(text string) string {
+	if len(text) > 1 && text[0] == '\'' && EscapeFormula(text[1:]) == text {
+		return text[1:]
+	}
+	return text
+}
+
+// formatReportCell renders a cell as text for CSV and PDF
+func formatReportCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.In(SchoolLocation()).Format("2006-01-02 15:04")
+	}
+	return fmt.Sprint(v)
+}
+
+// csvReportWriter keeps CSV files machine-readable: only the column row, the data and the totals
+type csvReportWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVReportWriter(w io.Writer, header ReportHeader) (*csvReportWriter, error) {
+	r := &csvReportWriter{writer: csv.NewWriter(w)}
+	if err := r.writer.Write(header.Columns); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *csvReportWriter) WriteRow(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = formatReportCell(v)
+		if _, ok := v.(string); ok {
+			record[i] = EscapeFormula(record[i])
+		}
+	}
+	return r.writer.Write(record)
+}
+
+func (r *csvReportWriter) Close(totals []interface{}) error {
+	if totals != nil {
+		if err := r.WriteRow(totals); err != nil {
+			return err
+		}
+	}
+	r.writer.Flush()
+	return r.writer.Error()
+}
+
+// xlsxReportWriter streams rows into a single-sheet workbook with the title above the table
+type xlsxReportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	bold   int
+	date   int
+	money  int
+	row    int
+}
+
+func newXLSXReportWriter(w io.Writer, header ReportHeader) (*xlsxReportWriter, error) {
+	f := excelize.NewFile()
+	r := &xlsxReportWriter{w: w, file: f}
+	if err := r.init(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *xlsxReportWriter) init(header ReportHeader) error {
+	const sheet = "Laporan"
+	if err := r.file.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	var err error
+	if r.bold, err = r.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		return err
+	}
+	dateFormat := "yyyy-mm-dd hh:mm"
+	if r.date, err = r.file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		return err
+	}
+	if r.money, err = r.file.NewStyle(&excelize.Style{NumFmt: 4}); err != nil { // #,##0.00
+		return err
+	}
+	if r.stream, err = r.file.NewStreamWriter(sheet); err != nil {
+		return err
+	}
+
+	if err := r.writeRow([]interface{}{excelize.Cell{StyleID: r.bold, Value: header.Title}}); err != nil {
+		return err
+	}
+	if header.Period != "" {
+		if err := r.writeRow([]interface{}{header.Period}); err != nil {
+			return err
+		}
+	}
+	r.row++ // Empty row between the title and the table
+
+	columns := make([]interface{}, len(header.Columns))
+	for i, column := range header.Columns {
+		columns[i] = excelize.Cell{StyleID: r.bold, Value: column}
+	}
+	return r.writeRow(columns)
+}
+
+func (r *xlsxReportWriter) writeRow(values []interface{}) error {
+	r.row++
+	cell, err := excelize.CoordinatesToCellName(1, r.row)
+	if err != nil {
+		return err
+	}
+	return r.stream.SetRow(cell, values)
+}
+
+// cells gives times and amounts a number format, and all cells the given style
+func (r *xlsxReportWriter) cells(row []interface{}, style int) []interface{} {
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		switch v := v.(type) {
+		case time.Time:
+			if v.IsZero() {
+				values[i] = nil
+				continue
+			}
+			// Excel has no timezones, so write the school's wall clock time
+			wall := v.In(SchoolLocation())
+			values[i] = excelize.Cell{StyleID: r.date, Value: time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, time.UTC)}
+		case float64:
+			values[i] = excelize.Cell{StyleID: r.money, Value: v}
+		case string:
+			values[i] = excelize.Cell{StyleID: style, Value: EscapeFormula(v)}
+		default:
+			if style != 0 {
+				values[i] = excelize.Cell{StyleID: style, Value: v}
+			} else {
+				values[i] = v
+			}
+		}
+	}
+	return values
+}
+
+func (r *xlsxReportWriter) WriteRow(row []interface{}) error {
+	return r.writeRow(r.cells(row, 0))
+}
+
+func (r *xlsxReportWriter) Close(totals []interface{}) error {
+	defer r.file.Close()
+	if totals != nil {
+		if err := r.writeRow(r.cells(totals, r.bold)); err != nil {
+			return err
+		}
+	}
+	if err := r.stream.Flush(); err != nil {
+		return err
+	}
+	return r.file.Write(r.w)
+}
+
+// pdfReportWriter lays out a simple table with the title and period on the first page
+// and the column row repeated on every page
+type pdfReportWriter struct {
+	w         io.Writer
+	pdf       *fpdf.Fpdf
+	columns   []string
+	width     float64
+	translate func(string) string
+}
+
+const (
+	pdfMargin    = 10.0
+	pdfRowHeight = 6.0
+)
+
+func newPDFReportWriter(w io.Writer, header ReportHeader) *pdfReportWriter {
+	orientation := "P"
+	if len(header.Columns) > 6 {
+		orientation = "L"
+	}
+	pdf := fpdf.New(orientation, "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.AliasNbPages("")
+
+	r := &pdfReportWriter{
+		w:         w,
+		pdf:       pdf,
+		columns:   header.Columns,
+		translate: pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+	pageWidth, _ := pdf.GetPageSize()
+	r.width = (pageWidth - 2*pdfMargin) / float64(max(len(header.Columns), 1))
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, r.translate(header.Title), "", 1, "L", false, 0, "")
+	if header.Period != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, r.translate(header.Period), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(2)
+	r.writeColumns()
+	return r
+}
+
+func (r *pdfReportWriter) writeColumns() {
+	r.pdf.SetFont("Helvetica", "B", 9)
+	r.pdf.SetFillColor(230, 230, 230)
+	for _, column := range r.columns {
+		r.pdf.CellFormat(r.width, pdfRowHeight, r.fit(column), "1", 0, "C", true, 0, "")
+	}
+	r.pdf.Ln(-1)
+	r.pdf.SetFont("Helvetica", "", 9)
+}
+
+// fit shortens text so it stays inside its column
+func (r *pdfReportWriter) fit(text string) string {
+	text = r.translate(text)
+	limit := r.width - 2
+	if r.pdf.GetStringWidth(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && r.pdf.GetStringWidth(string(runes)+"...") > limit {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func (r *pdfReportWriter) writeCells(row []interface{}) {
+	_, pageHeight := r.pdf.GetPageSize()
+	if r.pdf.GetY()+pdfRowHeight > pageHeight-2*pdfMargin {
+		r.pdf.AddPage()
+		r.writeColumns()
+	}
+	for i := range r.columns {
+		var v interface{}
+		if i < len(row) {
+			v = row[i]
+		}
+		align := "L"
+		switch v.(type) {
+		case int, int64, uint, float64:
+			align = "R"
+		}
+		r.pdf.CellFormat(r.width, pdfRowHeight, r.fit(formatReportCell(v)), "1", 0, align, false, 0, "")
+	}
+	r.pdf.Ln(-1)
+}
+
+func (r *pdfReportWriter) WriteRow(row []interface{}) error {
+	r.writeCells(row)
+	return r.pdf.Error()
+}
+
+func (r *pdfReportWriter) Close(totals []interface{}) error {
+	if totals != nil {
+		r.pdf.SetFont("Helvetica", "B", 9)
+		r.writeCells(totals)
+	}
+	return r.pdf.Output(r.w)
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTimezone is the school's timezone used for schedules and daily reports
+const DefaultTimezone = "Asia/Jakarta"
+
+var (
+	schoolLocationMu sync.RWMutex
+	schoolLocation   = loadLocation(DefaultTimezone)
+)
+
+// loadLocation loads an IANA timezone, falling back to WIB (UTC+7) when tzdata is unavailable
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// SetSchoolTimezone sets the timezone used by SchoolNow and SchoolLocation
+func SetSchoolTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+
+	schoolLocationMu.Lock()
+	schoolLocation = loc
+	schoolLocationMu.Unlock()
+	return nil
+}
+
+// SchoolLocation returns the school's timezone
+func SchoolLocation() *time.Location {
+	schoolLocationMu.RLock()
+	defer schoolLocationMu.RUnlock()
+	return schoolLocation
+}
+
+// SchoolNow returns the current time in the school's timezone
+func SchoolNow() time.Time {
+	return time.Now().In(SchoolLocation())
+}